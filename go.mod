@@ -1,6 +1,6 @@
 module github.com/speedoops/go-gqlrest
 
-go 1.15
+go 1.16
 
 require (
 	github.com/99designs/gqlgen v0.14.0
@@ -9,5 +9,8 @@ require (
 	github.com/stretchr/testify v1.7.0
 	github.com/vektah/gqlparser/v2 v2.2.0
 	github.com/zeromicro/go-zero v1.3.0
+	go.opentelemetry.io/otel v1.3.0
+	go.opentelemetry.io/otel/sdk v1.3.0
+	go.opentelemetry.io/otel/trace v1.3.0
 	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,53 @@
+package handlerx
+
+import (
+	"net/http"
+	"time"
+)
+
+// AccessLogEntry is the structured access log of a request
+type AccessLogEntry struct {
+	Method    string
+	Path      string
+	Operation string // the GraphQL operation of RESTful requests, or the URL path of GraphQL requests
+	Status    int    // the HTTP status
+	Code      int    // the response code of RESTful requests, or the HTTP status of GraphQL requests
+	Duration  time.Duration
+	Bytes     int // the bytes of the response body, before compression
+	RequestID string
+	Error     string // the original error messages of RESTful responses, see SetErrorSanitizer
+}
+
+var accessLogger func(AccessLogEntry)
+
+// SetAccessLogger sets a hook called once per request with its access log, after the response is
+// written, including the responses written by the panic recovery. Pass nil to remove the hook.
+func SetAccessLogger(logger func(AccessLogEntry)) {
+	accessLogger = logger
+}
+
+// logAccess reports the request r and its response recorded by rec to the access logger
+func logAccess(r *http.Request, rec *statusRecorder, dur time.Duration) {
+	if accessLogger == nil {
+		return
+	}
+	var errorMessage string
+	if state := responseStateFromContext(r.Context()); state != nil {
+		errorMessage = state.errorMessage
+	}
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	accessLogger(AccessLogEntry{
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Operation: observedOperation(r),
+		Status:    status,
+		Code:      rec.responseCode(),
+		Duration:  dur,
+		Bytes:     rec.bytes,
+		RequestID: RequestIDFromContext(r.Context()),
+		Error:     errorMessage,
+	})
+}
@@ -0,0 +1,59 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetAccessLogger(t *testing.T) {
+	setupTestMapping()
+	var entries []AccessLogEntry
+	SetAccessLogger(func(entry AccessLogEntry) {
+		entries = append(entries, entry)
+	})
+	defer SetAccessLogger(nil)
+
+	tests := []struct {
+		Name      string
+		Response  *graphql.Response
+		HTTPCode  bool
+		Status    int
+		Code      int
+		Operation string
+	}{
+		{Name: "success", Response: &graphql.Response{Data: json.RawMessage(`{"todos":[]}`)}, Status: http.StatusOK, Code: 0, Operation: "todos"},
+		{Name: "panic recovery", Response: &graphql.Response{Data: json.RawMessage(`{"todos":`)}, Status: http.StatusOK, Code: http.StatusInternalServerError, Operation: "todos"},
+		{Name: "panic recovery with http status", Response: &graphql.Response{Data: json.RawMessage(`{"todos":`)}, HTTPCode: true, Status: http.StatusInternalServerError, Code: http.StatusInternalServerError, Operation: "todos"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetHTTPStatusEnabled(tt.HTTPCode)
+			defer SetHTTPStatusEnabled(false)
+			entries = nil
+
+			r := withResponseState(WithRoute(httptest.NewRequest(http.MethodGet, "/todos?limit=1", nil), "/todos"))
+			r.Header.Set(RequestIDHeader, "req-1")
+			recorder := httptest.NewRecorder()
+			r = withRequestID(recorder, r)
+			w, observe := wrapMetrics(recorder, r)
+			writeJSON(w, r, tt.Response, true)
+			observe()
+
+			assert.Len(t, entries, 1)
+			entry := entries[0]
+			assert.Equal(t, http.MethodGet, entry.Method)
+			assert.Equal(t, "/todos", entry.Path)
+			assert.Equal(t, tt.Operation, entry.Operation)
+			assert.Equal(t, tt.Status, entry.Status)
+			assert.Equal(t, tt.Code, entry.Code)
+			assert.Equal(t, recorder.Body.Len(), entry.Bytes)
+			assert.Equal(t, "req-1", entry.RequestID)
+		})
+	}
+}
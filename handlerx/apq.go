@@ -0,0 +1,124 @@
+package handlerx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// PersistedQueryHashHeader is the request header of the SHA-256 hash of the automatic persisted query
+// of a RESTful request, see SetAutomaticPersistedQueryCache
+const PersistedQueryHashHeader = "X-Persisted-Query-Hash"
+
+var apqCache graphql.Cache
+
+// SetAutomaticPersistedQueryCache enables Automatic Persisted Queries (APQ) over REST, with cache storing the
+// queries by their hash, eg. lru.New(100) or a cache shared by the instances. A RESTful request with the
+// PersistedQueryHashHeader executes the cached query of the hash, with the request parameters passed as
+// its variables. If it's not cached, the request is responded with 404 PersistedQueryNotFound and the
+// client resends it with the full query in the `query` URL parameter to register it. The query must
+// select the single root field of the operation of the route, with its arguments passed as the variables
+// of the route parameters, other queries are rejected with 400.
+// Pass nil to disable it (default).
+func SetAutomaticPersistedQueryCache(cache graphql.Cache) {
+	apqCache = cache
+}
+
+// persistedQueryNotFoundError is returned when the automatic persisted query of a hash isn't cached
+type persistedQueryNotFoundError struct {
+	hash string
+}
+
+func (e *persistedQueryNotFoundError) Error() string {
+	return "PersistedQueryNotFound"
+}
+
+// isAutomaticPersistedQuery reports whether r is a RESTful request of an automatic persisted query
+func isAutomaticPersistedQuery(r *http.Request) bool {
+	return apqCache != nil && r.Header.Get(PersistedQueryHashHeader) != "" && isRESTRoute(r)
+}
+
+// automaticPersistedQuery returns the query of the hash of r for operationName, the query sent along with
+// the hash is registered if it matches the hash and the operation
+func automaticPersistedQuery(r *http.Request, operationName string, argTypes StringMap) (string, error) {
+	hash := strings.ToLower(r.Header.Get(PersistedQueryHashHeader))
+	query := r.URL.Query().Get("query")
+	if query == "" {
+		cached, ok := apqCache.Get(r.Context(), hash)
+		if query, _ = cached.(string); !ok || query == "" {
+			return "", &persistedQueryNotFoundError{hash: hash}
+		}
+		// the cache is shared by the routes, the query may be registered by another one
+		if err := checkPersistedQueryOperation(r, query, operationName, argTypes); err != nil {
+			return "", err
+		}
+		return query, nil
+	}
+
+	sum := sha256.Sum256([]byte(query))
+	if hex.EncodeToString(sum[:]) != hash {
+		return "", &paramError{"query", "mapping: persisted query hash " + hash + " does not match the query"}
+	}
+	if err := checkPersistedQueryOperation(r, query, operationName, argTypes); err != nil {
+		return "", err
+	}
+	apqCache.Add(r.Context(), hash, query)
+	return query, nil
+}
+
+// checkPersistedQueryOperation checks that query is a single operation of the type of r selecting the root
+// field operationName only, with its arguments passed as variables of argTypes (or toggles of its selection)
+func checkPersistedQueryOperation(r *http.Request, query string, operationName string, argTypes StringMap) error {
+	invalid := func(reason string) error {
+		return &paramError{"query", "mapping: persisted query is not an operation of " + operationName + ": " + reason}
+	}
+	doc, gqlErr := parser.ParseQuery(&ast.Source{Input: query})
+	if gqlErr != nil {
+		return invalid(gqlErr.Message)
+	}
+	if len(doc.Operations) != 1 {
+		return invalid("it must define a single operation")
+	}
+	op := doc.Operations[0]
+	operation := ast.Mutation
+	if isSubscription(operationName) {
+		operation = ast.Subscription
+	} else if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		operation = ast.Query
+	}
+	if op.Operation != operation {
+		return invalid("it must be a " + string(operation))
+	}
+	if len(op.SelectionSet) != 1 {
+		return invalid("it must select a single root field")
+	}
+	field, ok := op.SelectionSet[0].(*ast.Field)
+	if !ok || field.Name != operationName || (field.Alias != "" && field.Alias != field.Name) {
+		return invalid("it must select the root field " + operationName)
+	}
+
+	toggles := selectionToggles(query)
+	for _, def := range op.VariableDefinitions {
+		if _, ok := argTypes[def.Variable]; ok {
+			continue
+		}
+		if _, ok := toggles[def.Variable]; ok {
+			continue
+		}
+		return invalid("unknown variable $" + def.Variable)
+	}
+	for _, arg := range field.Arguments {
+		if arg.Value.Kind != ast.Variable || arg.Value.Raw != arg.Name {
+			return invalid("argument " + arg.Name + " must be passed as $" + arg.Name)
+		}
+		if _, ok := argTypes[arg.Name]; !ok {
+			return invalid("unknown argument " + arg.Name)
+		}
+	}
+	return nil
+}
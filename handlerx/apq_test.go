@@ -0,0 +1,97 @@
+package handlerx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// paramsExecutor records the params of the executed operations
+type paramsExecutor struct {
+	params []*graphql.RawParams
+}
+
+func (e *paramsExecutor) CreateOperationContext(ctx context.Context, params *graphql.RawParams) (*graphql.OperationContext, gqlerror.List) {
+	e.params = append(e.params, params)
+	op := &ast.OperationDefinition{Operation: ast.Query}
+	return &graphql.OperationContext{RawQuery: params.Query, Variables: params.Variables, Operation: op, Doc: &ast.QueryDocument{Operations: ast.OperationList{op}}}, nil
+}
+
+func (e *paramsExecutor) DispatchOperation(ctx context.Context, rc *graphql.OperationContext) (graphql.ResponseHandler, context.Context) {
+	return func(ctx context.Context) *graphql.Response {
+		return &graphql.Response{Data: json.RawMessage(`{"todos":[]}`)}
+	}, ctx
+}
+
+func (e *paramsExecutor) DispatchError(ctx context.Context, list gqlerror.List) *graphql.Response {
+	return &graphql.Response{Errors: list}
+}
+
+func TestSetAutomaticPersistedQueryCache(t *testing.T) {
+	setupTestMapping()
+	SetAutomaticPersistedQueryCache(lru.New(10))
+	defer SetAutomaticPersistedQueryCache(nil)
+
+	hashOf := func(query string) string {
+		sum := sha256.Sum256([]byte(query))
+		return hex.EncodeToString(sum[:])
+	}
+	query := "query($limit: Int) { todos(limit: $limit) { id } }"
+	hash := hashOf(query)
+	otherQuery := "query { users { id password } }"
+	extraQuery := "query($limit: Int) { todos(limit: $limit) { id } users { id } }"
+	literalQuery := "query { todos(limit: 1000000) { id } }"
+	mutationQuery := "mutation { todos { id } }"
+
+	tests := []struct {
+		Name     string
+		Hash     string
+		Query    string
+		Expected string
+		Executed bool
+	}{
+		{Name: "not cached", Hash: hash, Expected: `{"code":404,"message":"PersistedQueryNotFound","data":null}`},
+		{Name: "hash mismatch", Hash: "abc", Query: query, Expected: `{"code":400,"message":"json body could not be decoded: mapping: persisted query hash abc does not match the query","data":null}`},
+		{Name: "registered", Hash: hash, Query: query, Expected: `{"code":0,"data":[]}`, Executed: true},
+		{Name: "cached", Hash: hash, Expected: `{"code":0,"data":[]}`, Executed: true},
+		{Name: "other root field", Hash: hashOf(otherQuery), Query: otherQuery, Expected: `{"code":400,"message":"json body could not be decoded: mapping: persisted query is not an operation of todos: it must select the root field todos","data":null}`},
+		{Name: "several root fields", Hash: hashOf(extraQuery), Query: extraQuery, Expected: `{"code":400,"message":"json body could not be decoded: mapping: persisted query is not an operation of todos: it must select a single root field","data":null}`},
+		{Name: "literal argument", Hash: hashOf(literalQuery), Query: literalQuery, Expected: `{"code":400,"message":"json body could not be decoded: mapping: persisted query is not an operation of todos: argument limit must be passed as $limit","data":null}`},
+		{Name: "mutation", Hash: hashOf(mutationQuery), Query: mutationQuery, Expected: `{"code":400,"message":"json body could not be decoded: mapping: persisted query is not an operation of todos: it must be a query","data":null}`},
+		{Name: "other root field not cached", Hash: hashOf(otherQuery), Expected: `{"code":404,"message":"PersistedQueryNotFound","data":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			target := "/todos?limit=2"
+			if tt.Query != "" {
+				target += "&query=" + url.QueryEscape(tt.Query)
+			}
+			r := WithRoute(httptest.NewRequest(http.MethodGet, target, nil), "/todos")
+			r.Header.Set(PersistedQueryHashHeader, tt.Hash)
+			w := httptest.NewRecorder()
+			exec := &paramsExecutor{}
+			GET{}.Do(w, r, exec)
+
+			assert.Equal(t, tt.Expected, w.Body.String())
+			if tt.Executed {
+				assert.Len(t, exec.params, 1)
+				assert.Equal(t, query, exec.params[0].Query)
+				assert.Equal(t, map[string]interface{}{"limit": json.Number("2")}, exec.params[0].Variables)
+			} else {
+				assert.Empty(t, exec.params)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package handlerx
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+var streamingArrays bool
+
+// SetStreamingArrays makes successful RESTful JSON responses with an array `data` stream the array
+// element by element: the envelope prefix is written first, then each element is transformed (null
+// members omitted, large ints stringified, fields filtered) and written, then the envelope suffix,
+// so the transformed array is never held in memory. Other responses are marshaled as usual.
+func SetStreamingArrays(streaming bool) {
+	streamingArrays = streaming
+}
+
+// isStreamableArray reports whether the reshaped data of the response r to req is streamed element by element
+func isStreamableArray(req *http.Request, opName string, r *graphql.Response, data json.RawMessage) bool {
+	if !streamingArrays || len(r.Errors) > 0 || isRawOutput(req) {
+		return false
+	}
+	if _, ok := binaryFields[opName]; ok {
+		return false
+	}
+	if strictFieldFiltering && requestedFields(req, opName) != nil {
+		return false // unknown fields are reported before writing the response
+	}
+	if responseContentType(req) != "application/json" {
+		return false
+	}
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// writeStreamingArray writes the response with the array `data` element by element, see SetStreamingArrays
+func writeStreamingArray(w http.ResponseWriter, req *http.Request, opName string, response *RESTResponse) {
+	writeStatus(w, req, response, true)
+	var out io.Writer = w
+	if prettyJSON {
+		out = &indentWriter{w: w}
+	}
+	tree := requestedFields(req, opName)
+	n, err := response.writeTo(out, func(w io.Writer) error {
+		return writeArrayElements(w, response.Data, tree)
+	})
+	if err == nil {
+		return
+	}
+	if n == 0 {
+		// nothing has been written yet, let the recover block write an error envelope
+		panic(err)
+	}
+	// the envelope is partially written, there's nothing better to do than stop here
+	dbgPrintf("restful response aborted after %d bytes: %v", n, err)
+}
+
+// writeArrayElements transforms and writes the elements of the JSON array data to w one by one
+func writeArrayElements(w io.Writer, data json.RawMessage, tree fieldTree) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil { // [
+		return err
+	}
+	if _, err := w.Write([]byte{'['}); err != nil {
+		return err
+	}
+	for i := 0; dec.More(); i++ {
+		var elem json.RawMessage
+		if err := dec.Decode(&elem); err != nil {
+			return err
+		}
+		elem, err := transformValues(elem)
+		if err != nil {
+			return err
+		}
+		if tree != nil {
+			if elem, _, err = filterFields(elem, tree); err != nil {
+				return err
+			}
+		}
+		if i > 0 {
+			elem = append([]byte{','}, elem...)
+		}
+		if _, err := w.Write(elem); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil { // ]
+		return err
+	}
+	_, err := w.Write([]byte{']'})
+	return err
+}
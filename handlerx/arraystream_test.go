@@ -0,0 +1,59 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetStreamingArrays(t *testing.T) {
+	setupTestMapping()
+	SetStreamingArrays(true)
+	defer SetStreamingArrays(false)
+	defer SetOmitNulls(false)
+	defer SetFieldFiltering(false)
+	defer SetStrictFieldFiltering(false)
+
+	tests := []struct {
+		Name      string
+		OmitNulls bool
+		Fields    bool
+		Strict    bool
+		URL       string
+		Data      string
+		Expected  string
+	}{
+		{Name: "array", URL: "/todos", Data: `{"todos":[{"id":"1","text":null},{"id":"2","text":"y"}]}`, Expected: `{"code":0,"data":[{"id":"1","text":null},{"id":"2","text":"y"}]}`},
+		{Name: "empty array", URL: "/todos", Data: `{"todos":[]}`, Expected: `{"code":0,"data":[]}`},
+		{Name: "omit nulls", OmitNulls: true, URL: "/todos", Data: `{"todos":[{"id":"1","text":null},{"id":"2","text":"y"}]}`, Expected: `{"code":0,"data":[{"id":"1"},{"id":"2","text":"y"}]}`},
+		{Name: "fields", Fields: true, URL: "/todos?fields=text", Data: `{"todos":[{"id":"1","text":"x"},{"id":"2","text":"y"}]}`, Expected: `{"code":0,"data":[{"text":"x"},{"text":"y"}]}`},
+		{Name: "strict fields buffered", Fields: true, Strict: true, URL: "/todos?fields=title", Data: `{"todos":[{"id":"1","text":"x"}]}`, Expected: `{"code":422,"message":"unknown fields: title","data":null}`},
+		{Name: "object buffered", OmitNulls: true, URL: "/todos", Data: `{"todos":{"id":"1","text":null}}`, Expected: `{"code":0,"data":{"id":"1"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetOmitNulls(tt.OmitNulls)
+			SetFieldFiltering(tt.Fields)
+			SetStrictFieldFiltering(tt.Strict)
+			r := WithRoute(httptest.NewRequest(http.MethodGet, tt.URL, nil), "/todos")
+			w := httptest.NewRecorder()
+			writeJSON(w, r, &graphql.Response{Data: json.RawMessage(tt.Data)}, true)
+			assert.Equal(t, tt.Expected, w.Body.String())
+		})
+	}
+}
+
+func TestWriteArrayElements(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := writeArrayElements(w, json.RawMessage(`[{"id":1}, null ,[2]]`), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"id":1},null,[2]]`, w.Body.String())
+
+	err = writeArrayElements(httptest.NewRecorder(), json.RawMessage(`[{"id":1},`), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,176 @@
+package handlerx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// BatchRequest is a single sub-request of a batch request
+type BatchRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"` // eg. "/api/v1/todos?limit=10"
+	Body    json.RawMessage   `json:"body,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+var maxBatchSize = 20
+
+// SetMaxBatchSize sets the max number of sub-requests of a batch request, larger batches are
+// rejected with 413. Default is 20, 0 means unlimited.
+func SetMaxBatchSize(n int) {
+	maxBatchSize = n
+}
+
+type batchKey struct{}
+
+// batchHeaderExcludes are the headers of a batch request not inherited by its sub-requests
+var batchHeaderExcludes = []string{"Accept", "Accept-Encoding", "Content-Length", "Content-Type", "If-None-Match", "If-Match"}
+
+// NewBatchHandler returns a handler executing a JSON array of BatchRequest with router one by one,
+// eg. r.Post("/batch", NewBatchHandler(r)). The response is a JSON array of RESTful responses, in order.
+// Sub-requests are isolated, a failed one yields its own error envelope without aborting the rest.
+// Headers of the batch request (eg. Authorization) are inherited by the sub-requests.
+// The batch body is limited by SetMaxRequestBytes, like the body of each sub-request.
+func NewBatchHandler(router http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", jsonContentType)
+		isRESTful := true
+
+		if r.Context().Value(batchKey{}) != nil {
+			writeHeader(w, r, http.StatusBadRequest, isRESTful)
+			writeJSONError(w, r, http.StatusBadRequest, isRESTful, "batch requests can't be nested")
+			return
+		}
+
+		var batch []BatchRequest
+		limitRequestBody(w, r)
+		if err := jsonDecode(r.Body, &batch); err != nil {
+			if isBodyTooLarge(err) {
+				writeHeader(w, r, http.StatusRequestEntityTooLarge, isRESTful)
+				writeJSONErrorWithExtensions(w, r, http.StatusRequestEntityTooLarge, isRESTful, fmt.Sprintf("batch body exceeds %d bytes", maxRequestBytes),
+					map[string]interface{}{"reason": "BODY_TOO_LARGE", "retryable": false})
+				return
+			}
+			writeHeader(w, r, http.StatusBadRequest, isRESTful)
+			writeJSONErrorf(w, r, http.StatusBadRequest, isRESTful, "batch body could not be decoded: "+err.Error())
+			return
+		}
+		if maxBatchSize > 0 && len(batch) > maxBatchSize {
+			writeHeader(w, r, http.StatusRequestEntityTooLarge, isRESTful)
+			writeJSONErrorf(w, r, http.StatusRequestEntityTooLarge, isRESTful, "batch of %d requests exceeds %d", len(batch), maxBatchSize)
+			return
+		}
+
+		responses := make([]json.RawMessage, 0, len(batch))
+		for _, sub := range batch {
+			responses = append(responses, serveBatchRequest(router, r, sub))
+		}
+
+		b, err := jsonCodec.Marshal(responses)
+		if err != nil {
+			panic(err)
+		}
+		if _, err := w.Write(b); err != nil {
+			panic(err)
+		}
+	})
+}
+
+// serveBatchRequest executes a sub-request and returns its RESTful response
+func serveBatchRequest(router http.Handler, parent *http.Request, sub BatchRequest) (resp json.RawMessage) {
+	rec := &batchResponseWriter{header: make(http.Header)}
+	defer func() {
+		if err := recover(); err != nil {
+			var buf [4096]byte
+			n := runtime.Stack(buf[:], false)
+			ctxPrintf(parent, "batch request %s %s recover from panic: %v\n%v", sub.Method, sub.Path, err, string(buf[:n]))
+			resp = batchErrorResponse(http.StatusInternalServerError, internalErrorMessage)
+		}
+	}()
+
+	method := strings.ToUpper(sub.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	if !strings.HasPrefix(sub.Path, "/") {
+		return batchErrorResponse(http.StatusBadRequest, "batch request path must be absolute: "+sub.Path)
+	}
+
+	// reset the route context, so that sub-requests are routed from scratch
+	ctx := context.WithValue(parent.Context(), chi.RouteCtxKey, (*chi.Context)(nil))
+	ctx = context.WithValue(ctx, batchKey{}, true)
+	req, err := http.NewRequestWithContext(ctx, method, sub.Path, bytes.NewReader(sub.Body))
+	if err != nil {
+		return batchErrorResponse(http.StatusBadRequest, "batch request is invalid: "+err.Error())
+	}
+	req.RemoteAddr = parent.RemoteAddr
+	for k, v := range parent.Header {
+		req.Header[k] = v
+	}
+	for _, k := range batchHeaderExcludes {
+		req.Header.Del(k)
+	}
+	if len(sub.Body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range sub.Headers {
+		req.Header.Set(k, v)
+	}
+
+	router.ServeHTTP(rec, req)
+
+	body := bytes.TrimSpace(rec.body.Bytes())
+	if len(body) == 0 || !json.Valid(body) {
+		// eg. 404 of the router, or 204 No Content
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status < 300 {
+			return batchSuccessResponse()
+		}
+		return batchErrorResponse(status, http.StatusText(status))
+	}
+	return body
+}
+
+func batchSuccessResponse() json.RawMessage {
+	b, _ := json.Marshal(&RESTResponse{Code: successCode})
+	return b
+}
+
+func batchErrorResponse(code int, msg string) json.RawMessage {
+	b, _ := json.Marshal(&RESTResponse{Code: code, Message: msg})
+	return b
+}
+
+// batchResponseWriter buffers the response of a sub-request
+type batchResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *batchResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *batchResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *batchResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
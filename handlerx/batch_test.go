@@ -0,0 +1,78 @@
+package handlerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBatchHandler(t *testing.T) {
+	defer SetMaxBatchSize(20)
+	defer SetMaxRequestBytes(0)
+
+	r := chi.NewRouter()
+	r.Get("/todos/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if chi.URLParam(r, "id") == "0" {
+			panic("boom")
+		}
+		_, _ = w.Write([]byte(`{"code":0,"data":{"id":"` + chi.URLParam(r, "id") + `","auth":"` + r.Header.Get("Authorization") + `"}}`))
+	})
+	r.Post("/batch", NewBatchHandler(r).ServeHTTP)
+
+	serve := func(body string) string {
+		req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+		req.Header.Set("Authorization", "token")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	tests := []struct {
+		Name         string
+		MaxBatchSize int
+		MaxBytes     int64
+		Body         string
+		Expected     string
+	}{
+		{
+			Name:     "isolated sub-requests",
+			Body:     `[{"path":"/todos/1"},{"path":"/todos/0"},{"method":"DELETE","path":"/todos/1"},{"path":"/todos/2"}]`,
+			Expected: `[{"code":0,"data":{"id":"1","auth":"token"}},{"code":500,"message":"unexpected error: unmarshal or write response error","data":null},{"code":405,"message":"Method Not Allowed","data":null},{"code":0,"data":{"id":"2","auth":"token"}}]`,
+		},
+		{
+			Name:     "nested batch",
+			Body:     `[{"method":"POST","path":"/batch","body":[]}]`,
+			Expected: `[{"code":400,"message":"batch requests can't be nested","data":null}]`,
+		},
+		{
+			Name:         "too large",
+			MaxBatchSize: 2,
+			Body:         `[{"path":"/todos/1"},{"path":"/todos/2"},{"path":"/todos/3"}]`,
+			Expected:     `{"code":413,"message":"batch of 3 requests exceeds 2","data":null}`,
+		},
+		{
+			Name:     "body too large",
+			MaxBytes: 32,
+			Body:     `[{"path":"/todos/1"},{"path":"/todos/2"},{"path":"/todos/3"}]`,
+			Expected: `{"code":413,"message":"batch body exceeds 32 bytes","data":null}`,
+		},
+		{
+			Name:     "body within the limit",
+			MaxBytes: 64,
+			Body:     `[{"path":"/todos/1"}]`,
+			Expected: `[{"code":0,"data":{"id":"1","auth":"token"}}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetMaxBatchSize(tt.MaxBatchSize)
+			SetMaxRequestBytes(tt.MaxBytes)
+			assert.Equal(t, tt.Expected, serve(tt.Body))
+		})
+	}
+}
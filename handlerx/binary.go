@@ -0,0 +1,63 @@
+package handlerx
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// binaryField is the base64-encoded field of the responses of an operation written as raw bytes
+type binaryField struct {
+	name        string
+	contentType string
+}
+
+var binaryFields = make(map[string]binaryField)
+
+// SetBinaryField makes the successful RESTful responses of operation opName the base64-decoded bytes
+// of the string member fieldName of `data`, with a Content-Type contentType instead of the JSON
+// envelope, eg. for file downloads. An empty fieldName decodes `data` itself, and invalid base64 is
+// reported as 500. Errors and null `data` are still enveloped. Pass an empty contentType to remove it.
+func SetBinaryField(opName, fieldName, contentType string) {
+	if contentType == "" {
+		delete(binaryFields, opName)
+	} else {
+		binaryFields[opName] = binaryField{name: fieldName, contentType: contentType}
+	}
+}
+
+// writeBinaryField writes the binary field of the response of operation opName, it returns false
+// if opName has no binary field or there's nothing to decode
+func writeBinaryField(w http.ResponseWriter, req *http.Request, opName string, response *RESTResponse) bool {
+	field, ok := binaryFields[opName]
+	if !ok || isEmptyData(response.Data) {
+		return false
+	}
+
+	data := response.Data
+	if field.name != "" {
+		var found bool
+		if data, found = unwrapNamedField(data, field.name); !found || isEmptyData(data) {
+			return false
+		}
+	}
+	var encoded string
+	if err := jsonCodec.Unmarshal(data, &encoded); err != nil {
+		panic(fmt.Errorf("binary field %q of %s is not a string: %w", field.name, opName, err))
+	}
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		panic(fmt.Errorf("binary field %q of %s is not base64-encoded: %w", field.name, opName, err))
+	}
+
+	recordCode(req, response.Code)
+	setCacheControl(w, req, true)
+	w.Header().Set("Content-Type", field.contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+	writeStatus(w, req, response, true)
+	if _, err := w.Write(b); err != nil {
+		panic(err)
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestSetBinaryField(t *testing.T) {
+	setupTestMapping()
+	SetBinaryField("todo", "content", "application/pdf")
+	defer SetBinaryField("todo", "", "")
+
+	tests := []struct {
+		Name        string
+		Response    *graphql.Response
+		ContentType string
+		Body        string
+	}{
+		{
+			Name:        "decoded",
+			Response:    &graphql.Response{Data: json.RawMessage(`{"todo":{"content":"JVBERi0xLjQ="}}`)},
+			ContentType: "application/pdf",
+			Body:        "%PDF-1.4",
+		},
+		{
+			Name:        "invalid base64",
+			Response:    &graphql.Response{Data: json.RawMessage(`{"todo":{"content":"%%"}}`)},
+			ContentType: "application/json; charset=utf-8",
+			Body:        `{"code":500,"message":"unexpected error: unmarshal or write response error","data":null}`,
+		},
+		{
+			Name:        "null data",
+			Response:    &graphql.Response{Data: json.RawMessage(`{"todo":null}`)},
+			ContentType: "application/json; charset=utf-8",
+			Body:        `{"code":0,"data":null}`,
+		},
+		{
+			Name:        "error",
+			Response:    &graphql.Response{Errors: gqlerror.List{{Message: "not found", Extensions: map[string]interface{}{"code": 404}}}},
+			ContentType: "application/json; charset=utf-8",
+			Body:        `{"code":404,"message":"not found","data":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos/1", nil), "/todos/{id}", "1")
+			w := httptest.NewRecorder()
+			writeJSON(w, r, tt.Response, true)
+			assert.Equal(t, tt.ContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.Body, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package handlerx
+
+import (
+	"net/http"
+)
+
+var requestBodyTransforms = make(map[string]func([]byte) ([]byte, error))
+
+// SetRequestBodyTransform registers fn to normalize the raw body of RESTful requests of operation opName
+// before it's decoded, eg. to convert the numbers sent as strings by legacy clients.
+// An error of fn is responded with 400. Pass a nil fn to remove the transform.
+func SetRequestBodyTransform(opName string, fn func([]byte) ([]byte, error)) {
+	if fn == nil {
+		delete(requestBodyTransforms, opName)
+	} else {
+		requestBodyTransforms[opName] = fn
+	}
+}
+
+// transformRequestBody applies the request body transform of the operation of r to body
+func transformRequestBody(r *http.Request, body []byte) ([]byte, error) {
+	opName, ok := restOperationName(r)
+	if !ok {
+		return body, nil
+	}
+	fn, ok := requestBodyTransforms[opName]
+	if !ok || len(body) == 0 {
+		return body, nil
+	}
+	return fn(body)
+}
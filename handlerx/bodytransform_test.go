@@ -0,0 +1,50 @@
+package handlerx
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetRequestBodyTransform(t *testing.T) {
+	setupTestMapping()
+	SetRequestBodyTransform("createTodo", func(body []byte) ([]byte, error) {
+		if bytes.Contains(body, []byte("bad")) {
+			return nil, errors.New("unexpected legacy field")
+		}
+		return bytes.ReplaceAll(body, []byte(`"user_id"`), []byte(`"userId"`)), nil
+	})
+	defer SetRequestBodyTransform("createTodo", nil)
+
+	tests := []struct {
+		Name     string
+		Method   string
+		Pattern  string
+		Body     string
+		OK       bool
+		Expected string
+	}{
+		{Name: "transformed", Method: http.MethodPost, Pattern: "/todos", Body: `{"text":"x","user_id":"1"}`, OK: true, Expected: `{"text":"x","userId":"1"}`},
+		{Name: "transform error", Method: http.MethodPost, Pattern: "/todos", Body: `{"bad":1}`, Expected: `{"code":400,"message":"request body could not be transformed: unexpected legacy field","data":null}`},
+		{Name: "other operation", Method: http.MethodPut, Pattern: "/todos/{id}", Body: `{"user_id":"1"}`, OK: true, Expected: `{"user_id":"1"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(tt.Method, "/todos/1", strings.NewReader(tt.Body)), tt.Pattern, "1")
+			w := httptest.NewRecorder()
+			body, ok := readRequestBody(w, r)
+			assert.Equal(t, tt.OK, ok)
+			if ok {
+				assert.Equal(t, tt.Expected, string(body))
+			} else {
+				assert.Equal(t, tt.Expected, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package handlerx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// isBracketParam reports whether the query parameter k uses the bracket notation, eg. "filter[status]"
+func isBracketParam(k string) bool {
+	return strings.ContainsAny(k, "[]")
+}
+
+// parseBracketParam splits a query parameter in the bracket notation into its name and path,
+// eg. "filter[tags][]" is "filter" and ["tags", ""], the empty segment appends to a list.
+// Only the last segment may be empty.
+func parseBracketParam(k string) (string, []string, error) {
+	malformed := &paramError{k, fmt.Sprintf("mapping: malformed parameter %s", k)}
+	i := strings.IndexByte(k, '[')
+	if i <= 0 {
+		return "", nil, malformed
+	}
+	name, rest := k[:i], k[i:]
+	path := make([]string, 0)
+	for rest != "" {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end < 0 {
+			return "", nil, malformed
+		}
+		segment := rest[1:end]
+		if strings.ContainsAny(segment, "[") || (len(path) > 0 && path[len(path)-1] == "") {
+			return "", nil, malformed
+		}
+		path = append(path, segment)
+		rest = rest[end+1:]
+	}
+	return name, path, nil
+}
+
+// decodeBracketParams decodes the query parameters in the bracket notation into nested objects and lists,
+// eg. "filter[status]=active&filter[tags][]=a&filter[tags][]=b" is {"filter": {"status": "active", "tags": ["a", "b"]}}.
+// The leaves are kept as strings, they're coerced to the input field types like the other parameters.
+// The members of `input` are merged into inputParams.
+func decodeBracketParams(values map[string][]string, queryParams, inputParams map[string]interface{}) error {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		name, path, err := parseBracketParam(k)
+		if err != nil {
+			return err
+		}
+		if name == "input" {
+			if path[0] == "" {
+				return &paramError{k, fmt.Sprintf("mapping: malformed parameter %s", k)}
+			}
+			if err := setBracketValue(inputParams, k, path[0], path[1:], values[k]); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := setBracketValue(queryParams, k, name, path, values[k]); err != nil {
+			return err
+		}
+		inputParams[name] = queryParams[name]
+	}
+	return nil
+}
+
+// setBracketValue sets the values of parameter k to the member name of m, nested by path
+func setBracketValue(m map[string]interface{}, k string, name string, path []string, values []string) error {
+	conflict := &paramError{k, fmt.Sprintf("mapping: parameter %s conflicts with parameter %s", k, name)}
+	if len(path) == 0 {
+		if _, ok := m[name]; ok {
+			return conflict
+		}
+		if len(values) == 1 {
+			m[name] = values[0]
+		} else {
+			m[name] = stringsToInterfaces(values)
+		}
+		return nil
+	}
+
+	if path[0] == "" {
+		list, ok := m[name].([]interface{})
+		if _, exists := m[name]; exists && !ok {
+			return conflict
+		}
+		m[name] = append(list, stringsToInterfaces(values)...)
+		return nil
+	}
+
+	child, ok := m[name].(map[string]interface{})
+	if !ok {
+		if _, exists := m[name]; exists {
+			return conflict
+		}
+		child = make(map[string]interface{})
+		m[name] = child
+	}
+	return setBracketValue(child, k, path[0], path[1:], values)
+}
+
+func stringsToInterfaces(values []string) []interface{} {
+	vals := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		vals = append(vals, v)
+	}
+	return vals
+}
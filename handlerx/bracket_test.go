@@ -0,0 +1,63 @@
+package handlerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBracketParams(t *testing.T) {
+	SetupHTTP2GraphQLMapping(
+		StringMap{"GET:/todos": "todos"},
+		StringMap{"todos": "{id}"},
+		ArgTypeMap{"todos": {"filter": "TodoFilter", "limit": "Int"}},
+		ArgTypeMap{
+			"TodoFilter":  {"state": "TodoState", "tags": "[String!]", "done": "Boolean", "owner": "OwnerFilter"},
+			"OwnerFilter": {"id": "ID"},
+		},
+		StringMap{"TodoFilter": "INPUT_OBJECT", "OwnerFilter": "INPUT_OBJECT", "TodoState": "ENUM"},
+	)
+	defer setupTestMapping()
+
+	tests := []struct {
+		Name     string
+		Query    string
+		Contains []string
+		Error    string
+	}{
+		{
+			Name:     "nested object",
+			Query:    "filter[state]=DONE&filter[done]=1&filter[owner][id]=7",
+			Contains: []string{"filter:{", "done:true", `owner:{id:"7"}`, "state:DONE"},
+		},
+		{
+			Name:     "list",
+			Query:    "filter[tags][]=a&filter[tags][]=b,c&limit=2",
+			Contains: []string{`filter:{tags:["a","b,c"]}`, "limit:2"},
+		},
+		{Name: "leaf coercion", Query: "filter[done]=maybe", Error: "mapping: parameter done requires Boolean but got \"maybe\""},
+		{Name: "unclosed bracket", Query: "filter[state=DONE", Error: "mapping: malformed parameter filter[state"},
+		{Name: "no name", Query: "[state]=DONE", Error: "mapping: malformed parameter [state]"},
+		{Name: "list in the middle", Query: "filter[][state]=DONE", Error: "mapping: malformed parameter filter[][state]"},
+		{Name: "conflict", Query: "filter[tags]=a&filter[tags][]=b", Error: "mapping: parameter filter[tags][] conflicts with parameter tags"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos?"+tt.Query, nil), "/todos")
+			query, err := convertHTTPRequestParamsToGraphQLQuery(r, &graphql.RawParams{}, map[string]interface{}{})
+			if tt.Error != "" {
+				assert.EqualError(t, err, tt.Error)
+				assert.Equal(t, http.StatusBadRequest, convertErrorCode(err))
+				return
+			}
+			assert.NoError(t, err)
+			for _, c := range tt.Contains {
+				assert.Contains(t, query, c)
+			}
+		})
+	}
+}
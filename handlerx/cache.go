@@ -0,0 +1,42 @@
+package handlerx
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type cachePolicy struct {
+	maxAge time.Duration
+	public bool
+}
+
+var cachePolicies = make(map[string]cachePolicy)
+
+// SetCacheControl sets the Cache-Control header of successful RESTful responses of operation opName,
+// eg. "public, max-age=60". Error responses of the operation are sent with "no-store".
+func SetCacheControl(opName string, maxAge time.Duration, public bool) {
+	cachePolicies[opName] = cachePolicy{maxAge: maxAge, public: public}
+}
+
+// setCacheControl sets the Cache-Control header of the response to req, if there's a cache policy
+func setCacheControl(w http.ResponseWriter, req *http.Request, success bool) {
+	opName, ok := restOperationName(req)
+	if !ok {
+		return
+	}
+	policy, ok := cachePolicies[opName]
+	if !ok {
+		return
+	}
+
+	if !success {
+		w.Header().Set("Cache-Control", "no-store")
+		return
+	}
+	visibility := "private"
+	if policy.public {
+		visibility = "public"
+	}
+	w.Header().Set("Cache-Control", visibility+", max-age="+strconv.Itoa(int(policy.maxAge/time.Second)))
+}
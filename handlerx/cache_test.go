@@ -0,0 +1,56 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestSetCacheControl(t *testing.T) {
+	setupTestMapping()
+	defer delete(cachePolicies, "todos")
+	defer delete(cachePolicies, "todo")
+	SetCacheControl("todos", time.Minute, true)
+	SetCacheControl("todo", 10*time.Second, false)
+
+	tests := []struct {
+		Name     string
+		Pattern  string
+		Response *graphql.Response
+		Expected string
+	}{
+		{
+			Name:     "public",
+			Pattern:  "/todos",
+			Response: &graphql.Response{Data: json.RawMessage(`{"todos":[]}`)},
+			Expected: "public, max-age=60",
+		},
+		{
+			Name:     "private",
+			Pattern:  "/todos/{id}",
+			Response: &graphql.Response{Data: json.RawMessage(`{"todo":null}`)},
+			Expected: "private, max-age=10",
+		},
+		{
+			Name:     "error",
+			Pattern:  "/todos",
+			Response: &graphql.Response{Errors: gqlerror.List{{Message: "denied"}}},
+			Expected: "no-store",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(http.MethodGet, "/", nil), tt.Pattern)
+			w := httptest.NewRecorder()
+			writeJSON(w, r, tt.Response, true)
+			assert.Equal(t, tt.Expected, w.Header().Get("Cache-Control"))
+		})
+	}
+}
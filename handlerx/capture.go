@@ -0,0 +1,80 @@
+package handlerx
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+)
+
+var (
+	captureSinksMu sync.RWMutex
+	captureSinks   = make(map[string]func(req, resp []byte))
+)
+
+// SetCaptureSink captures the raw body of the RESTful requests of operation opName and their final
+// response body (uncompressed), and passes them to fn once the response is written, eg. to debug a
+// misbehaving integration. The request body is recorded while it's read, without consuming it.
+// Pass a nil fn to disable it.
+func SetCaptureSink(opName string, fn func(req, resp []byte)) {
+	captureSinksMu.Lock()
+	defer captureSinksMu.Unlock()
+
+	if fn == nil {
+		delete(captureSinks, opName)
+	} else {
+		captureSinks[opName] = fn
+	}
+}
+
+// captureReader records the request body read through it
+type captureReader struct {
+	io.ReadCloser
+	buf bytes.Buffer
+}
+
+func (r *captureReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.buf.Write(p[:n])
+	return n, err
+}
+
+// captureWriter records the response body written through it
+type captureWriter struct {
+	http.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.buf.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher, eg. for server-sent events
+func (w *captureWriter) Flush() {
+	flushResponse(w.ResponseWriter)
+}
+
+// withCapture records the bodies of r and its response if a capture sink is set for its operation,
+// the returned func must be called once the response is written.
+func withCapture(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, func()) {
+	opName, ok := restOperationName(r)
+	if !ok {
+		return w, func() {}
+	}
+	captureSinksMu.RLock()
+	fn, ok := captureSinks[opName]
+	captureSinksMu.RUnlock()
+	if !ok {
+		return w, func() {}
+	}
+
+	body := &captureReader{ReadCloser: r.Body}
+	if r.Body != nil {
+		r.Body = body
+	}
+	cw := &captureWriter{ResponseWriter: w}
+	return cw, func() {
+		fn(body.buf.Bytes(), cw.buf.Bytes())
+	}
+}
@@ -0,0 +1,34 @@
+package handlerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetCaptureSink(t *testing.T) {
+	setupTestMapping()
+	var captured [][2]string
+	SetCaptureSink("createTodo", func(req, resp []byte) {
+		captured = append(captured, [2]string{string(req), string(resp)})
+	})
+	defer SetCaptureSink("createTodo", nil)
+
+	body := `{"text":"abc","userId":"1"}`
+	r := WithRoute(httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(body)), "/todos")
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	POST{}.Do(w, r, &slowExecutor{canceled: make(chan bool, 1)})
+
+	// the body is still decoded to build the query
+	assert.Equal(t, `{"code":0,"data":[]}`, w.Body.String())
+	assert.Equal(t, [][2]string{{body, `{"code":0,"data":[]}`}}, captured)
+
+	// other operations aren't captured
+	r = WithRoute(httptest.NewRequest(http.MethodPut, "/todos/1", strings.NewReader(`{"text":"abc"}`)), "/todos/{id}", "1")
+	POST{}.Do(httptest.NewRecorder(), r, &slowExecutor{canceled: make(chan bool, 1)})
+	assert.Len(t, captured, 1)
+}
@@ -0,0 +1,47 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSONDecoder decodes JSON values from a stream, like json.Decoder
+type JSONDecoder interface {
+	Decode(v interface{}) error
+	UseNumber()
+	DisallowUnknownFields()
+}
+
+// JSONCodec is the JSON serializer of request bodies and responses, eg. an adapter of jsoniter or sonic
+type JSONCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	NewDecoder(r io.Reader) JSONDecoder
+}
+
+// stdJSONCodec is the JSONCodec of encoding/json
+type stdJSONCodec struct{}
+
+func (stdJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (stdJSONCodec) NewDecoder(r io.Reader) JSONDecoder {
+	return json.NewDecoder(r)
+}
+
+var jsonCodec JSONCodec = stdJSONCodec{}
+
+// SetJSONCodec sets the JSON serializer used to decode request bodies and encode responses,
+// the default is encoding/json. Decoded numbers must be json.Number when UseNumber is called.
+// Pass nil to restore the default.
+func SetJSONCodec(codec JSONCodec) {
+	if codec == nil {
+		codec = stdJSONCodec{}
+	}
+	jsonCodec = codec
+}
@@ -0,0 +1,56 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingCodec struct {
+	stdJSONCodec
+	marshaled int
+	decoders  int
+}
+
+func (c *countingCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshaled++
+	return json.Marshal(v)
+}
+
+func (c *countingCodec) NewDecoder(r io.Reader) JSONDecoder {
+	c.decoders++
+	return json.NewDecoder(r)
+}
+
+func TestSetJSONCodec(t *testing.T) {
+	codec := &countingCodec{}
+	SetJSONCodec(codec)
+	defer SetJSONCodec(nil)
+
+	var v map[string]interface{}
+	assert.NoError(t, jsonDecode(strings.NewReader(`{"limit":10}`), &v))
+	assert.Equal(t, json.Number("10"), v["limit"])
+	assert.Equal(t, 1, codec.decoders)
+
+	// the envelope and its members, but `data`, are marshaled by the codec
+	w := httptest.NewRecorder()
+	writeJSON(w, nil, &graphql.Response{Data: json.RawMessage(`{"todo":{"id":"1"}}`)}, true)
+	assert.Equal(t, `{"code":0,"data":{"id":"1"}}`, w.Body.String())
+	assert.Equal(t, 2, codec.marshaled)
+
+	SetStreamingJSON(true)
+	defer SetStreamingJSON(false)
+	codec.marshaled = 0
+	w = httptest.NewRecorder()
+	writeJSON(w, nil, &graphql.Response{Data: json.RawMessage(`{"todo":{"id":"1"}}`)}, true)
+	assert.Equal(t, `{"code":0,"data":{"id":"1"}}`, w.Body.String())
+	assert.Equal(t, 1, codec.marshaled)
+
+	SetJSONCodec(nil)
+	assert.Equal(t, stdJSONCodec{}, jsonCodec)
+}
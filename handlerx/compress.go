@@ -0,0 +1,177 @@
+package handlerx
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// compressionMinBytes is the minimal response size to compress, negative means disabled
+var compressionMinBytes = -1
+
+// SetCompression enables gzip/deflate compression, negotiated by the Accept-Encoding header,
+// for responses of at least minBytes bytes. Pass a negative value to disable it (default).
+func SetCompression(minBytes int) {
+	compressionMinBytes = minBytes
+}
+
+// wrapCompression returns a writer compressing the response if enabled and accepted by the client,
+// the returned func must be called once the response is complete.
+func wrapCompression(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, func()) {
+	if compressionMinBytes < 0 {
+		return w, func() {}
+	}
+
+	w.Header().Add("Vary", "Accept-Encoding")
+	encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+	if encoding == "" {
+		return w, func() {}
+	}
+
+	cw := &compressWriter{ResponseWriter: w, encoding: encoding, minBytes: compressionMinBytes}
+	return cw, func() {
+		if err := cw.Close(); err != nil {
+			dbgPrintf("compress response error: %v", err)
+		}
+	}
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header, gzip is preferred.
+// Per RFC 9110, an empty result means identity: a coding with q=0 is not acceptable, "*" matches
+// the codings not listed, and identity is kept when listed with a higher qvalue than the best coding.
+func negotiateEncoding(acceptEncoding string) string {
+	accepted := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				var err error
+				if q, err = strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err != nil {
+					q = 0
+				}
+			}
+		}
+		accepted[name] = q
+	}
+	qvalue := func(name string) float64 {
+		if q, ok := accepted[name]; ok {
+			return q
+		}
+		return accepted["*"]
+	}
+
+	best, bestQ := "", 0.0
+	for _, name := range []string{"gzip", "deflate"} {
+		if q := qvalue(name); q > bestQ {
+			best, bestQ = name, q
+		}
+	}
+	if qvalue("identity") > bestQ {
+		return ""
+	}
+	return best
+}
+
+// compressWriter buffers the response until minBytes is reached, then decides whether to compress.
+// WriteHeader is delayed until then, because Content-Encoding must be set before it.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minBytes int
+
+	status  int
+	buf     []byte
+	decided bool
+	cw      io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.decided {
+		if w.cw != nil {
+			return w.cw.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minBytes {
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *compressWriter) start(compress bool) error {
+	w.decided = true
+
+	if compress && w.status != http.StatusNoContent && w.status != http.StatusNotModified {
+		w.Header().Set("Content-Encoding", w.encoding)
+		w.Header().Del("Content-Length")
+		if w.encoding == "gzip" {
+			w.cw = gzip.NewWriter(w.ResponseWriter)
+		} else {
+			w.cw, _ = flate.NewWriter(w.ResponseWriter, flate.DefaultCompression)
+		}
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if w.cw != nil {
+		_, err := w.cw.Write(buf)
+		return err
+	}
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// Flush implements http.Flusher, responses flushed before minBytes are written as-is
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if err := w.start(false); err != nil {
+			dbgPrintf("compress response error: %v", err)
+			return
+		}
+	}
+	if f, ok := w.cw.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			dbgPrintf("compress response error: %v", err)
+			return
+		}
+	}
+	flushResponse(w.ResponseWriter)
+}
+
+// Close flushes the buffered or compressed data, responses below minBytes are written as-is
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		if err := w.start(false); err != nil {
+			return err
+		}
+	}
+	if w.cw != nil {
+		return w.cw.Close()
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package handlerx
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetCompression(t *testing.T) {
+	defer SetCompression(-1)
+	SetCompression(16)
+
+	tests := []struct {
+		Name           string
+		AcceptEncoding string
+		Body           string
+		Expected       string
+	}{
+		{
+			Name:           "large body with gzip",
+			AcceptEncoding: "deflate, gzip",
+			Body:           strings.Repeat("x", 32),
+			Expected:       "gzip",
+		},
+		{
+			Name:           "small body with gzip",
+			AcceptEncoding: "gzip",
+			Body:           "x",
+			Expected:       "",
+		},
+		{
+			Name:           "large body with deflate",
+			AcceptEncoding: "gzip;q=0.5, deflate",
+			Body:           strings.Repeat("x", 32),
+			Expected:       "deflate",
+		},
+		{
+			Name:           "large body with gzip refused",
+			AcceptEncoding: "deflate;q=0, gzip;q=0",
+			Body:           strings.Repeat("x", 32),
+			Expected:       "",
+		},
+		{
+			Name:           "large body without encoding",
+			AcceptEncoding: "",
+			Body:           strings.Repeat("x", 32),
+			Expected:       "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/todos", nil)
+			r.Header.Set("Accept-Encoding", tt.AcceptEncoding)
+			rec := httptest.NewRecorder()
+
+			w, closeWriter := wrapCompression(rec, r)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(tt.Body))
+			closeWriter()
+
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+			assert.Equal(t, tt.Expected, rec.Header().Get("Content-Encoding"))
+			if tt.Expected == "gzip" {
+				gr, err := gzip.NewReader(rec.Body)
+				assert.NoError(t, err)
+				body, _ := ioutil.ReadAll(gr)
+				assert.Equal(t, tt.Body, string(body))
+			} else if tt.Expected == "" {
+				assert.Equal(t, tt.Body, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		Name           string
+		AcceptEncoding string
+		Expected       string
+	}{
+		{Name: "none", AcceptEncoding: "", Expected: ""},
+		{Name: "gzip", AcceptEncoding: "gzip", Expected: "gzip"},
+		{Name: "gzip preferred", AcceptEncoding: "deflate, gzip", Expected: "gzip"},
+		{Name: "higher qvalue", AcceptEncoding: "gzip;q=0.5, deflate", Expected: "deflate"},
+		{Name: "gzip refused", AcceptEncoding: "gzip;q=0", Expected: ""},
+		{Name: "all refused", AcceptEncoding: "deflate;q=0, gzip;q=0", Expected: ""},
+		{Name: "gzip refused, deflate accepted", AcceptEncoding: "gzip;q=0, deflate;q=0.1", Expected: "deflate"},
+		{Name: "gzip with low qvalue", AcceptEncoding: "gzip;q=0.1", Expected: "gzip"},
+		{Name: "unsupported", AcceptEncoding: "br", Expected: ""},
+		{Name: "invalid qvalue", AcceptEncoding: "gzip;q=x", Expected: ""},
+		{Name: "wildcard", AcceptEncoding: "*", Expected: "gzip"},
+		{Name: "wildcard without gzip", AcceptEncoding: "*, gzip;q=0", Expected: "deflate"},
+		{Name: "wildcard refused", AcceptEncoding: "*;q=0", Expected: ""},
+		{Name: "wildcard below gzip", AcceptEncoding: "*;q=0.1, gzip;q=0.5", Expected: "gzip"},
+		{Name: "identity preferred", AcceptEncoding: "gzip;q=0.5, identity", Expected: ""},
+		{Name: "identity equal", AcceptEncoding: "gzip;q=0.5, identity;q=0.5", Expected: "gzip"},
+		{Name: "identity refused", AcceptEncoding: "identity;q=0, gzip;q=0.5", Expected: "gzip"},
+		{Name: "identity below wildcard", AcceptEncoding: "*;q=0.5, identity;q=0.1", Expected: "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Expected, negotiateEncoding(tt.AcceptEncoding))
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package handlerx
+
+import (
+	"net/http"
+	"strings"
+)
+
+// GraphQL Operation => the variable of the If-Match entity tag
+var concurrencyVariables = make(StringMap)
+
+// SetConcurrencyVariable makes RESTful requests of operation opName pass the entity tag of their If-Match
+// header to the argument (or input field) variableName, eg. expectedVersion, so that the resolver can reject
+// stale updates: the tag is passed without its quotes, eg. "3" for `If-Match: "3"`, and coerced to the
+// variable type like query parameters. Requests without If-Match are rejected with 428 Precondition Required,
+// and the 409 Conflict errors of the resolver are reported as 412 Precondition Failed. If-Match uses the strong
+// comparison, requests with a weak entity tag, eg. `W/"3"`, are rejected with 412 Precondition Failed.
+// Pass an empty variableName to remove it.
+func SetConcurrencyVariable(opName, variableName string) {
+	if variableName == "" {
+		delete(concurrencyVariables, opName)
+	} else {
+		concurrencyVariables[opName] = variableName
+	}
+}
+
+// preconditionRequiredError is returned when the If-Match header of a request is missing
+type preconditionRequiredError struct {
+	variable string
+}
+
+func (e *preconditionRequiredError) Error() string {
+	return "missing header If-Match of " + e.variable
+}
+
+// preconditionFailedError is returned when the If-Match header of a request can't match any version
+type preconditionFailedError struct {
+	variable string
+}
+
+func (e *preconditionFailedError) Error() string {
+	return "header If-Match of " + e.variable + " must be a strong entity tag"
+}
+
+// applyConcurrencyVariable sets the concurrency variable of operationName from the If-Match header of r
+func applyConcurrencyVariable(r *http.Request, operationName string, queryParams, inputParams map[string]interface{}) error {
+	variable, ok := concurrencyVariables[operationName]
+	if !ok {
+		return nil
+	}
+	tag := strings.TrimSpace(r.Header.Get("If-Match"))
+	if tag == "" {
+		return &preconditionRequiredError{variable: variable}
+	}
+	if strings.Contains(tag, ",") {
+		return &paramError{param: variable, msg: "header If-Match of " + variable + " must be a single entity tag"}
+	}
+	if strings.HasPrefix(tag, "W/") {
+		return &preconditionFailedError{variable: variable}
+	}
+	if len(tag) >= 2 && strings.HasPrefix(tag, `"`) && strings.HasSuffix(tag, `"`) {
+		tag = tag[1 : len(tag)-1]
+	}
+	inputParams[variable] = tag
+	queryParams[variable] = tag
+	return nil
+}
+
+// concurrencyCode returns the response code of operation opName for the error code, see SetConcurrencyVariable
+func concurrencyCode(opName string, code int) int {
+	if _, ok := concurrencyVariables[opName]; ok && code == http.StatusConflict {
+		return http.StatusPreconditionFailed
+	}
+	return code
+}
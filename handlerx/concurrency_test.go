@@ -0,0 +1,74 @@
+package handlerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestSetConcurrencyVariable(t *testing.T) {
+	setupTestMapping()
+	SetConcurrencyVariable("createTodo", "userId")
+	defer SetConcurrencyVariable("createTodo", "")
+
+	body := `{"text":"x"}`
+	tests := []struct {
+		Name     string
+		IfMatch  string
+		Contains string
+		Code     int
+	}{
+		{Name: "entity tag", IfMatch: `"v3"`, Contains: `userId:"v3"`},
+		{Name: "unquoted", IfMatch: `v3`, Contains: `userId:"v3"`},
+		{Name: "missing", Code: http.StatusPreconditionRequired},
+		{Name: "several tags", IfMatch: `"v3", "v4"`, Code: http.StatusBadRequest},
+		{Name: "weak tag", IfMatch: `W/"v3"`, Code: http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(body)), "/todos")
+			if tt.IfMatch != "" {
+				r.Header.Set("If-Match", tt.IfMatch)
+			}
+			query, err := convertHTTPRequestToGraphQLQuery(r, &graphql.RawParams{}, []byte(body))
+			if tt.Code != 0 {
+				assert.Error(t, err)
+				assert.Equal(t, tt.Code, convertErrorCode(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Contains(t, query, tt.Contains)
+		})
+	}
+
+	t.Run("weak tag response", func(t *testing.T) {
+		SetHTTPStatusEnabled(true)
+		defer SetHTTPStatusEnabled(false)
+
+		r := WithRoute(httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(body)), "/todos")
+		r.Header.Set("If-Match", `W/"v3"`)
+		w := httptest.NewRecorder()
+		exec := &paramsExecutor{}
+		POST{}.Do(w, r, exec)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Equal(t, `{"code":412,"message":"header If-Match of userId must be a strong entity tag","data":null}`, w.Body.String())
+		assert.Empty(t, exec.params)
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		SetHTTPStatusEnabled(true)
+		defer SetHTTPStatusEnabled(false)
+
+		r := WithRoute(httptest.NewRequest(http.MethodPost, "/todos", nil), "/todos")
+		w := httptest.NewRecorder()
+		writeJSON(w, r, &graphql.Response{Errors: gqlerror.List{{Message: "stale", Extensions: map[string]interface{}{"code": http.StatusConflict}}}}, true)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Equal(t, `{"code":412,"message":"stale","data":null}`, w.Body.String())
+	})
+}
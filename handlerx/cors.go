@@ -0,0 +1,166 @@
+package handlerx
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig is the CORS policy of a RESTful route, see https://fetch.spec.whatwg.org/#http-cors-protocol
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed to request the route, exact (eg. "https://app.example.com")
+	// or with wildcards (eg. "https://*.example.com"), "*" allows any origin
+	AllowedOrigins []string
+	// AllowedMethods are the methods allowed by preflights, defaults to the methods of the route
+	AllowedMethods []string
+	// AllowedHeaders are the request headers allowed by preflights, "*" allows any header.
+	// Defaults to Accept, Content-Type and X-Requested-With
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers readable by the client, eg. X-Request-ID
+	ExposedHeaders []string
+	// AllowCredentials allows requests with cookies or HTTP authentication
+	AllowCredentials bool
+	// MaxAge is how long the preflight result may be cached by the client, 0 leaves it to the client
+	MaxAge time.Duration
+}
+
+var defaultCORSAllowedHeaders = []string{"Accept", "Content-Type", "X-Requested-With"}
+
+var corsConfigs = make(map[string]*CORSConfig)
+
+// SetCORS sets the CORS policy of the RESTful routes of operation opName. Preflight OPTIONS requests are
+// answered without executing the operation, by the Options transport and by MethodNotAllowed for chi
+// routers, and the Access-Control-* headers are set on the actual responses of allowed origins.
+// Pass a cfg without AllowedOrigins to remove the policy.
+func SetCORS(opName string, cfg CORSConfig) {
+	if len(cfg.AllowedOrigins) == 0 {
+		delete(corsConfigs, opName)
+		return
+	}
+	if len(cfg.AllowedHeaders) == 0 {
+		cfg.AllowedHeaders = defaultCORSAllowedHeaders
+	}
+	corsConfigs[opName] = &cfg
+}
+
+// allowsOrigin reports whether origin matches one of the allowed origins
+func (c *CORSConfig) allowsOrigin(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+		if ok, _ := path.Match(strings.ToLower(allowed), origin); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsHeaders reports whether the comma separated request headers are all allowed
+func (c *CORSConfig) allowsHeaders(headers string) bool {
+	for _, header := range strings.Split(headers, ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+		allowed := false
+		for _, h := range c.AllowedHeaders {
+			if h == "*" || strings.EqualFold(h, header) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// setAllowOrigin sets the headers allowing origin, "*" is only responded to requests without credentials
+func (c *CORSConfig) setAllowOrigin(w http.ResponseWriter, origin string) {
+	h := w.Header()
+	if len(c.AllowedOrigins) == 1 && c.AllowedOrigins[0] == "*" && !c.AllowCredentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// setCORSHeaders sets the Access-Control-* headers of the actual response to r, if its origin is allowed
+func setCORSHeaders(w http.ResponseWriter, r *http.Request) {
+	if len(corsConfigs) == 0 {
+		return
+	}
+	origin := r.Header.Get("Origin")
+	opName, ok := restOperationName(r)
+	if origin == "" || !ok {
+		return
+	}
+	cfg, ok := corsConfigs[opName]
+	if !ok || !cfg.allowsOrigin(origin) {
+		return
+	}
+	w.Header().Add("Vary", "Origin")
+	cfg.setAllowOrigin(w, origin)
+	if len(cfg.ExposedHeaders) > 0 {
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(cfg.ExposedHeaders, ", "))
+	}
+}
+
+// handlePreflight answers r if it's a CORS preflight of a RESTful route with a CORS policy, the operation
+// isn't executed. The headers are only allowed if the origin, the method and the headers are allowed.
+func handlePreflight(w http.ResponseWriter, r *http.Request) bool {
+	method := r.Header.Get("Access-Control-Request-Method")
+	origin := r.Header.Get("Origin")
+	if r.Method != http.MethodOptions || method == "" || origin == "" || len(corsConfigs) == 0 {
+		return false
+	}
+	opName, pattern, ok := matchRESTOperation(method, r.URL.Path)
+	if !ok {
+		return false
+	}
+	cfg, ok := corsConfigs[opName]
+	if !ok {
+		return false
+	}
+
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+	methods := cfg.AllowedMethods
+	if len(methods) == 0 {
+		methods = allowedMethods(pattern)
+	}
+	requestHeaders := r.Header.Get("Access-Control-Request-Headers")
+	if cfg.allowsOrigin(origin) && containsMethod(methods, method) && cfg.allowsHeaders(requestHeaders) {
+		cfg.setAllowOrigin(w, origin)
+		h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+		if requestHeaders != "" {
+			h.Set("Access-Control-Allow-Headers", requestHeaders)
+		}
+		if cfg.MaxAge > 0 {
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge/time.Second)))
+		}
+	} else {
+		dbgPrintf("HTTP %s %s: CORS preflight of %s %s from %s is not allowed", r.Method, r.URL.Path, method, requestHeaders, origin)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package handlerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetCORS(t *testing.T) {
+	setupTestMapping()
+	SetCORS("createTodo", CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		ExposedHeaders:   []string{RequestIDHeader},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+	SetCORS("todos", CORSConfig{AllowedOrigins: []string{"*"}})
+	defer SetCORS("createTodo", CORSConfig{})
+	defer SetCORS("todos", CORSConfig{})
+
+	tests := []struct {
+		Name           string
+		Method         string
+		Origin         string
+		RequestHeaders string
+		Headers        map[string]string
+	}{
+		{
+			Name:           "exact origin",
+			Method:         http.MethodPost,
+			Origin:         "https://app.example.com",
+			RequestHeaders: "content-type, authorization",
+			Headers: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods":     "GET, HEAD, POST",
+				"Access-Control-Allow-Headers":     "content-type, authorization",
+				"Access-Control-Max-Age":           "600",
+			},
+		},
+		{
+			Name:    "wildcard origin",
+			Method:  http.MethodPost,
+			Origin:  "https://admin.example.org",
+			Headers: map[string]string{"Access-Control-Allow-Origin": "https://admin.example.org"},
+		},
+		{
+			Name:    "any origin",
+			Method:  http.MethodGet,
+			Origin:  "https://other.com",
+			Headers: map[string]string{"Access-Control-Allow-Origin": "*", "Access-Control-Allow-Credentials": ""},
+		},
+		{
+			Name:    "origin not allowed",
+			Method:  http.MethodPost,
+			Origin:  "https://evil.com",
+			Headers: map[string]string{"Access-Control-Allow-Origin": "", "Access-Control-Allow-Methods": ""},
+		},
+		{
+			Name:           "header not allowed",
+			Method:         http.MethodPost,
+			Origin:         "https://app.example.com",
+			RequestHeaders: "X-Secret",
+			Headers:        map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			for _, handler := range []func(w http.ResponseWriter, r *http.Request){
+				MethodNotAllowed,
+				func(w http.ResponseWriter, r *http.Request) { Options{}.Do(w, r, nil) }, // not executed
+			} {
+				r := httptest.NewRequest(http.MethodOptions, "/todos", nil)
+				r.Header.Set("Origin", tt.Origin)
+				r.Header.Set("Access-Control-Request-Method", tt.Method)
+				if tt.RequestHeaders != "" {
+					r.Header.Set("Access-Control-Request-Headers", tt.RequestHeaders)
+				}
+				w := httptest.NewRecorder()
+				handler(w, r)
+				assert.Equal(t, http.StatusNoContent, w.Code)
+				for k, v := range tt.Headers {
+					assert.Equal(t, v, w.Header().Get(k), k)
+				}
+			}
+		})
+	}
+
+	// actual responses
+	r := WithRoute(httptest.NewRequest(http.MethodPost, "/todos", nil), "/todos")
+	r.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+	setCORSHeaders(w, r)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, RequestIDHeader, w.Header().Get("Access-Control-Expose-Headers"))
+	assert.Equal(t, "Origin", w.Header().Get("Vary"))
+
+	// routes without a policy
+	r = WithRoute(httptest.NewRequest(http.MethodPut, "/todos/1", nil), "/todos/{id}", "1")
+	r.Header.Set("Origin", "https://app.example.com")
+	w = httptest.NewRecorder()
+	setCORSHeaders(w, r)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,114 @@
+package handlerx
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var csvFallbackJSON bool
+
+// SetCSVFallbackJSON controls RESTful responses to `Accept: text/csv` requests when the `data` isn't
+// a list of objects: they are sent as JSON if enabled, otherwise a 406 error is reported (default).
+func SetCSVFallbackJSON(enabled bool) {
+	csvFallbackJSON = enabled
+}
+
+var errNotCSV = errors.New("data is not a list of objects")
+
+// encodeCSV encodes a JSON array of objects as CSV. The header row is the union of the object keys,
+// in the order they first appear. Strings are written as-is, null as an empty cell, and other values
+// (including nested objects and lists) as JSON.
+func encodeCSV(data json.RawMessage) ([]byte, error) {
+	var rows []json.RawMessage
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, errNotCSV
+	}
+
+	header := make([]string, 0)
+	columns := make(map[string]int)
+	records := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		keys, values, err := decodeCSVRow(row)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			if _, ok := columns[k]; !ok {
+				columns[k] = len(header)
+				header = append(header, k)
+			}
+		}
+		records = append(records, values)
+	}
+
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if len(header) == 0 {
+		return buf.Bytes(), nil
+	}
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+	for _, values := range records {
+		record := make([]string, len(header))
+		for i, k := range header {
+			record[i] = values[k]
+		}
+		if err := cw.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	cw.Flush()
+	return buf.Bytes(), cw.Error()
+}
+
+// decodeCSVRow decodes a JSON object to cells, keeping the order of the keys
+func decodeCSVRow(row json.RawMessage) ([]string, map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(row))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, errNotCSV
+	}
+
+	keys := make([]string, 0)
+	values := make(map[string]string)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, _ := tok.(string)
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+		values[key] = csvCell(value)
+	}
+	return keys, values, nil
+}
+
+func csvCell(value json.RawMessage) string {
+	trimmed := strings.TrimSpace(string(value))
+	if trimmed == "null" {
+		return ""
+	}
+	var s string
+	if strings.HasPrefix(trimmed, `"`) && json.Unmarshal(value, &s) == nil {
+		return s
+	}
+	return trimmed
+}
+
+// csvFilename returns the filename of the CSV response to req, eg. "todos.csv"
+func csvFilename(req *http.Request) string {
+	if opName, ok := restOperationName(req); ok {
+		return opName + ".csv"
+	}
+	return "data.csv"
+}
@@ -0,0 +1,60 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteJSONNegotiateCSV(t *testing.T) {
+	SetContentNegotiation(true)
+	defer SetContentNegotiation(false)
+	setupTestMapping()
+	defer SetCSVFallbackJSON(false)
+
+	tests := []struct {
+		Name         string
+		Data         string
+		FallbackJSON bool
+		ContentType  string
+		Expected     string
+	}{
+		{
+			Name:        "list of objects",
+			Data:        `{"todos":[{"id":"1","text":"a, b","user":{"id":"U1"}},{"id":"2","done":true,"text":null}]}`,
+			ContentType: "text/csv; charset=utf-8",
+			Expected:    "id,text,user,done\n1,\"a, b\",\"{\"\"id\"\":\"\"U1\"\"}\",\n2,,,true\n",
+		},
+		{
+			Name:        "not a list",
+			Data:        `{"todos":{"id":"1"}}`,
+			ContentType: "application/json; charset=utf-8",
+			Expected:    `{"code":406,"message":"text/csv is not acceptable: data is not a list of objects","data":null}`,
+		},
+		{
+			Name:         "fallback to json",
+			Data:         `{"todos":{"id":"1"}}`,
+			FallbackJSON: true,
+			ContentType:  "application/json; charset=utf-8",
+			Expected:     `{"code":0,"data":{"id":"1"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetCSVFallbackJSON(tt.FallbackJSON)
+			r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos", nil), "/todos")
+			r.Header.Set("Accept", "text/csv")
+			w := httptest.NewRecorder()
+			w.Header().Set("Content-Type", "application/json")
+			writeJSON(w, r, &graphql.Response{Data: json.RawMessage(tt.Data)}, true)
+
+			assert.Equal(t, tt.ContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.Expected, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package handlerx
+
+import (
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// DebugEchoHeader is the request header enabling the `_debug` field of RESTful responses, see SetDebugEcho
+const DebugEchoHeader = "X-Debug-Echo"
+
+var debugEcho bool
+
+// SetDebugEcho allows clients to get the GraphQL query and variables a RESTful request is converted to,
+// in the `_debug` field of the response. It's reported only if the request has the DebugEchoHeader header,
+// never enable it in production.
+func SetDebugEcho(enabled bool) {
+	debugEcho = enabled
+}
+
+// RESTDebug is the `_debug` field of RESTful responses
+type RESTDebug struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// recordDebugEcho records the converted GraphQL query of r if debug echo is requested
+func recordDebugEcho(r *http.Request, params *graphql.RawParams) {
+	if !debugEcho || r.Header.Get(DebugEchoHeader) == "" {
+		return
+	}
+	state := responseStateFromContext(r.Context())
+	if state == nil {
+		return
+	}
+
+	debug := &RESTDebug{Query: params.Query}
+	for k, v := range params.Variables {
+		if debug.Variables == nil {
+			debug.Variables = make(map[string]interface{})
+		}
+		if upload, ok := v.(graphql.Upload); ok {
+			// the file content is not echoed
+			v = map[string]interface{}{"filename": upload.Filename, "size": upload.Size, "contentType": upload.ContentType}
+		}
+		debug.Variables[k] = v
+	}
+	state.debug = debug
+	dbgPrintf("debug echo %s %s: %s %v", r.Method, r.URL.Path, params.Query, debug.Variables)
+}
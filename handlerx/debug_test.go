@@ -0,0 +1,48 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetDebugEcho(t *testing.T) {
+	setupTestMapping()
+	defer SetDebugEcho(false)
+
+	tests := []struct {
+		Name     string
+		Enabled  bool
+		Header   string
+		Expected bool
+	}{
+		{Name: "enabled with header", Enabled: true, Header: "1", Expected: true},
+		{Name: "enabled without header", Enabled: true},
+		{Name: "disabled with header", Header: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetDebugEcho(tt.Enabled)
+			r := withResponseState(WithRoute(httptest.NewRequest(http.MethodGet, "/todos/42", nil), "/todos/{id}", "42"))
+			r.Header.Set(DebugEchoHeader, tt.Header)
+			query, err := convertHTTPRequestToGraphQLQuery(r, &graphql.RawParams{}, nil)
+			assert.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			writeJSON(w, r, &graphql.Response{Data: json.RawMessage(`{"todo":null}`)}, true)
+
+			var resp map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			if tt.Expected {
+				assert.Equal(t, map[string]interface{}{"query": query}, resp["_debug"])
+			} else {
+				assert.NotContains(t, resp, "_debug")
+			}
+		})
+	}
+}
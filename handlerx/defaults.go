@@ -0,0 +1,34 @@
+package handlerx
+
+// GraphQL Operation => Variable Defaults
+var operationVariableDefaults = make(map[string]map[string]interface{})
+
+// SetVariableDefaults sets the default values of the arguments (or input fields) of RESTful requests of
+// operation opName, eg. {"limit": 20}. A default fills in a variable missing from the path, query, body
+// and header parameters, values supplied by the client always win. The defaults only apply to RESTful
+// requests, unlike the defaults of the schema. Pass an empty defaults to remove them.
+func SetVariableDefaults(opName string, defaults map[string]interface{}) {
+	if len(defaults) == 0 {
+		delete(operationVariableDefaults, opName)
+		return
+	}
+	copied := make(map[string]interface{}, len(defaults))
+	for k, v := range defaults {
+		copied[k] = v
+	}
+	operationVariableDefaults[opName] = copied
+}
+
+// applyVariableDefaults sets the defaults of operationName missing from the request parameters
+func applyVariableDefaults(operationName string, queryParams, inputParams map[string]interface{}) {
+	for k, v := range operationVariableDefaults[operationName] {
+		if _, ok := inputParams[k]; ok {
+			continue
+		}
+		if _, ok := queryParams[k]; ok {
+			continue
+		}
+		inputParams[k] = v
+		queryParams[k] = v
+	}
+}
@@ -0,0 +1,44 @@
+package handlerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetVariableDefaults(t *testing.T) {
+	setupTestMapping()
+	SetVariableDefaults("todos", map[string]interface{}{"limit": 20})
+	SetVariableDefaults("createTodo", map[string]interface{}{"userId": "u0"})
+	defer SetVariableDefaults("todos", nil)
+	defer SetVariableDefaults("createTodo", nil)
+
+	tests := []struct {
+		Name     string
+		Method   string
+		Target   string
+		Body     string
+		Contains []string
+	}{
+		{Name: "default", Method: http.MethodGet, Target: "/todos", Contains: []string{`limit:20`}},
+		{Name: "query wins", Method: http.MethodGet, Target: "/todos?limit=5", Contains: []string{`limit:5`}},
+		{Name: "input field default", Method: http.MethodPost, Target: "/todos", Body: `{"text":"x"}`, Contains: []string{`userId:"u0"`, `text:"x"`}},
+		{Name: "body wins", Method: http.MethodPost, Target: "/todos", Body: `{"text":"x","userId":"u2"}`, Contains: []string{`userId:"u2"`}},
+		{Name: "explicit null wins", Method: http.MethodPost, Target: "/todos", Body: `{"text":"x","userId":null}`, Contains: []string{`userId:null`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(tt.Method, tt.Target, strings.NewReader(tt.Body)), "/todos")
+			query, err := convertHTTPRequestToGraphQLQuery(r, &graphql.RawParams{}, []byte(tt.Body))
+			assert.NoError(t, err)
+			for _, c := range tt.Contains {
+				assert.Contains(t, query, c)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package handlerx
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// DryRunParam is the query parameter requesting a dry run of a RESTful mutation, eg. `?dryRun=true`
+	DryRunParam = "dryRun"
+	// DryRunHeader is the request header requesting a dry run of a RESTful mutation
+	DryRunHeader = "X-Dry-Run"
+)
+
+type dryRunKey struct{}
+
+// WithDryRun returns a context of a dry run, eg. to test resolvers
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// DryRunFromContext reports whether the current request is a dry run, requested by the DryRunParam
+// query parameter or the DryRunHeader header of a RESTful mutation. Resolvers should validate the
+// request and return the would-be result without committing changes, the handler only plumbs the
+// flag and reports `"dry_run": true` in the response. Operations with a `dryRun` argument also
+// receive the query parameter as the argument.
+func DryRunFromContext(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+// withDryRun stores the dry run flag of r in the request context
+func withDryRun(r *http.Request) *http.Request {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || !isRESTRoute(r) {
+		return r
+	}
+	v := r.URL.Query().Get(DryRunParam)
+	if v == "" {
+		v = r.Header.Get(DryRunHeader)
+	}
+	if dryRun, err := strconv.ParseBool(v); err != nil || !dryRun {
+		return r
+	}
+	return r.WithContext(WithDryRun(r.Context()))
+}
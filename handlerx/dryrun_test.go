@@ -0,0 +1,50 @@
+package handlerx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDryRun(t *testing.T) {
+	setupTestMapping()
+
+	tests := []struct {
+		Name     string
+		Method   string
+		Target   string
+		Pattern  string
+		Header   string
+		Expected bool
+	}{
+		{Name: "query parameter", Method: http.MethodPost, Target: "/todos?dryRun=true", Pattern: "/todos", Expected: true},
+		{Name: "header", Method: http.MethodPut, Target: "/todos/1", Pattern: "/todos/{id}", Header: "1", Expected: true},
+		{Name: "false", Method: http.MethodPost, Target: "/todos?dryRun=false", Pattern: "/todos"},
+		{Name: "invalid", Method: http.MethodPost, Target: "/todos?dryRun=maybe", Pattern: "/todos"},
+		{Name: "query", Method: http.MethodGet, Target: "/todos?dryRun=true", Pattern: "/todos"},
+		{Name: "graphql", Method: http.MethodPost, Target: "/query?dryRun=true", Pattern: "/query"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(tt.Method, tt.Target, nil), tt.Pattern, "1")
+			if tt.Header != "" {
+				r.Header.Set(DryRunHeader, tt.Header)
+			}
+			assert.Equal(t, tt.Expected, DryRunFromContext(withDryRun(r).Context()))
+		})
+	}
+}
+
+func TestDryRunResponse(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/todos", nil)
+	r = r.WithContext(WithDryRun(context.Background()))
+	w := httptest.NewRecorder()
+	writeJSON(w, r, &graphql.Response{Data: json.RawMessage(`{"createTodo":{"id":"1"}}`)}, true)
+	assert.Equal(t, `{"code":0,"data":{"id":"1"},"dry_run":true}`, w.Body.String())
+}
@@ -0,0 +1,27 @@
+package handlerx
+
+import (
+	"context"
+	"net/http"
+)
+
+var contextEnricher func(context.Context, *http.Request) context.Context
+
+// SetContextEnricher sets a hook deriving the context of each request before the GraphQL execution,
+// eg. to store the tenant of the subdomain or a header for resolvers. The enriched context is the
+// one passed to the executor and to the panic recovery logging. Pass nil to remove the hook.
+func SetContextEnricher(enricher func(context.Context, *http.Request) context.Context) {
+	contextEnricher = enricher
+}
+
+// enrichContext applies the context enricher to r
+func enrichContext(r *http.Request) *http.Request {
+	if contextEnricher == nil {
+		return r
+	}
+	ctx := contextEnricher(r.Context(), r)
+	if ctx == nil {
+		return r
+	}
+	return r.WithContext(ctx)
+}
@@ -0,0 +1,57 @@
+package handlerx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+type tenantKey struct{}
+
+type contextPrinter struct {
+	tenants []interface{}
+}
+
+func (p *contextPrinter) Println(v ...interface{})               {}
+func (p *contextPrinter) Printf(format string, v ...interface{}) {}
+func (p *contextPrinter) PrintfContext(ctx context.Context, format string, v ...interface{}) {
+	p.tenants = append(p.tenants, ctx.Value(tenantKey{}))
+}
+
+func TestSetContextEnricher(t *testing.T) {
+	setupTestMapping()
+	SetContextEnricher(func(ctx context.Context, r *http.Request) context.Context {
+		return context.WithValue(ctx, tenantKey{}, r.Header.Get("X-Tenant"))
+	})
+	defer SetContextEnricher(nil)
+
+	printer := &contextPrinter{}
+	RegisterPrinter(printer)
+	defer RegisterPrinter(nil)
+
+	RegisterResponseTransform("todos", func(data json.RawMessage) (json.RawMessage, error) {
+		return nil, errors.New("broken transform")
+	})
+	defer delete(responseTransforms, "todos")
+
+	r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos", nil), "/todos")
+	r.Header.Set("X-Tenant", "acme")
+	r = enrichContext(r)
+	assert.Equal(t, "acme", r.Context().Value(tenantKey{}))
+
+	w := httptest.NewRecorder()
+	writeJSON(w, r, &graphql.Response{Data: json.RawMessage(`{"todos":[]}`)}, true)
+	assert.Contains(t, w.Body.String(), fmt.Sprintf(`"code":%d`, http.StatusInternalServerError))
+	assert.Equal(t, []interface{}{"acme"}, printer.tenants)
+
+	SetContextEnricher(nil)
+	r = enrichContext(WithRoute(httptest.NewRequest(http.MethodGet, "/todos", nil), "/todos"))
+	assert.Nil(t, r.Context().Value(tenantKey{}))
+}
@@ -0,0 +1,236 @@
+package handlerx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// enumMapping maps the values of an enum field between the GraphQL schema and the RESTful API
+type enumMapping struct {
+	path       []string // the field path within the operation, eg. ["state"] of "todos.state"
+	toInternal map[string]string
+	toExternal map[string]string
+}
+
+var enumMappings = make(map[string]map[string]*enumMapping) // opName => fieldPath => mapping
+
+// SetEnumMapping maps the enum values of the field at fieldPath between their external representation in
+// RESTful requests and responses and their GraphQL one, eg. "done" to DONE. The first element of fieldPath
+// is the operation, the others the field path within its parameters and its result data, lists included,
+// eg. "todos.state" is both the parameter state of todos and the field state of each todo it returns, and
+// "createTodo.input.state" the field state of its input argument.
+//
+// Request values are mapped with toInternal, a value it doesn't map is rejected with 422. Response values
+// are mapped with toExternal, the values it doesn't map are passed through unchanged. Pass nil maps to
+// remove the mapping.
+func SetEnumMapping(fieldPath string, toInternal, toExternal map[string]string) {
+	path := strings.Split(fieldPath, ".")
+	opName := path[0]
+	if len(toInternal) == 0 && len(toExternal) == 0 {
+		delete(enumMappings[opName], fieldPath)
+		if len(enumMappings[opName]) == 0 {
+			delete(enumMappings, opName)
+		}
+		return
+	}
+	if enumMappings[opName] == nil {
+		enumMappings[opName] = make(map[string]*enumMapping)
+	}
+	enumMappings[opName][fieldPath] = &enumMapping{path: path[1:], toInternal: toInternal, toExternal: toExternal}
+}
+
+// applyEnumMappings maps the enum values of the parameters of operation opName to their GraphQL values
+func applyEnumMappings(opName string, queryParams, inputParams map[string]interface{}) error {
+	verr := &ValidationError{}
+	for _, m := range enumMappings[opName] {
+		if len(m.path) == 0 {
+			continue // the result of the operation itself
+		}
+		if m.path[0] == "input" {
+			if len(m.path) > 1 {
+				mapParam(verr, ast.Path{ast.PathName("input")}, inputParams, m.path[1:], m.toInternal)
+			}
+			continue
+		}
+		mapParam(verr, nil, queryParams, m.path, m.toInternal)
+		inputErr := verr
+		if _, ok := queryParams[m.path[0]]; ok {
+			inputErr = &ValidationError{} // the parameters are mirrored to the input, reported once above
+		}
+		mapParam(inputErr, ast.Path{ast.PathName("input")}, inputParams, m.path, m.toInternal)
+	}
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// mapParam maps the value of the parameter path[0] of params, whose path is parent, with toInternal
+func mapParam(verr *ValidationError, parent ast.Path, params map[string]interface{}, path []string, toInternal map[string]string) {
+	v, ok := params[path[0]]
+	if !ok || v == nil {
+		return
+	}
+	params[path[0]] = mapParamValue(verr, append(append(ast.Path(nil), parent...), ast.PathName(path[0])), v, path[1:], toInternal)
+}
+
+// mapParamValue returns v with the enum values at path mapped, the maps and lists of v are copied, not modified
+func mapParamValue(verr *ValidationError, path ast.Path, v interface{}, rest []string, toInternal map[string]string) interface{} {
+	switch vv := v.(type) {
+	case []interface{}:
+		items := make([]interface{}, len(vv))
+		for i, item := range vv {
+			items[i] = mapParamValue(verr, append(append(ast.Path(nil), path...), ast.PathIndex(i)), item, rest, toInternal)
+		}
+		return items
+	case map[string]interface{}:
+		if len(rest) == 0 {
+			return v
+		}
+		fields := make(map[string]interface{}, len(vv))
+		for k, fv := range vv {
+			fields[k] = fv
+		}
+		mapParam(verr, path, fields, rest, toInternal)
+		return fields
+	case string:
+		if len(rest) > 0 {
+			return v
+		}
+		if internal, ok := toInternal[vv]; ok {
+			return internal
+		}
+		// eg. "state=open,done" of a list parameter
+		values := strings.Split(vv, ",")
+		for i, s := range values {
+			internal, ok := toInternal[s]
+			if !ok {
+				verr.add(path, "must be one of "+strings.Join(externalEnumValues(toInternal), ", "))
+				return v
+			}
+			values[i] = internal
+		}
+		return strings.Join(values, ",")
+	default:
+		return v
+	}
+}
+
+// externalEnumValues returns the sorted external values of toInternal
+func externalEnumValues(toInternal map[string]string) []string {
+	values := make([]string, 0, len(toInternal))
+	for k := range toInternal {
+		values = append(values, fmt.Sprintf("%q", k))
+	}
+	sort.Strings(values)
+	return values
+}
+
+// mapResponseEnums maps the enum values of the result data of operation opName to their external values
+func mapResponseEnums(opName string, data json.RawMessage) (json.RawMessage, error) {
+	var err error
+	for _, m := range enumMappings[opName] {
+		if len(m.toExternal) == 0 {
+			continue
+		}
+		path := append([]string{opName}, m.path...)
+		if data, err = mapResponseValue(data, path, m.toExternal); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// mapResponseValue returns data with the enum values at path mapped with toExternal, keeping the order of the members
+func mapResponseValue(data json.RawMessage, path []string, toExternal map[string]string) (json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return data, nil
+	}
+
+	switch trimmed[0] {
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		buf.WriteByte('[')
+		for i, item := range items {
+			value, err := mapResponseValue(item, path, toExternal)
+			if err != nil {
+				return nil, err
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(value)
+		}
+		buf.WriteByte(']')
+		return buf.Bytes(), nil
+	case '{':
+		if len(path) == 0 {
+			return data, nil
+		}
+		return mapResponseMember(trimmed, path, toExternal)
+	case '"':
+		if len(path) > 0 {
+			return data, nil
+		}
+		var s string
+		if err := json.Unmarshal(trimmed, &s); err != nil {
+			return nil, err
+		}
+		if external, ok := toExternal[s]; ok {
+			return json.Marshal(external)
+		}
+		return data, nil
+	default:
+		return data, nil
+	}
+}
+
+// mapResponseMember maps the member path[0] of the object data, see mapResponseValue
+func mapResponseMember(data json.RawMessage, path []string, toExternal map[string]string) (json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; dec.More(); i++ {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		name, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		if name == path[0] {
+			if value, err = mapResponseValue(value, path[1:], toExternal); err != nil {
+				return nil, err
+			}
+		}
+
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
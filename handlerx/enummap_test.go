@@ -0,0 +1,57 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetEnumMapping(t *testing.T) {
+	setupTestMapping()
+	toInternal := map[string]string{"open": "OPEN", "in-progress": "IN_PROGRESS", "done": "DONE"}
+	toExternal := map[string]string{"OPEN": "open", "IN_PROGRESS": "in-progress", "DONE": "done"}
+	SetEnumMapping("todos.state", toInternal, toExternal)
+	SetEnumMapping("createTodo.input.text", map[string]string{"x": "X"}, nil)
+	defer SetEnumMapping("todos.state", nil, nil)
+	defer SetEnumMapping("createTodo.input.text", nil, nil)
+
+	tests := []struct {
+		Name     string
+		Method   string
+		Target   string
+		Body     string
+		Contains string
+		Code     int
+	}{
+		{Name: "query parameter", Method: http.MethodGet, Target: "/todos?state=in-progress", Contains: `state:IN_PROGRESS`},
+		{Name: "unknown value", Method: http.MethodGet, Target: "/todos?state=IN_PROGRESS", Code: http.StatusUnprocessableEntity},
+		{Name: "unmapped parameter", Method: http.MethodGet, Target: "/todos?limit=5", Contains: `limit:5`},
+		{Name: "input field", Method: http.MethodPost, Target: "/todos", Body: `{"input":{"text":"x","userId":"u1"}}`, Contains: `text:"X"`},
+		{Name: "unknown input value", Method: http.MethodPost, Target: "/todos", Body: `{"input":{"text":"y","userId":"u1"}}`, Code: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(tt.Method, tt.Target, strings.NewReader(tt.Body)), "/todos")
+			query, err := convertHTTPRequestToGraphQLQuery(r, &graphql.RawParams{}, []byte(tt.Body))
+			if tt.Code != 0 {
+				assert.Error(t, err)
+				assert.Equal(t, tt.Code, convertErrorCode(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Contains(t, query, tt.Contains)
+		})
+	}
+
+	t.Run("response", func(t *testing.T) {
+		data, err := mapResponseEnums("todos", json.RawMessage(`{"todos":[{"id":"1","state":"IN_PROGRESS"},{"id":"2","state":"ARCHIVED"},{"id":"3","state":null}]}`))
+		assert.NoError(t, err)
+		assert.Equal(t, `{"todos":[{"id":"1","state":"in-progress"},{"id":"2","state":"ARCHIVED"},{"id":"3","state":null}]}`, string(data))
+	})
+}
@@ -0,0 +1,281 @@
+package handlerx
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// envelopeFieldNames are the JSON keys used when marshaling RESTResponse
+var envelopeFieldNames = struct {
+	Code    string
+	Message string
+	Data    string
+}{
+	Code:    "code",
+	Message: "message",
+	Data:    "data",
+}
+
+// reservedEnvelopeFields are the fixed JSON keys of RESTResponse, which can't be renamed to
+var reservedEnvelopeFields = map[string]bool{
+	"errors": true, "meta": true, "fields": true, "request_id": true, "error_id": true, "dry_run": true, "_debug": true,
+}
+
+// SetEnvelopeFieldNames rewrites the JSON keys of the RESTful response envelope,
+// eg. SetEnvelopeFieldNames("status", "error", "result").
+// Defaults are "code", "message" and "data". GraphQL responses are not affected.
+// The names must not collide with the fixed keys, eg. "errors", "meta" or "request_id".
+func SetEnvelopeFieldNames(code, message, data string) error {
+	if code == "" || message == "" || data == "" {
+		return errors.New("handlerx: envelope field names must not be empty")
+	}
+	if code == message || code == data || message == data {
+		return errors.New("handlerx: envelope field names must be distinct")
+	}
+	for _, name := range []string{code, message, data} {
+		if reservedEnvelopeFields[name] {
+			return fmt.Errorf("handlerx: envelope field name %q is reserved", name)
+		}
+	}
+
+	envelopeFieldNames.Code = code
+	envelopeFieldNames.Message = message
+	envelopeFieldNames.Data = data
+	return nil
+}
+
+// NullDataPolicy is how a null `data` of RESTful error responses is marshaled
+type NullDataPolicy int
+
+const (
+	// NullDataKeep keeps `data` null (default)
+	NullDataKeep NullDataPolicy = iota
+	// NullDataOmit omits the `data` member
+	NullDataOmit
+	// NullDataEmptyObject replaces `data` with {}
+	NullDataEmptyObject
+	// NullDataEmptyList replaces `data` with []
+	NullDataEmptyList
+)
+
+var nullDataPolicy = NullDataKeep
+
+// SetNullDataPolicy sets how the null or missing `data` of RESTful error responses is marshaled,
+// successful responses with null `data` are not affected. The default is NullDataKeep.
+func SetNullDataPolicy(policy NullDataPolicy) {
+	nullDataPolicy = policy
+}
+
+// applyNullDataPolicy applies the null data policy to the error response r
+func applyNullDataPolicy(r *RESTResponse) {
+	if !isEmptyData(r.Data) {
+		return
+	}
+	switch nullDataPolicy {
+	case NullDataOmit:
+		r.omitData = true
+	case NullDataEmptyObject:
+		r.Data = json.RawMessage("{}")
+	case NullDataEmptyList:
+		r.Data = json.RawMessage("[]")
+	}
+}
+
+// envelopeField is a single key/value pair of the RESTful response envelope
+type envelopeField struct {
+	key   string
+	value interface{}
+}
+
+// marshalEnvelope marshals fields into a JSON object, keeping the given order
+func marshalEnvelope(fields []envelopeField) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		val, err := marshalEnvelopeValue(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalEnvelopeValue marshals the value of an envelope member with jsonCodec, raw values (eg. `data`)
+// are copied verbatim instead of being scanned again, a nil one is null
+func marshalEnvelopeValue(v interface{}) ([]byte, error) {
+	if raw, ok := v.(json.RawMessage); ok {
+		if len(raw) == 0 {
+			return []byte("null"), nil
+		}
+		return raw, nil
+	}
+	return jsonCodec.Marshal(v)
+}
+
+// envelopeFields returns the envelope members of r in output order
+func (r RESTResponse) envelopeFields() []envelopeField {
+	fields := []envelopeField{{envelopeFieldNames.Code, r.Code}}
+	if r.Message != "" {
+		fields = append(fields, envelopeField{envelopeFieldNames.Message, r.Message})
+	}
+	if !r.omitData {
+		fields = append(fields, envelopeField{envelopeFieldNames.Data, r.Data})
+	}
+	if len(r.Meta) > 0 {
+		fields = append(fields, envelopeField{"meta", r.Meta})
+	}
+	if len(r.Errors) > 0 {
+		fields = append(fields, envelopeField{"errors", r.Errors})
+	}
+	if len(r.Fields) > 0 {
+		fields = append(fields, envelopeField{"fields", r.Fields})
+	}
+	if r.DryRun {
+		fields = append(fields, envelopeField{"dry_run", true})
+	}
+	if r.RequestID != "" {
+		fields = append(fields, envelopeField{"request_id", r.RequestID})
+	}
+	if r.ErrorID != "" {
+		fields = append(fields, envelopeField{"error_id", r.ErrorID})
+	}
+	if r.Debug != nil {
+		fields = append(fields, envelopeField{"_debug", r.Debug})
+	}
+	return fields
+}
+
+// MarshalJSON implements json.Marshaler, honoring the configured envelope field names
+func (r RESTResponse) MarshalJSON() ([]byte, error) {
+	return marshalEnvelope(r.envelopeFields())
+}
+
+// WriteTo implements io.WriterTo, it streams the JSON envelope to w.
+// Raw members (eg. `data`) are copied as-is, so they are never buffered twice.
+func (r RESTResponse) WriteTo(w io.Writer) (int64, error) {
+	return r.writeTo(w, nil)
+}
+
+// writeTo streams the JSON envelope to w, the `data` member is written by writeData unless it's nil
+func (r RESTResponse) writeTo(w io.Writer, writeData func(w io.Writer) error) (int64, error) {
+	cw := &countingWriter{w: w}
+	if _, err := cw.Write([]byte{'{'}); err != nil {
+		return cw.n, err
+	}
+	for i, f := range r.envelopeFields() {
+		key, err := json.Marshal(f.key)
+		if err != nil {
+			return cw.n, err
+		}
+		if i > 0 {
+			key = append([]byte{','}, key...)
+		}
+		if _, err := cw.Write(append(key, ':')); err != nil {
+			return cw.n, err
+		}
+		if writeData != nil && f.key == envelopeFieldNames.Data {
+			if err := writeData(cw); err != nil {
+				return cw.n, err
+			}
+			continue
+		}
+
+		val, err := marshalEnvelopeValue(f.value)
+		if err != nil {
+			return cw.n, err
+		}
+		if _, err := cw.Write(val); err != nil {
+			return cw.n, err
+		}
+	}
+	_, err := cw.Write([]byte{'}'})
+	return cw.n, err
+}
+
+// countingWriter counts the bytes written to the underlying writer
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// MarshalXML implements xml.Marshaler, honoring the configured envelope field names.
+// Every member is converted to its JSON form first, so `data` is encoded as nested elements
+// rather than raw bytes, and array elements are encoded as <item>.
+func (r RESTResponse) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Local: "response"}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, f := range r.envelopeFields() {
+		b, err := json.Marshal(f.value)
+		if err != nil {
+			return err
+		}
+		var v interface{}
+		if err := jsonDecode(bytes.NewReader(b), &v); err != nil {
+			return err
+		}
+		if err := encodeXMLValue(e, f.key, v); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// encodeXMLValue encodes a generic JSON value as an XML element named name
+func encodeXMLValue(e *xml.Encoder, name string, v interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	switch vv := v.(type) {
+	case nil:
+		return e.EncodeElement("", start)
+	case map[string]interface{}:
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(vv))
+		for k := range vv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := encodeXMLValue(e, k, vv[k]); err != nil {
+				return err
+			}
+		}
+		return e.EncodeToken(start.End())
+	case []interface{}:
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, item := range vv {
+			if err := encodeXMLValue(e, "item", item); err != nil {
+				return err
+			}
+		}
+		return e.EncodeToken(start.End())
+	default:
+		return e.EncodeElement(fmt.Sprint(vv), start)
+	}
+}
@@ -0,0 +1,72 @@
+package handlerx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+var (
+	etagEnabled    bool
+	etagOperations = make(map[string]bool)
+)
+
+// SetETagEnabled enables the ETag header of successful RESTful GET responses, it's the SHA-256 of
+// the `data`. Requests with a matching If-None-Match header get an empty 304 Not Modified.
+func SetETagEnabled(enabled bool) {
+	etagEnabled = enabled
+}
+
+// SetOperationETagEnabled overrides SetETagEnabled for operation opName
+func SetOperationETagEnabled(opName string, enabled bool) {
+	etagOperations[opName] = enabled
+}
+
+// SetResponseETag sets the ETag of the current RESTful response (eg. a version hash known by the resolver),
+// so that it's not computed from the `data`. etag is quoted if needed, eg. "v42" is sent as `"v42"`.
+func SetResponseETag(ctx context.Context, etag string) {
+	if state := responseStateFromContext(ctx); state != nil {
+		if !strings.HasSuffix(etag, `"`) {
+			etag = `"` + etag + `"`
+		}
+		state.etag = etag
+	}
+}
+
+// isETagEnabled reports whether the response to req carries an ETag
+func isETagEnabled(req *http.Request) bool {
+	if req == nil || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return false
+	}
+	opName, _ := restOperationName(req)
+	if enabled, ok := etagOperations[opName]; ok {
+		return enabled
+	}
+	return etagEnabled
+}
+
+// computeETag returns a strong ETag of the data in the content type
+func computeETag(contentType string, data []byte) string {
+	h := sha256.New()
+	h.Write([]byte(contentType))
+	h.Write([]byte{0})
+	h.Write(data)
+	return `"` + hex.EncodeToString(h.Sum(nil)) + `"`
+}
+
+// etagMatches reports whether the If-None-Match header matches etag, using the weak comparison
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
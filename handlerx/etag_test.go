@@ -0,0 +1,47 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetETagEnabled(t *testing.T) {
+	setupTestMapping()
+	defer SetETagEnabled(false)
+	defer delete(etagOperations, "todo")
+	SetETagEnabled(true)
+
+	serve := func(target string, pattern string, ifNoneMatch string, etag string) *httptest.ResponseRecorder {
+		r := WithRoute(httptest.NewRequest(http.MethodGet, target, nil), pattern)
+		r = withResponseState(r)
+		r.Header.Set("If-None-Match", ifNoneMatch)
+		if etag != "" {
+			SetResponseETag(r.Context(), etag)
+		}
+		w := httptest.NewRecorder()
+		writeJSON(w, r, &graphql.Response{Data: json.RawMessage(`{"todos":[{"id":"1"}]}`)}, true)
+		return w
+	}
+
+	w := serve("/todos", "/todos", "", "")
+	etag := w.Header().Get("ETag")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, etag, 66)
+
+	w = serve("/todos", "/todos", `"other", `+etag, "")
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = serve("/todos", "/todos", `"v42"`, "v42")
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	SetOperationETagEnabled("todo", false)
+	w = serve("/todos/1", "/todos/{id}", etag, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+}
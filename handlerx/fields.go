@@ -0,0 +1,122 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// FieldsParam is the query parameter selecting the fields of RESTful responses, eg. `?fields=id,user.name`
+const FieldsParam = "fields"
+
+var (
+	fieldFiltering       bool
+	strictFieldFiltering bool
+)
+
+// SetFieldFiltering enables filtering the `data` of RESTful responses down to the fields listed in
+// the `fields` query parameter, nested fields are dotted and lists are filtered item by item.
+// Operations with a `fields` argument are not filtered.
+func SetFieldFiltering(enabled bool) {
+	fieldFiltering = enabled
+}
+
+// SetStrictFieldFiltering makes unknown fields in the `fields` query parameter a 422 error,
+// by default they're ignored.
+func SetStrictFieldFiltering(strict bool) {
+	strictFieldFiltering = strict
+}
+
+// fieldTree is the parsed `fields` parameter, a nil subtree selects the whole value
+type fieldTree map[string]fieldTree
+
+// requestedFields returns the fields requested by req, or nil if the response isn't filtered
+func requestedFields(req *http.Request, opName string) fieldTree {
+	if !fieldFiltering || req == nil {
+		return nil
+	}
+	if _, ok := restOperation2Arguments[opName][FieldsParam]; ok {
+		return nil
+	}
+	values, ok := req.URL.Query()[FieldsParam]
+	if !ok {
+		return nil
+	}
+
+	tree := make(fieldTree)
+	for _, v := range values {
+		for _, field := range strings.Split(v, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				tree.add(strings.Split(field, "."))
+			}
+		}
+	}
+	return tree
+}
+
+func (t fieldTree) add(path []string) {
+	name := path[0]
+	sub, ok := t[name]
+	if ok && sub == nil {
+		return // already selected as a whole
+	}
+	if len(path) == 1 {
+		t[name] = nil
+		return
+	}
+	if sub == nil {
+		sub = make(fieldTree)
+		t[name] = sub
+	}
+	sub.add(path[1:])
+}
+
+// filterFields filters data down to the fields of tree, keeping the order of the members.
+// The dotted paths of the requested fields missing in data are returned as unknown.
+func filterFields(data json.RawMessage, tree fieldTree) (json.RawMessage, []string, error) {
+	unknown := make(map[string]bool)
+	filtered, err := tree.walk("", unknown).walk(data)
+
+	paths := make([]string, 0, len(unknown))
+	for path := range unknown {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return filtered, paths, err
+}
+
+// walk returns the walk filtering values down to the fields of t, the dotted paths of the fields
+// missing in the objects (scalars have none) are added to unknown, with prefix
+func (t fieldTree) walk(prefix string, unknown map[string]bool) *jsonWalk {
+	seen := make(map[string]bool)
+	return &jsonWalk{
+		leaf: func(value json.RawMessage) json.RawMessage {
+			if string(value) != "null" {
+				for name := range t {
+					unknown[prefix+name] = true
+				}
+			}
+			return value
+		},
+		member: func(name string, value json.RawMessage) (*jsonWalk, bool) {
+			sub, ok := t[name]
+			if !ok {
+				return nil, false
+			}
+			seen[name] = true
+			if sub == nil {
+				return nil, true
+			}
+			return sub.walk(prefix+name+".", unknown), true
+		},
+		end: func() {
+			for name := range t {
+				if !seen[name] {
+					unknown[prefix+name] = true
+				}
+			}
+			seen = make(map[string]bool) // the next object, eg. of a list
+		},
+	}
+}
@@ -0,0 +1,73 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterFields(t *testing.T) {
+	data := `[{"id":"1","text":"x","user":{"id":"u","name":"n"},"tags":[{"name":"a","color":"red"}]},null]`
+
+	tests := []struct {
+		Name     string
+		Fields   string
+		Expected string
+		Unknown  []string
+	}{
+		{Name: "top level", Fields: "text,id", Expected: `[{"id":"1","text":"x"},null]`},
+		{Name: "nested", Fields: "id,user.name,tags.name", Expected: `[{"id":"1","user":{"name":"n"},"tags":[{"name":"a"}]},null]`},
+		{Name: "whole object wins", Fields: "user.name,user", Expected: `[{"user":{"id":"u","name":"n"}},null]`},
+		{Name: "unknown", Fields: "id,title,user.email,text.x", Expected: `[{"id":"1","text":"x","user":{}},null]`, Unknown: []string{"text.x", "title", "user.email"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetFieldFiltering(true)
+			defer SetFieldFiltering(false)
+			r := httptest.NewRequest(http.MethodGet, "/todos?fields="+tt.Fields, nil)
+			filtered, unknown, err := filterFields(json.RawMessage(data), requestedFields(r, "todos"))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.Expected, string(filtered))
+			assert.Equal(t, len(tt.Unknown), len(unknown))
+			if len(tt.Unknown) > 0 {
+				assert.Equal(t, tt.Unknown, unknown)
+			}
+		})
+	}
+}
+
+func TestSetFieldFiltering(t *testing.T) {
+	setupTestMapping()
+	defer SetFieldFiltering(false)
+	defer SetStrictFieldFiltering(false)
+
+	tests := []struct {
+		Name     string
+		Enabled  bool
+		Strict   bool
+		URL      string
+		Expected string
+	}{
+		{Name: "disabled", URL: "/todos/1?fields=id", Expected: `{"code":0,"data":{"id":"1","text":"x"}}`},
+		{Name: "enabled", Enabled: true, URL: "/todos/1?fields=id", Expected: `{"code":0,"data":{"id":"1"}}`},
+		{Name: "without fields", Enabled: true, URL: "/todos/1", Expected: `{"code":0,"data":{"id":"1","text":"x"}}`},
+		{Name: "unknown ignored", Enabled: true, URL: "/todos/1?fields=id,title", Expected: `{"code":0,"data":{"id":"1"}}`},
+		{Name: "unknown rejected", Enabled: true, Strict: true, URL: "/todos/1?fields=id,title", Expected: `{"code":422,"message":"unknown fields: title","data":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetFieldFiltering(tt.Enabled)
+			SetStrictFieldFiltering(tt.Strict)
+			r := WithRoute(httptest.NewRequest(http.MethodGet, tt.URL, nil), "/todos/{id}", "1")
+			w := httptest.NewRecorder()
+			writeJSON(w, r, &graphql.Response{Data: json.RawMessage(`{"todo":{"id":"1","text":"x"}}`)}, true)
+			assert.Equal(t, tt.Expected, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package handlerx
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// formBodyParams converts form values to body parameters, they are coerced to the argument types
+// like query parameters. Besides repeated keys, brackets are supported for lists and input objects:
+//   - "ids[]=1&ids[]=2" is {"ids": ["1", "2"]}
+//   - "input[text]=x&input[tags][]=a" is {"input": {"text": "x", "tags": ["a"]}}
+func formBodyParams(values url.Values) map[string]interface{} {
+	bodyParams := make(map[string]interface{})
+	for k, v := range values {
+		path := parseFormKey(k)
+		for _, vv := range v {
+			setFormValue(bodyParams, path, vv)
+		}
+	}
+	return bodyParams
+}
+
+// parseFormKey splits a form key by brackets, eg. "input[tags][]" is ["input", "tags", ""].
+// Malformed keys are kept as-is.
+func parseFormKey(key string) []string {
+	i := strings.IndexByte(key, '[')
+	if i <= 0 {
+		return []string{key}
+	}
+
+	path := []string{key[:i]}
+	for rest := key[i:]; rest != ""; {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end < 0 {
+			return []string{key}
+		}
+		path = append(path, rest[1:end])
+		rest = rest[end+1:]
+	}
+	return path
+}
+
+func setFormValue(params map[string]interface{}, path []string, v string) {
+	name := path[0]
+	switch {
+	case len(path) == 1:
+		switch old := params[name].(type) {
+		case nil:
+			params[name] = v
+		case []interface{}:
+			params[name] = append(old, v)
+		default:
+			params[name] = []interface{}{old, v}
+		}
+	case len(path) == 2 && path[1] == "":
+		vals, _ := params[name].([]interface{})
+		params[name] = append(vals, v)
+	default:
+		child, ok := params[name].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			params[name] = child
+		}
+		setFormValue(child, path[1:], v)
+	}
+}
+
+func (h POST) doForm(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
+	isRESTful := true
+	start := graphql.Now()
+
+	limitRequestBody(w, r)
+	if err := r.ParseForm(); err != nil {
+		if isBodyTooLarge(err) {
+			writeHeader(w, r, http.StatusRequestEntityTooLarge, isRESTful)
+			writeJSONErrorWithExtensions(w, r, http.StatusRequestEntityTooLarge, isRESTful, fmt.Sprintf("request body exceeds %d bytes", maxRequestBytes),
+				map[string]interface{}{"reason": "BODY_TOO_LARGE", "retryable": false})
+			return
+		}
+		writeHeader(w, r, http.StatusBadRequest, isRESTful)
+		writeJSONErrorf(w, r, http.StatusBadRequest, isRESTful, "form body could not be parsed: "+err.Error())
+		return
+	}
+
+	h.doRESTful(w, r, exec, new(graphql.RawParams), formBodyParams(r.PostForm), start)
+}
@@ -0,0 +1,53 @@
+package handlerx
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormBodyParams(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Form     string
+		Expected map[string]interface{}
+	}{
+		{
+			Name:     "plain values",
+			Form:     "text=x&done=true",
+			Expected: map[string]interface{}{"text": "x", "done": "true"},
+		},
+		{
+			Name:     "repeated keys",
+			Form:     "ids=1&ids=2",
+			Expected: map[string]interface{}{"ids": []interface{}{"1", "2"}},
+		},
+		{
+			Name:     "bracket list",
+			Form:     "ids[]=1",
+			Expected: map[string]interface{}{"ids": []interface{}{"1"}},
+		},
+		{
+			Name: "bracket input object",
+			Form: "input[text]=x&input[tags][]=a&input[tags][]=b",
+			Expected: map[string]interface{}{"input": map[string]interface{}{
+				"text": "x",
+				"tags": []interface{}{"a", "b"},
+			}},
+		},
+		{
+			Name:     "malformed key",
+			Form:     "a[b=1",
+			Expected: map[string]interface{}{"a[b": "1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.Form)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.Expected, formBodyParams(values))
+		})
+	}
+}
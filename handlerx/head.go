@@ -0,0 +1,46 @@
+package handlerx
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// headResponseWriter discards the body of the response to a HEAD request, and sets its Content-Length
+type headResponseWriter struct {
+	http.ResponseWriter
+	status int
+	length int
+}
+
+func (w *headResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.length += len(b)
+	return len(b), nil
+}
+
+// wrapHead returns a writer discarding the body if r is a HEAD request, it's handled like GET otherwise.
+// The returned func writes the headers and must be called once the response is written.
+func wrapHead(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, func()) {
+	if r.Method != http.MethodHead {
+		return w, func() {}
+	}
+
+	hw := &headResponseWriter{ResponseWriter: w}
+	return hw, func() {
+		if hw.status == 0 {
+			hw.status = http.StatusOK
+		}
+		if hw.status != http.StatusNoContent && hw.status != http.StatusNotModified && w.Header().Get("Content-Length") == "" {
+			w.Header().Set("Content-Length", strconv.Itoa(hw.length))
+		}
+		w.WriteHeader(hw.status)
+	}
+}
@@ -0,0 +1,47 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapHead(t *testing.T) {
+	setupTestMapping()
+	SetETagEnabled(true)
+	defer SetETagEnabled(false)
+
+	response := &graphql.Response{Data: json.RawMessage(`{"todo":{"id":"1"}}`)}
+	get := WithRoute(httptest.NewRequest(http.MethodGet, "/todos/1", nil), "/todos/{id}", "1")
+	gw := httptest.NewRecorder()
+	writeJSON(gw, get, response, true)
+
+	head := WithRoute(httptest.NewRequest(http.MethodHead, "/todos/1", nil), "/todos/{id}", "1")
+	op, ok := restOperationName(head)
+	assert.True(t, ok)
+	assert.Equal(t, "todo", op)
+
+	rec := httptest.NewRecorder()
+	w, finish := wrapHead(rec, head)
+	writeJSON(w, head, response, true)
+	finish()
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, strconv.Itoa(gw.Body.Len()), rec.Header().Get("Content-Length"))
+	assert.Equal(t, gw.Header().Get("ETag"), rec.Header().Get("ETag"))
+
+	// conditional HEAD
+	head.Header.Set("If-None-Match", gw.Header().Get("ETag"))
+	rec = httptest.NewRecorder()
+	w, finish = wrapHead(rec, head)
+	writeJSON(w, head, response, true)
+	finish()
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Header().Get("Content-Length"))
+}
@@ -0,0 +1,74 @@
+package handlerx
+
+import (
+	"net/http"
+	"strings"
+)
+
+// headerVariable maps an HTTP header to a variable of an operation
+type headerVariable struct {
+	header   string
+	variable string
+}
+
+// GraphQL Operation => Header Variables
+var operationHeaderVariables = make(map[string][]headerVariable)
+
+// SetHeaderVariable makes RESTful requests of operation opName read the argument (or input field)
+// variableName from the HTTP header headerName, eg. an API version or an idempotency key. The header
+// value is coerced to the variable type like query parameters, and takes precedence over query and
+// body parameters. A missing header leaves the variable to the other parameters or its default, unless
+// the variable is non-null, which is reported as 400. Pass an empty headerName to remove the mapping.
+func SetHeaderVariable(opName, headerName, variableName string) {
+	vars := make([]headerVariable, 0, len(operationHeaderVariables[opName])+1)
+	for _, hv := range operationHeaderVariables[opName] {
+		if hv.variable != variableName {
+			vars = append(vars, hv)
+		}
+	}
+	if headerName != "" {
+		vars = append(vars, headerVariable{header: headerName, variable: variableName})
+	}
+	if len(vars) == 0 {
+		delete(operationHeaderVariables, opName)
+	} else {
+		operationHeaderVariables[opName] = vars
+	}
+}
+
+// applyHeaderVariables sets the header variables of operationName from r to the request parameters
+func applyHeaderVariables(r *http.Request, operationName string, argTypes StringMap, queryParams, inputParams map[string]interface{}) error {
+	for _, hv := range operationHeaderVariables[operationName] {
+		values := r.Header.Values(hv.header)
+		if len(values) == 0 {
+			if _, ok := inputParams[hv.variable]; !ok && isNonNullParam(argTypes, hv.variable) {
+				return &paramError{param: hv.variable, msg: "missing header " + hv.header + " of " + hv.variable}
+			}
+			continue
+		}
+
+		var val interface{}
+		if len(values) > 1 && isListParam(argTypes, hv.variable) {
+			vals := make([]interface{}, 0, len(values))
+			for _, v := range values {
+				vals = append(vals, v)
+			}
+			val = vals
+		} else {
+			val = strings.Join(values, ",")
+		}
+		inputParams[hv.variable] = val
+		queryParams[hv.variable] = val
+	}
+	return nil
+}
+
+// isNonNullParam reports whether parameter k is a non-null argument, or a non-null field of the input argument
+func isNonNullParam(argTypes StringMap, k string) bool {
+	argType, ok := argTypes[k]
+	if !ok {
+		_, inputType := getUnderlayingArgType(argTypes["input"])
+		argType = inputType2FieldDefinitions[inputType][k]
+	}
+	return strings.HasSuffix(argType, "!")
+}
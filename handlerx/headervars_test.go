@@ -0,0 +1,56 @@
+package handlerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetHeaderVariable(t *testing.T) {
+	setupTestMapping()
+	SetHeaderVariable("todos", "X-Limit", "limit")
+	SetHeaderVariable("createTodo", "X-User-Id", "userId")
+	defer SetHeaderVariable("todos", "", "limit")
+	defer SetHeaderVariable("createTodo", "", "userId")
+
+	tests := []struct {
+		Name     string
+		Method   string
+		Target   string
+		Body     string
+		Header   http.Header
+		Contains []string
+		Code     int
+	}{
+		{Name: "typed header", Method: http.MethodGet, Target: "/todos", Header: http.Header{"X-Limit": {"5"}}, Contains: []string{`limit:5`}},
+		{Name: "header overrides query", Method: http.MethodGet, Target: "/todos?limit=1", Header: http.Header{"X-Limit": {"5"}}, Contains: []string{`limit:5`}},
+		{Name: "missing nullable header", Method: http.MethodGet, Target: "/todos?limit=1", Contains: []string{`limit:1`}},
+		{Name: "invalid header", Method: http.MethodGet, Target: "/todos", Header: http.Header{"X-Limit": {"five"}}, Code: http.StatusBadRequest},
+		{Name: "input field header", Method: http.MethodPost, Target: "/todos", Body: `{"text":"x"}`, Header: http.Header{"X-User-Id": {"u1"}}, Contains: []string{`userId:"u1"`, `text:"x"`}},
+		{Name: "missing non-null header", Method: http.MethodPost, Target: "/todos", Body: `{"text":"x"}`, Code: http.StatusBadRequest},
+		{Name: "non-null from body", Method: http.MethodPost, Target: "/todos", Body: `{"text":"x","userId":"u2"}`, Contains: []string{`userId:"u2"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(tt.Method, tt.Target, strings.NewReader(tt.Body)), "/todos")
+			for k, v := range tt.Header {
+				r.Header[k] = v
+			}
+			query, err := convertHTTPRequestToGraphQLQuery(r, &graphql.RawParams{}, []byte(tt.Body))
+			if tt.Code != 0 {
+				assert.Error(t, err)
+				assert.Equal(t, tt.Code, convertErrorCode(err))
+				return
+			}
+			assert.NoError(t, err)
+			for _, c := range tt.Contains {
+				assert.Contains(t, query, c)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// healthCheckError is the error of a named health check
+type healthCheckError struct {
+	name string
+	err  error
+}
+
+func (e *healthCheckError) Error() string {
+	return e.name + ": " + e.err.Error()
+}
+
+func (e *healthCheckError) Unwrap() error {
+	return e.err
+}
+
+// NamedHealthCheck names a health check, the name is reported by HealthHandler when it fails
+func NamedHealthCheck(name string, check func() error) func() error {
+	return func() error {
+		if err := check(); err != nil {
+			return &healthCheckError{name: name, err: err}
+		}
+		return nil
+	}
+}
+
+// GraphQLHealthCheck returns a health check executing a `{ __typename }` query on the GraphQL
+// server srv at path (eg. "/query"), it fails unless the query succeeds.
+func GraphQLHealthCheck(srv http.Handler, path string) func() error {
+	return NamedHealthCheck("graphql", func() error {
+		req, err := http.NewRequest(http.MethodPost, path, strings.NewReader(`{"query":"{ __typename }"}`))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		rec := &batchResponseWriter{header: make(http.Header)}
+		srv.ServeHTTP(rec, req)
+
+		var resp graphql.Response
+		if err := json.Unmarshal(rec.body.Bytes(), &resp); err != nil {
+			return fmt.Errorf("status %d: %v", rec.status, err)
+		}
+		if len(resp.Errors) > 0 {
+			msgs := make([]string, 0, len(resp.Errors))
+			for _, e := range resp.Errors {
+				msgs = append(msgs, e.Message)
+			}
+			return errors.New(strings.Join(msgs, "; "))
+		}
+		if rec.status != http.StatusOK {
+			return fmt.Errorf("status %d", rec.status)
+		}
+		return nil
+	})
+}
+
+// HealthHandler returns a handler for health and readiness endpoints (eg. /healthz and /readyz),
+// it runs the checks and responds 200 when all of them pass, or 503 with the names of the failing
+// checks otherwise, in the RESTful envelope. The `data` of unhealthy responses maps the failing checks
+// to their errors, checks are named by NamedHealthCheck and unnamed ones are reported as "check#<index>".
+func HealthHandler(checks ...func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", jsonContentType)
+		w.Header().Set("Cache-Control", "no-store")
+		r = withRequestID(w, r)
+
+		failed := make([]string, 0)
+		reasons := make(map[string]string)
+		for i, check := range checks {
+			err := check()
+			if err == nil {
+				continue
+			}
+			name := fmt.Sprintf("check#%d", i)
+			var herr *healthCheckError
+			if errors.As(err, &herr) {
+				name, err = herr.name, herr.err
+			}
+			failed = append(failed, name)
+			reasons[name] = err.Error()
+		}
+
+		resp := &graphql.Response{}
+		status := http.StatusOK
+		if len(failed) > 0 {
+			status = http.StatusServiceUnavailable
+			resp.Data, _ = json.Marshal(map[string]interface{}{"checks": reasons})
+			resp.Errors = gqlerror.List{{
+				Message:    "unhealthy: " + strings.Join(failed, ", "),
+				Extensions: map[string]interface{}{"code": status},
+			}}
+		}
+		writeHeader(w, r, status, true)
+		writeJSON(w, r, resp, true)
+	}
+}
@@ -0,0 +1,64 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthHandler(t *testing.T) {
+	graphqlOK := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"__typename":"Query"}}`))
+	})
+	graphqlDown := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"errors":[{"message":"schema not loaded"}],"data":null}`))
+	})
+
+	tests := []struct {
+		Name    string
+		Checks  []func() error
+		Status  int
+		Message string
+		Data    map[string]interface{}
+	}{
+		{Name: "no checks", Status: http.StatusOK},
+		{
+			Name:   "all passed",
+			Checks: []func() error{NamedHealthCheck("db", func() error { return nil }), GraphQLHealthCheck(graphqlOK, "/query")},
+			Status: http.StatusOK,
+		},
+		{
+			Name:    "failed checks",
+			Checks:  []func() error{NamedHealthCheck("db", func() error { return errors.New("connection refused") }), func() error { return nil }, GraphQLHealthCheck(graphqlDown, "/query")},
+			Status:  http.StatusServiceUnavailable,
+			Message: "unhealthy: db, graphql",
+			Data:    map[string]interface{}{"db": "connection refused", "graphql": "schema not loaded"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			HealthHandler(tt.Checks...)(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			assert.Equal(t, tt.Status, w.Code)
+
+			var resp map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			if tt.Message != "" {
+				assert.Equal(t, float64(tt.Status), resp["code"])
+				assert.Equal(t, tt.Message, resp["message"])
+			} else {
+				assert.Equal(t, float64(0), resp["code"])
+			}
+			if tt.Data != nil {
+				assert.Equal(t, tt.Data, resp["data"])
+			} else {
+				assert.Nil(t, resp["data"])
+			}
+		})
+	}
+}
@@ -19,10 +19,38 @@ func (h DELETE) Supports(r *http.Request) bool {
 }
 
 func (h DELETE) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", jsonContentType)
+	r = withRequestID(w, r)
+	setCORSHeaders(w, r)
+	r = withResponseState(r)
+	w = withServerTiming(w, r)
+	r = withDryRun(r)
+	w, closeWriter := wrapCompression(w, r)
+	defer closeWriter()
+	w, observe := wrapMetrics(w, r)
+	defer observe()
+	r, endSpan := startSpan(w, r)
+	defer endSpan()
+	r = enrichContext(r)
+	if !allowRequest(w, r) {
+		return
+	}
+	if !allowRouteMethod(w, r) {
+		return
+	}
+	w, finishCapture := withCapture(w, r)
+	defer finishCapture()
+	w, finishIdempotency, ok := withIdempotency(w, r)
+	if !ok {
+		return
+	}
+	defer finishIdempotency()
 
 	// https://stackoverflow.com/questions/43021058/golang-read-request-body-multiple-times
-	body, _ := ioutil.ReadAll(r.Body)
+	body, ok := readRequestBody(w, r)
+	if !ok {
+		return
+	}
 	r.Body = ioutil.NopCloser(bytes.NewBuffer(body))
 
 	params := &graphql.RawParams{}
@@ -35,8 +63,8 @@ func (h DELETE) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExe
 
 		queryString, err := convertHTTPRequestToGraphQLQuery(r, params, body)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			writeJSONErrorf(w, http.StatusUnprocessableEntity, isRESTful, "query body could not be parsed: "+err.Error())
+			writeHeader(w, r, http.StatusBadRequest, isRESTful)
+			writeConvertError(w, r, err, isRESTful, "query body could not be parsed")
 			return
 		}
 		params.Query = queryString
@@ -49,13 +77,9 @@ func (h DELETE) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExe
 
 	rc, err := exec.CreateOperationContext(r.Context(), params)
 	if err != nil {
-		w.WriteHeader(statusFor(err))
-		resp := exec.DispatchError(graphql.WithOperationContext(r.Context(), rc), err)
-		writeJSON(w, resp, isRESTful)
+		writeOperationError(w, r, exec, rc, err, isRESTful)
 		return
 	}
 
-	ctx := graphql.WithOperationContext(r.Context(), rc)
-	responses, ctx := exec.DispatchOperation(ctx, rc)
-	writeJSON(w, responses(ctx), isRESTful)
+	writeOperation(w, r, exec, rc, isRESTful)
 }
@@ -21,26 +21,58 @@ func (h GET) Supports(r *http.Request) bool {
 		return false
 	}
 
-	return r.Method == "GET"
+	return r.Method == "GET" || r.Method == "HEAD"
 }
 
 func (h GET) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", jsonContentType)
+	r = withRequestID(w, r)
+	setCORSHeaders(w, r)
+	r = withResponseState(r)
+	w = withServerTiming(w, r)
+	w, finishHead := wrapHead(w, r)
+	defer finishHead()
+	w, closeWriter := wrapCompression(w, r)
+	defer closeWriter()
+	w, observe := wrapMetrics(w, r)
+	defer observe()
+	r, endSpan := startSpan(w, r)
+	defer endSpan()
+	r = enrichContext(r)
+	if !allowRequest(w, r) {
+		return
+	}
+	if !allowRouteMethod(w, r) {
+		return
+	}
+	w, finishCapture := withCapture(w, r)
+	defer finishCapture()
+	w, finishCache, ok := withResponseCache(w, r)
+	if !ok {
+		return
+	}
+	defer finishCache()
 
 	// https://stackoverflow.com/questions/43021058/golang-read-request-body-multiple-times
-	body, _ := ioutil.ReadAll(r.Body)
+	body, ok := readRequestBody(w, r)
+	if !ok {
+		return
+	}
 	r.Body = ioutil.NopCloser(bytes.NewBuffer(body))
 
 	params := &graphql.RawParams{
 		Query:         r.URL.Query().Get("query"),
 		OperationName: r.URL.Query().Get("operationName"),
 	}
+	if isAutomaticPersistedQuery(r) {
+		params.Query = "" // a RESTful request registering its automatic persisted query
+	}
 	params.ReadTime.Start = graphql.Now()
 
 	if variables := r.URL.Query().Get("variables"); variables != "" {
 		if err := jsonDecode(strings.NewReader(variables), &params.Variables); err != nil {
 			w.WriteHeader(http.StatusBadRequest)
-			writeJSONError(w, http.StatusUnprocessableEntity, false, "variables could not be decoded")
+			writeJSONError(w, r, http.StatusUnprocessableEntity, false, "variables could not be decoded")
 			return
 		}
 	}
@@ -48,7 +80,7 @@ func (h GET) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecut
 	if extensions := r.URL.Query().Get("extensions"); extensions != "" {
 		if err := jsonDecode(strings.NewReader(extensions), &params.Extensions); err != nil {
 			w.WriteHeader(http.StatusBadRequest)
-			writeJSONError(w, http.StatusUnprocessableEntity, false, "extensions could not be decoded")
+			writeJSONError(w, r, http.StatusUnprocessableEntity, false, "extensions could not be decoded")
 			return
 		}
 	}
@@ -60,8 +92,8 @@ func (h GET) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecut
 
 		queryString, err := convertHTTPRequestToGraphQLQuery(r, params, body)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			writeJSONErrorf(w, http.StatusUnprocessableEntity, isRESTful, "json body could not be decoded: "+err.Error())
+			writeHeader(w, r, http.StatusBadRequest, isRESTful)
+			writeConvertError(w, r, err, isRESTful, "json body could not be decoded")
 			return
 		}
 		params.Query = queryString
@@ -74,19 +106,20 @@ func (h GET) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecut
 
 	rc, err := exec.CreateOperationContext(r.Context(), params)
 	if err != nil {
-		w.WriteHeader(statusFor(err))
-		resp := exec.DispatchError(graphql.WithOperationContext(r.Context(), rc), err)
-		writeJSON(w, resp, isRESTful)
+		writeOperationError(w, r, exec, rc, err, isRESTful)
 		return
 	}
 
 	op := rc.Doc.Operations.ForName(rc.OperationName)
+	if op.Operation == ast.Subscription && isRESTful && r.Method == http.MethodGet {
+		writeEventStream(w, r, exec, rc)
+		return
+	}
 	if op.Operation != ast.Query {
-		w.WriteHeader(http.StatusNotAcceptable)
-		writeJSONError(w, http.StatusBadRequest, isRESTful, "GET requests only allow query operations")
+		writeHeader(w, r, http.StatusNotAcceptable, isRESTful)
+		writeJSONError(w, r, http.StatusBadRequest, isRESTful, "GET requests only allow query operations")
 		return
 	}
 
-	responses, ctx := exec.DispatchOperation(r.Context(), rc)
-	writeJSON(w, responses(ctx), isRESTful)
+	writeOperation(w, r, exec, rc, isRESTful)
 }
@@ -6,21 +6,19 @@ import (
 	"github.com/99designs/gqlgen/graphql"
 )
 
-// Options responds to http OPTIONS and HEAD requests
+// Options responds to http OPTIONS requests, HEAD requests are handled by GET
 type Options struct{}
 
 var _ graphql.Transport = Options{}
 
 func (o Options) Supports(r *http.Request) bool {
-	return r.Method == "HEAD" || r.Method == "OPTIONS"
+	return r.Method == "OPTIONS"
 }
 
 func (o Options) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
-	switch r.Method {
-	case http.MethodOptions:
-		w.WriteHeader(http.StatusOK)
-		w.Header().Set("Allow", "OPTIONS, GET, POST, PUT, DELETE")
-	case http.MethodHead:
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	if handlePreflight(w, r) {
+		return
 	}
+	w.Header().Set("Allow", "OPTIONS, HEAD, GET, POST, PUT, DELETE")
+	w.WriteHeader(http.StatusOK)
 }
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"mime"
 	"net/http"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql"
 )
@@ -20,18 +21,72 @@ func (h POST) Supports(r *http.Request) bool {
 		return false
 	}
 
-	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
-	if err != nil || mediaType != "application/json" {
+	if r.Method != "POST" && r.Method != "PUT" && r.Method != "PATCH" {
 		return false
 	}
-	return r.Method == "POST" || r.Method == "PUT" || r.Method == "PATCH"
+	if isRESTRoute(r) {
+		return true // unsupported content types are rejected with 415
+	}
+	if routeMethodNotAllowed(r) != nil {
+		return true // rejected with 405
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
 }
 
 func (h POST) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", jsonContentType)
+	r = withRequestID(w, r)
+	setCORSHeaders(w, r)
+	r = withResponseState(r)
+	w = withServerTiming(w, r)
+	r = withDryRun(r)
+	w, closeWriter := wrapCompression(w, r)
+	defer closeWriter()
+	w, observe := wrapMetrics(w, r)
+	defer observe()
+	r, endSpan := startSpan(w, r)
+	defer endSpan()
+	r = enrichContext(r)
+	if !allowRequest(w, r) {
+		return
+	}
+	if !allowRouteMethod(w, r) {
+		return
+	}
+	w, finishCapture := withCapture(w, r)
+	defer finishCapture()
+	w, finishIdempotency, ok := withIdempotency(w, r)
+	if !ok {
+		return
+	}
+	defer finishIdempotency()
+
+	if isRESTRoute(r) {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "", "application/json":
+		case "multipart/form-data":
+			h.doMultipart(w, r, exec)
+			return
+		case "application/merge-patch+json":
+			h.doMergePatch(w, r, exec)
+			return
+		case "application/x-www-form-urlencoded":
+			h.doForm(w, r, exec)
+			return
+		default:
+			writeHeader(w, r, http.StatusUnsupportedMediaType, true)
+			writeJSONErrorf(w, r, http.StatusUnsupportedMediaType, true, "unsupported content type %q", mediaType)
+			return
+		}
+	}
 
 	// https://stackoverflow.com/questions/43021058/golang-read-request-body-multiple-times
-	body, _ := ioutil.ReadAll(r.Body)
+	body, ok := readRequestBody(w, r)
+	if !ok {
+		return
+	}
 	r.Body = ioutil.NopCloser(bytes.NewBuffer(body))
 
 	var params *graphql.RawParams
@@ -42,9 +97,16 @@ func (h POST) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecu
 		bodyReader := ioutil.NopCloser(bytes.NewBuffer(body))
 		if err := jsonDecode(bodyReader, &params); err != nil {
 			w.WriteHeader(http.StatusBadRequest)
-			writeJSONErrorf(w, http.StatusUnprocessableEntity, false, "json body could not be decoded: "+err.Error())
+			writeJSONErrorf(w, r, http.StatusUnprocessableEntity, false, "json body could not be decoded: "+err.Error())
 			return
 		}
+		if params.Query != "" && strictDecoding {
+			if err := jsonDecodeStrict(bytes.NewReader(body), new(graphql.RawParams)); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				writeJSONErrorf(w, r, http.StatusUnprocessableEntity, false, "json body could not be decoded: "+err.Error())
+				return
+			}
+		}
 	}
 
 	// >>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>
@@ -54,8 +116,8 @@ func (h POST) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecu
 
 		queryString, err := convertHTTPRequestToGraphQLQuery(r, params, body)
 		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			writeJSONErrorf(w, http.StatusUnprocessableEntity, isRESTful, "query body could not be parsed: "+err.Error())
+			writeHeader(w, r, http.StatusBadRequest, isRESTful)
+			writeConvertError(w, r, err, isRESTful, "query body could not be parsed")
 			return
 		}
 		params.Query = queryString
@@ -69,9 +131,7 @@ func (h POST) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecu
 
 	rc, err := exec.CreateOperationContext(r.Context(), params)
 	if err != nil {
-		w.WriteHeader(statusFor(err))
-		resp := exec.DispatchError(graphql.WithOperationContext(r.Context(), rc), err)
-		writeJSON(w, resp, isRESTful)
+		writeOperationError(w, r, exec, rc, err, isRESTful)
 		return
 	}
 
@@ -79,7 +139,33 @@ func (h POST) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecu
 		dbgPrintf("HTTP %s %s: %s %s", r.Method, r.URL.Path, params.Query, params.Variables)
 	}
 
-	ctx := graphql.WithOperationContext(r.Context(), rc)
-	responses, ctx := exec.DispatchOperation(ctx, rc)
-	writeJSON(w, responses(ctx), isRESTful)
+	writeOperation(w, r, exec, rc, isRESTful)
+}
+
+// doRESTful executes a RESTful request with body parameters already decoded
+func (h POST) doRESTful(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor, params *graphql.RawParams, bodyParams map[string]interface{}, start time.Time) {
+	isRESTful := true
+
+	queryString, err := convertHTTPRequestParamsToGraphQLQuery(r, params, bodyParams)
+	if err != nil {
+		writeHeader(w, r, http.StatusBadRequest, isRESTful)
+		writeConvertError(w, r, err, isRESTful, "query body could not be parsed")
+		return
+	}
+	params.Query = queryString
+
+	params.ReadTime = graphql.TraceTiming{
+		Start: start,
+		End:   graphql.Now(),
+	}
+
+	rc, gerr := exec.CreateOperationContext(r.Context(), params)
+	if gerr != nil {
+		writeOperationError(w, r, exec, rc, gerr, isRESTful)
+		return
+	}
+
+	dbgPrintf("HTTP %s %s: %s %s", r.Method, r.URL.Path, params.Query, params.Variables)
+
+	writeOperation(w, r, exec, rc, isRESTful)
 }
@@ -0,0 +1,258 @@
+package handlerx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader is the request header carrying the idempotency key of a mutation
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentResponse is a response cached by an IdempotencyStore
+type IdempotentResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	// RequestHash is the SHA-256 hash of the body of the request, a request reusing the key must have the same body
+	RequestHash string
+	// Code is the response code of RESTful responses, it's reported to the Metrics of the replays
+	Code *int
+}
+
+// IdempotencyStore caches the responses of requests with an Idempotency-Key header,
+// eg. in Redis to share them between instances.
+type IdempotencyStore interface {
+	// Get returns the response cached by key
+	Get(key string) (*IdempotentResponse, bool)
+	// Set caches the response of key for ttl
+	Set(key string, resp *IdempotentResponse, ttl time.Duration)
+}
+
+// IdempotencyPolicy is how a request is handled while another one with the same key is in flight
+type IdempotencyPolicy int
+
+const (
+	// IdempotencyWait waits for the in-flight request and replays its response
+	IdempotencyWait IdempotencyPolicy = iota
+	// IdempotencyConflict rejects the request with 409 Conflict
+	IdempotencyConflict
+)
+
+var (
+	idempotencyStore  IdempotencyStore
+	idempotencyTTL    time.Duration
+	idempotencyPolicy = IdempotencyWait
+	idempotencyScope  = AuthorizationIdempotencyScope
+
+	idempotencyMu       sync.Mutex
+	idempotencyInFlight = make(map[string]chan struct{})
+)
+
+// SetIdempotencyStore enables the Idempotency-Key header of POST, PUT, PATCH and DELETE requests:
+// the response of the first request with a key is cached in store for ttl, and replayed to the
+// requests with the same key without executing them again, with an Idempotent-Replayed header.
+// Keys are scoped by the caller, see SetIdempotencyScope, and a request reusing a key with another
+// body is rejected with 422. Responses with errors (even with a 200 status) or a 5xx HTTP status and
+// dry runs are not cached, and the Set-Cookie header is not replayed. Pass a nil store to disable it.
+func SetIdempotencyStore(store IdempotencyStore, ttl time.Duration) {
+	idempotencyStore = store
+	idempotencyTTL = ttl
+}
+
+// SetIdempotencyPolicy sets how concurrent requests with the same in-flight key are handled,
+// the default is IdempotencyWait. In-flight keys are only tracked by this process.
+func SetIdempotencyPolicy(policy IdempotencyPolicy) {
+	idempotencyPolicy = policy
+}
+
+// SetIdempotencyScope sets fn to return the caller of a request, eg. the user ID, its idempotency keys
+// are only replayed to the same caller. Pass nil to restore AuthorizationIdempotencyScope.
+func SetIdempotencyScope(fn func(r *http.Request) string) {
+	if fn == nil {
+		fn = AuthorizationIdempotencyScope
+	}
+	idempotencyScope = fn
+}
+
+// AuthorizationIdempotencyScope identifies the caller of r by its Authorization header,
+// anonymous requests share the same scope
+func AuthorizationIdempotencyScope(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		return ""
+	}
+	return sha256Hex([]byte(auth))
+}
+
+// sha256Hex returns the hex encoded SHA-256 hash of b
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// NewMemoryIdempotencyStore returns an IdempotencyStore keeping the responses in memory
+func NewMemoryIdempotencyStore() IdempotencyStore {
+	return &memoryIdempotencyStore{entries: make(map[string]memoryIdempotencyEntry)}
+}
+
+type memoryIdempotencyEntry struct {
+	resp    *IdempotentResponse
+	expires time.Time
+}
+
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryIdempotencyEntry
+}
+
+func (s *memoryIdempotencyStore) Get(key string) (*IdempotentResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(s.entries, key)
+		return nil, false
+	}
+	return entry.resp, true
+}
+
+func (s *memoryIdempotencyStore) Set(key string, resp *IdempotentResponse, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range s.entries {
+		if now.After(entry.expires) {
+			delete(s.entries, k)
+		}
+	}
+	s.entries[key] = memoryIdempotencyEntry{resp: resp, expires: now.Add(ttl)}
+}
+
+// idempotencyRecorder records the response written to be cached
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	resp *IdempotentResponse
+}
+
+func (w *idempotencyRecorder) WriteHeader(status int) {
+	w.record(status)
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *idempotencyRecorder) Write(b []byte) (int, error) {
+	w.record(http.StatusOK)
+	w.resp.Body = append(w.resp.Body, b...)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyRecorder) record(status int) {
+	if w.resp.Status == 0 {
+		w.resp.Status = status
+		w.resp.Header = w.Header().Clone()
+	}
+}
+
+// withIdempotency replays the cached response of the Idempotency-Key of r, or returns a writer caching
+// the response. It returns false if the response is already written, otherwise the returned func must
+// be called once the response is written.
+func withIdempotency(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, func(), bool) {
+	store := idempotencyStore
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if store == nil || key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || DryRunFromContext(r.Context()) {
+		return w, func() {}, true
+	}
+	key = r.Method + " " + r.URL.Path + " " + idempotencyScope(r) + " " + key
+
+	body, ok := readRawRequestBody(w, r)
+	if !ok {
+		return w, nil, false
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	requestHash := sha256Hex(body)
+
+	for {
+		if resp, ok := store.Get(key); ok {
+			if resp.RequestHash != requestHash {
+				isRESTful := isRESTRoute(r)
+				writeHeader(w, r, http.StatusUnprocessableEntity, isRESTful)
+				writeJSONErrorWithExtensions(w, r, http.StatusUnprocessableEntity, isRESTful, "the idempotency key is reused with another request body",
+					map[string]interface{}{"reason": "IDEMPOTENCY_KEY_REUSED", "retryable": false})
+				return w, nil, false
+			}
+			replayIdempotentResponse(w, r, resp)
+			return w, nil, false
+		}
+
+		idempotencyMu.Lock()
+		inFlight, ok := idempotencyInFlight[key]
+		if !ok {
+			done := make(chan struct{})
+			idempotencyInFlight[key] = done
+			idempotencyMu.Unlock()
+
+			rec := &idempotencyRecorder{ResponseWriter: w, resp: &IdempotentResponse{RequestHash: requestHash}}
+			return rec, func() {
+				if rec.resp.Status != 0 && rec.resp.Status < http.StatusInternalServerError && !responseHasErrors(r) {
+					if state := responseStateFromContext(r.Context()); state != nil {
+						rec.resp.Code = state.code
+					}
+					store.Set(key, rec.resp, idempotencyTTL)
+				}
+				idempotencyMu.Lock()
+				delete(idempotencyInFlight, key)
+				idempotencyMu.Unlock()
+				close(done)
+			}, true
+		}
+		idempotencyMu.Unlock()
+
+		if idempotencyPolicy == IdempotencyConflict {
+			isRESTful := isRESTRoute(r)
+			writeHeader(w, r, http.StatusConflict, isRESTful)
+			writeJSONErrorWithExtensions(w, r, http.StatusConflict, isRESTful, "a request with the same idempotency key is in progress",
+				map[string]interface{}{"reason": "IDEMPOTENCY_KEY_IN_PROGRESS", "retryable": true})
+			return w, nil, false
+		}
+		select {
+		case <-inFlight:
+			// replay its response, or execute if it's not cached
+		case <-r.Context().Done():
+			return w, nil, false
+		}
+	}
+}
+
+// replayIdempotentResponse writes a cached response of r
+func replayIdempotentResponse(w http.ResponseWriter, r *http.Request, resp *IdempotentResponse) {
+	if resp.Code != nil {
+		recordCode(r, *resp.Code)
+	}
+	requestID := w.Header().Get(RequestIDHeader)
+	for k, v := range resp.Header {
+		if k == "Content-Encoding" || k == "Content-Length" {
+			continue // the body is cached uncompressed
+		}
+		if k == "Set-Cookie" {
+			continue // the cookies of the session of the first request
+		}
+		w.Header()[k] = v
+	}
+	if requestID != "" {
+		w.Header().Set(RequestIDHeader, requestID)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(resp.Status)
+	if _, err := w.Write(resp.Body); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,164 @@
+package handlerx
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetIdempotencyStore(t *testing.T) {
+	setupTestMapping()
+	SetIdempotencyStore(NewMemoryIdempotencyStore(), time.Minute)
+	defer SetIdempotencyStore(nil, 0)
+
+	executions := 0
+	serve := func(key string, status int) *httptest.ResponseRecorder {
+		r := WithRoute(httptest.NewRequest(http.MethodPost, "/todos", nil), "/todos")
+		if key != "" {
+			r.Header.Set(IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		ww, finish, ok := withIdempotency(w, r)
+		if !ok {
+			return w
+		}
+		defer finish()
+		executions++
+		ww.Header().Set("Location", "/todos/1")
+		ww.WriteHeader(status)
+		_, _ = ww.Write([]byte(`{"code":0}`))
+		return w
+	}
+
+	tests := []struct {
+		Name       string
+		Key        string
+		Status     int
+		Executions int
+		Replayed   bool
+	}{
+		{Name: "first use", Key: "k1", Status: http.StatusCreated, Executions: 1},
+		{Name: "replay", Key: "k1", Status: http.StatusOK, Executions: 1, Replayed: true},
+		{Name: "another key", Key: "k2", Status: http.StatusCreated, Executions: 2},
+		{Name: "without key", Status: http.StatusCreated, Executions: 3},
+		{Name: "server error", Key: "k3", Status: http.StatusInternalServerError, Executions: 4},
+		{Name: "server error not cached", Key: "k3", Status: http.StatusCreated, Executions: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			w := serve(tt.Key, tt.Status)
+			assert.Equal(t, tt.Executions, executions)
+			assert.Equal(t, tt.Replayed, w.Header().Get("Idempotent-Replayed") == "true")
+			if tt.Replayed {
+				assert.Equal(t, http.StatusCreated, w.Code)
+				assert.Equal(t, "/todos/1", w.Header().Get("Location"))
+				assert.Equal(t, `{"code":0}`, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSetIdempotencyPolicy(t *testing.T) {
+	setupTestMapping()
+	SetIdempotencyStore(NewMemoryIdempotencyStore(), time.Minute)
+	defer SetIdempotencyStore(nil, 0)
+	defer SetIdempotencyPolicy(IdempotencyWait)
+
+	newRequest := func() *http.Request {
+		r := WithRoute(httptest.NewRequest(http.MethodPost, "/todos", nil), "/todos")
+		r.Header.Set(IdempotencyKeyHeader, "k")
+		return r
+	}
+
+	// the first request is in flight
+	ww, finish, ok := withIdempotency(httptest.NewRecorder(), newRequest())
+	assert.True(t, ok)
+
+	SetIdempotencyPolicy(IdempotencyConflict)
+	w := httptest.NewRecorder()
+	_, _, ok = withIdempotency(w, newRequest())
+	assert.False(t, ok)
+	assert.Contains(t, w.Body.String(), `"code":409`)
+
+	SetIdempotencyPolicy(IdempotencyWait)
+	waited := make(chan *httptest.ResponseRecorder)
+	go func() {
+		w := httptest.NewRecorder()
+		_, _, ok := withIdempotency(w, newRequest())
+		assert.False(t, ok)
+		waited <- w
+	}()
+	_, _ = ww.Write([]byte(`{"code":0,"data":{"id":"1"}}`))
+	finish()
+	assert.Equal(t, `{"code":0,"data":{"id":"1"}}`, (<-waited).Body.String())
+}
+
+func TestIdempotencyKeyScope(t *testing.T) {
+	setupTestMapping()
+	SetIdempotencyStore(NewMemoryIdempotencyStore(), time.Minute)
+	defer SetIdempotencyStore(nil, 0)
+
+	executions := 0
+	serve := func(auth, body string, failed bool) *httptest.ResponseRecorder {
+		r := withResponseState(WithRoute(httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(body)), "/todos"))
+		r.Header.Set(IdempotencyKeyHeader, "k")
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		ww, finish, ok := withIdempotency(w, r)
+		if !ok {
+			return w
+		}
+		defer finish()
+		executions++
+		b, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, body, string(b))
+		if failed {
+			writeJSONError(ww, r, http.StatusInternalServerError, true, "boom") // written with a 200 status
+			return w
+		}
+		ww.Header().Set("Set-Cookie", "session=alice")
+		_, _ = ww.Write([]byte(`{"code":0}`))
+		return w
+	}
+
+	tests := []struct {
+		Name       string
+		Auth       string
+		Body       string
+		Failed     bool
+		Executions int
+		Replayed   bool
+		Code       int
+	}{
+		{Name: "failed", Auth: "Bearer a", Body: `{"text":"x"}`, Failed: true, Executions: 1, Code: http.StatusOK},
+		{Name: "failure not cached", Auth: "Bearer a", Body: `{"text":"x"}`, Executions: 2, Code: http.StatusOK},
+		{Name: "replay", Auth: "Bearer a", Body: `{"text":"x"}`, Executions: 2, Replayed: true, Code: http.StatusOK},
+		{Name: "another caller", Auth: "Bearer b", Body: `{"text":"x"}`, Executions: 3, Code: http.StatusOK},
+		{Name: "another body", Auth: "Bearer a", Body: `{"text":"y"}`, Executions: 3, Code: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			w := serve(tt.Auth, tt.Body, tt.Failed)
+			assert.Equal(t, tt.Executions, executions)
+			assert.Equal(t, tt.Replayed, w.Header().Get("Idempotent-Replayed") == "true")
+			if tt.Code == http.StatusUnprocessableEntity {
+				assert.Contains(t, w.Body.String(), `"code":422`)
+				return
+			}
+			assert.Equal(t, tt.Code, w.Code)
+			if tt.Replayed {
+				assert.Empty(t, w.Header().Get("Set-Cookie"))
+				assert.Equal(t, `{"code":0}`, w.Body.String())
+			}
+		})
+	}
+}
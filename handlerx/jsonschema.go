@@ -0,0 +1,380 @@
+package handlerx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// jsonSchema is a JSON Schema (draft 7) document, only the validation keywords of jsonSchema are supported,
+// the others (eg. format or $ref) are ignored.
+type jsonSchema struct {
+	Type                 jsonSchemaTypes        `json:"type"`
+	Enum                 []interface{}          `json:"enum"`
+	Const                *interface{}           `json:"const"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties *jsonSchemaOrBool      `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	MinItems             *int                   `json:"minItems"`
+	MaxItems             *int                   `json:"maxItems"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	Pattern              string                 `json:"pattern"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	ExclusiveMinimum     *float64               `json:"exclusiveMinimum"`
+	ExclusiveMaximum     *float64               `json:"exclusiveMaximum"`
+	AllOf                []*jsonSchema          `json:"allOf"`
+	AnyOf                []*jsonSchema          `json:"anyOf"`
+	OneOf                []*jsonSchema          `json:"oneOf"`
+	Not                  *jsonSchema            `json:"not"`
+
+	pattern *regexp.Regexp
+}
+
+// jsonSchemaTypes is the `type` keyword, a type name or a list of them
+type jsonSchemaTypes []string
+
+func (t *jsonSchemaTypes) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		*t = jsonSchemaTypes{name}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(t))
+}
+
+// jsonSchemaOrBool is the `additionalProperties` keyword, a schema or a boolean
+type jsonSchemaOrBool struct {
+	allowed bool
+	schema  *jsonSchema
+}
+
+func (s *jsonSchemaOrBool) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &s.allowed); err == nil {
+		return nil
+	}
+	s.allowed = true
+	return json.Unmarshal(b, &s.schema)
+}
+
+var (
+	requestSchemasMu sync.RWMutex
+	requestSchemas   = make(map[string]*jsonSchema)
+)
+
+// SetRequestSchema validates the body of RESTful requests of operation opName against the JSON Schema
+// document schema before the GraphQL execution, eg. for cross-field rules like "either email or phone is
+// required". Violations are reported as 422 with an error per invalid field, like the constraint directives.
+// The supported keywords are type, enum, const, required, properties, additionalProperties, items,
+// minItems, maxItems, minLength, maxLength, pattern, minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// allOf, anyOf, oneOf and not. Pass a nil schema to remove it.
+func SetRequestSchema(opName string, schema []byte) error {
+	requestSchemasMu.Lock()
+	defer requestSchemasMu.Unlock()
+
+	if schema == nil {
+		delete(requestSchemas, opName)
+		return nil
+	}
+	var s jsonSchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return fmt.Errorf("handlerx: invalid request schema of %s: %w", opName, err)
+	}
+	if err := s.compile(); err != nil {
+		return fmt.Errorf("handlerx: invalid request schema of %s: %w", opName, err)
+	}
+	requestSchemas[opName] = &s
+	return nil
+}
+
+// compile compiles the patterns of s and its subschemas
+func (s *jsonSchema) compile() error {
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return err
+		}
+		s.pattern = pattern
+	}
+
+	subschemas := append(append(append([]*jsonSchema{s.Items, s.Not}, s.AllOf...), s.AnyOf...), s.OneOf...)
+	for _, sub := range s.Properties {
+		subschemas = append(subschemas, sub)
+	}
+	if s.AdditionalProperties != nil {
+		subschemas = append(subschemas, s.AdditionalProperties.schema)
+	}
+	for _, sub := range subschemas {
+		if sub == nil {
+			continue
+		}
+		if err := sub.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateRequestSchema validates the decoded body of a request of operationName against its schema
+func validateRequestSchema(operationName string, bodyParams map[string]interface{}) error {
+	requestSchemasMu.RLock()
+	schema, ok := requestSchemas[operationName]
+	requestSchemasMu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	verr := &ValidationError{}
+	schema.validate(verr, nil, bodyParams)
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// validate reports the violations of v at path to verr
+func (s *jsonSchema) validate(verr *ValidationError, path ast.Path, v interface{}) {
+	if len(s.Type) > 0 && !s.Type.matches(v) {
+		verr.add(path, "must be "+strings.Join(s.Type, " or "))
+		return
+	}
+	if len(s.Enum) > 0 && !containsJSONValue(s.Enum, v) {
+		verr.add(path, "must be one of "+formatJSONValues(s.Enum))
+		return
+	}
+	if s.Const != nil && !equalJSONValues(*s.Const, v) {
+		verr.add(path, "must be "+formatJSONValues([]interface{}{*s.Const}))
+		return
+	}
+
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		s.validateObject(verr, path, vv)
+	case []interface{}:
+		s.validateArray(verr, path, vv)
+	case string:
+		s.validateString(verr, path, vv)
+	case json.Number, float64:
+		if n, ok := jsonNumber(vv); ok {
+			s.validateNumber(verr, path, n)
+		}
+	}
+
+	for _, sub := range s.AllOf {
+		sub.validate(verr, path, v)
+	}
+	if len(s.AnyOf) > 0 && s.countMatches(s.AnyOf, v) == 0 {
+		verr.add(path, "must match at least one of the schemas: "+s.describe(s.AnyOf, path, v))
+	}
+	if len(s.OneOf) > 0 {
+		if n := s.countMatches(s.OneOf, v); n != 1 {
+			verr.add(path, fmt.Sprintf("must match exactly one of the schemas, but matches %d", n))
+		}
+	}
+	if s.Not != nil && s.countMatches([]*jsonSchema{s.Not}, v) == 1 {
+		verr.add(path, "must not match the schema")
+	}
+}
+
+func (s *jsonSchema) validateObject(verr *ValidationError, path ast.Path, v map[string]interface{}) {
+	for _, name := range s.Required {
+		if _, ok := v[name]; !ok {
+			verr.add(append(append(ast.Path(nil), path...), ast.PathName(name)), "is required")
+		}
+	}
+
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fieldPath := append(append(ast.Path(nil), path...), ast.PathName(name))
+		if sub, ok := s.Properties[name]; ok {
+			sub.validate(verr, fieldPath, v[name])
+			continue
+		}
+		if s.AdditionalProperties == nil {
+			continue
+		}
+		if !s.AdditionalProperties.allowed {
+			verr.add(fieldPath, "is not allowed")
+		} else if s.AdditionalProperties.schema != nil {
+			s.AdditionalProperties.schema.validate(verr, fieldPath, v[name])
+		}
+	}
+}
+
+func (s *jsonSchema) validateArray(verr *ValidationError, path ast.Path, v []interface{}) {
+	if s.MinItems != nil && len(v) < *s.MinItems {
+		verr.add(path, fmt.Sprintf("must have at least %d items", *s.MinItems))
+		return
+	}
+	if s.MaxItems != nil && len(v) > *s.MaxItems {
+		verr.add(path, fmt.Sprintf("must have at most %d items", *s.MaxItems))
+		return
+	}
+	if s.Items != nil {
+		for i, item := range v {
+			s.Items.validate(verr, append(append(ast.Path(nil), path...), ast.PathIndex(i)), item)
+		}
+	}
+}
+
+func (s *jsonSchema) validateString(verr *ValidationError, path ast.Path, v string) {
+	length := utf8.RuneCountInString(v)
+	if s.MinLength != nil && length < *s.MinLength {
+		verr.add(path, fmt.Sprintf("length must be at least %d", *s.MinLength))
+	} else if s.MaxLength != nil && length > *s.MaxLength {
+		verr.add(path, fmt.Sprintf("length must be at most %d", *s.MaxLength))
+	} else if s.pattern != nil && !s.pattern.MatchString(v) {
+		verr.add(path, "must match pattern "+s.Pattern)
+	}
+}
+
+func (s *jsonSchema) validateNumber(verr *ValidationError, path ast.Path, n float64) {
+	format := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	if s.Minimum != nil && n < *s.Minimum {
+		verr.add(path, "must be greater than or equal to "+format(*s.Minimum))
+	} else if s.Maximum != nil && n > *s.Maximum {
+		verr.add(path, "must be less than or equal to "+format(*s.Maximum))
+	} else if s.ExclusiveMinimum != nil && n <= *s.ExclusiveMinimum {
+		verr.add(path, "must be greater than "+format(*s.ExclusiveMinimum))
+	} else if s.ExclusiveMaximum != nil && n >= *s.ExclusiveMaximum {
+		verr.add(path, "must be less than "+format(*s.ExclusiveMaximum))
+	}
+}
+
+// countMatches returns how many of schemas v matches
+func (s *jsonSchema) countMatches(schemas []*jsonSchema, v interface{}) int {
+	n := 0
+	for _, sub := range schemas {
+		verr := &ValidationError{}
+		sub.validate(verr, nil, v)
+		if len(verr.Fields) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// describe returns the violations of the first of schemas, to explain why none of them matches
+func (s *jsonSchema) describe(schemas []*jsonSchema, path ast.Path, v interface{}) string {
+	msgs := make([]string, 0, len(schemas))
+	for _, sub := range schemas {
+		verr := &ValidationError{}
+		sub.validate(verr, path, v)
+		msgs = append(msgs, strings.TrimPrefix(verr.Error(), "validation failed: "))
+	}
+	return strings.Join(msgs, " | ")
+}
+
+// matches reports whether v is one of the types
+func (t jsonSchemaTypes) matches(v interface{}) bool {
+	for _, name := range t {
+		switch vv := v.(type) {
+		case nil:
+			if name == "null" {
+				return true
+			}
+		case bool:
+			if name == "boolean" {
+				return true
+			}
+		case string:
+			if name == "string" {
+				return true
+			}
+		case map[string]interface{}:
+			if name == "object" {
+				return true
+			}
+		case []interface{}:
+			if name == "array" {
+				return true
+			}
+		case json.Number, float64:
+			n, ok := jsonNumber(vv)
+			if ok && (name == "number" || (name == "integer" && n == math.Trunc(n))) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonNumber returns the value of a decoded JSON number
+func jsonNumber(v interface{}) (float64, bool) {
+	switch vv := v.(type) {
+	case json.Number:
+		n, err := vv.Float64()
+		return n, err == nil
+	case float64:
+		return vv, true
+	}
+	return 0, false
+}
+
+// equalJSONValues reports whether the decoded JSON values a and b are equal, numbers are compared by value
+func equalJSONValues(a, b interface{}) bool {
+	if na, ok := jsonNumber(a); ok {
+		nb, ok := jsonNumber(b)
+		return ok && na == nb
+	}
+	return reflect.DeepEqual(normalizeJSONValue(a), normalizeJSONValue(b))
+}
+
+// normalizeJSONValue converts the numbers of v to float64
+func normalizeJSONValue(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(vv))
+		for k, item := range vv {
+			m[k] = normalizeJSONValue(item)
+		}
+		return m
+	case []interface{}:
+		items := make([]interface{}, 0, len(vv))
+		for _, item := range vv {
+			items = append(items, normalizeJSONValue(item))
+		}
+		return items
+	}
+	if n, ok := jsonNumber(v); ok {
+		return n
+	}
+	return v
+}
+
+func containsJSONValue(values []interface{}, v interface{}) bool {
+	for _, value := range values {
+		if equalJSONValues(value, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatJSONValues(values []interface{}) string {
+	var buf bytes.Buffer
+	for i, v := range values {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		b, _ := json.Marshal(v)
+		buf.Write(b)
+	}
+	return buf.String()
+}
@@ -0,0 +1,84 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetRequestSchema(t *testing.T) {
+	setupTestMapping()
+	assert.Error(t, SetRequestSchema("createTodo", []byte(`{"type":`)))
+	assert.Error(t, SetRequestSchema("createTodo", []byte(`{"pattern":"("}`)))
+	assert.NoError(t, SetRequestSchema("createTodo", []byte(`{
+		"type": "object",
+		"required": ["text"],
+		"properties": {
+			"text": {"type": "string", "minLength": 2, "pattern": "^[a-z]+$"},
+			"priority": {"type": "integer", "minimum": 1, "maximum": 5},
+			"state": {"enum": ["TODO", "DONE"]},
+			"tags": {"type": "array", "maxItems": 2, "items": {"type": "object", "additionalProperties": false, "properties": {"name": {"type": "string"}}}}
+		},
+		"anyOf": [{"required": ["email"]}, {"required": ["phone"]}]
+	}`)))
+	defer SetRequestSchema("createTodo", nil)
+	defer SetFieldErrorMode(false)
+
+	tests := []struct {
+		Name     string
+		Body     string
+		Expected map[string]interface{}
+		Message  string
+	}{
+		{Name: "valid", Body: `{"text":"abc","email":"a@b.c","priority":3,"state":"DONE","tags":[{"name":"x"}]}`},
+		{Name: "valid phone", Body: `{"text":"abc","phone":"123"}`},
+		{
+			Name: "field violations", Body: `{"text":"A","email":"a@b.c","priority":1.5,"state":"NEW","tags":[{"name":"x","color":"red"}]}`,
+			Expected: map[string]interface{}{
+				"text":          "length must be at least 2",
+				"priority":      "must be integer",
+				"state":         `must be one of "TODO", "DONE"`,
+				"tags[0].color": "is not allowed",
+			},
+		},
+		{
+			Name: "cross-field rule", Body: `{"text":"abc"}`,
+			Message: "must match at least one of the schemas: email: is required | phone: is required",
+		},
+		{
+			Name: "required", Body: `{"phone":"123","priority":9}`,
+			Expected: map[string]interface{}{"text": "is required", "priority": "must be less than or equal to 5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(tt.Body)), "/todos")
+			_, err := convertHTTPRequestToGraphQLQuery(r, &graphql.RawParams{}, []byte(tt.Body))
+			if tt.Expected == nil && tt.Message == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, http.StatusUnprocessableEntity, convertErrorCode(err))
+
+			SetFieldErrorMode(true)
+			w := httptest.NewRecorder()
+			writeConvertError(w, r, err, true, "query body could not be parsed")
+
+			var resp map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, float64(http.StatusUnprocessableEntity), resp["code"])
+			if tt.Expected != nil {
+				assert.Equal(t, tt.Expected, resp["fields"])
+			}
+			if tt.Message != "" {
+				assert.Contains(t, resp["message"], tt.Message)
+			}
+		})
+	}
+}
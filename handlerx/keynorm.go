@@ -0,0 +1,112 @@
+package handlerx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var keyNormalizer func(key string) string
+
+// SetKeyNormalizer sets fn to normalize the query and body parameter keys of RESTful requests: a key
+// matches the argument (or input field) of the operation whose name has the same normalized form, eg.
+// FoldedKey matches "user_id", "userId" and "userID" to the argument userId. Keys matching no argument
+// are kept as-is, several keys matching the same argument are reported as 400. Only the name of the
+// parameters in the bracket notation is normalized, eg. "owner_filter" of "owner_filter[id]".
+// Keys match exactly by default, pass nil to restore it.
+func SetKeyNormalizer(fn func(key string) string) {
+	keyNormalizer = fn
+}
+
+// FoldedKey normalizes key to lower case without underscores and dashes, eg. "user_id" and "userID" to "userid"
+func FoldedKey(key string) string {
+	var sb strings.Builder
+	for _, c := range key {
+		if c == '_' || c == '-' {
+			continue
+		}
+		sb.WriteRune(unicode.ToLower(c))
+	}
+	return sb.String()
+}
+
+// keyMatcher matches the parameter keys of a request to the arguments and input fields of an operation
+type keyMatcher struct {
+	normalize func(key string) string
+	names     map[string]string // normalized name => argument or input field name
+}
+
+// newKeyMatcher returns the keyMatcher of the arguments argTypes, nil if keys match exactly
+func newKeyMatcher(argTypes StringMap) *keyMatcher {
+	normalize := keyNormalizer
+	if normalize == nil {
+		return nil
+	}
+	m := &keyMatcher{normalize: normalize, names: make(map[string]string)}
+	_, inputType := getUnderlayingArgType(argTypes["input"])
+	for name := range inputType2FieldDefinitions[inputType] {
+		m.names[normalize(name)] = name
+	}
+	for name := range argTypes {
+		m.names[normalize(name)] = name // arguments take precedence over input fields
+	}
+	return m
+}
+
+// match returns the name key matches, seen maps the names to the keys matched so far from the same parameters
+func (m *keyMatcher) match(key string, seen map[string]string) (string, error) {
+	if m == nil {
+		return key, nil
+	}
+	base, suffix := key, ""
+	if i := strings.IndexByte(key, '['); i > 0 {
+		base, suffix = key[:i], key[i:]
+	}
+	name, ok := m.names[m.normalize(base)]
+	if !ok {
+		return key, nil
+	}
+	name += suffix
+	if old, ok := seen[name]; ok && old != key {
+		keys := []string{old, key}
+		sort.Strings(keys)
+		return "", &paramError{name, fmt.Sprintf("mapping: parameters %s and %s are ambiguous", keys[0], keys[1])}
+	}
+	seen[name] = key
+	return name, nil
+}
+
+// matchKeys returns params with the keys matched to the names, see keyMatcher.match
+func (m *keyMatcher) matchKeys(params map[string]interface{}) (map[string]interface{}, error) {
+	if m == nil || len(params) == 0 {
+		return params, nil
+	}
+	matched := make(map[string]interface{}, len(params))
+	seen := make(map[string]string, len(params))
+	for k, v := range params {
+		name, err := m.match(k, seen)
+		if err != nil {
+			return nil, err
+		}
+		matched[name] = v
+	}
+	return matched, nil
+}
+
+// matchBodyKeys returns the body parameters with the keys, and the keys of the input member, matched to the names
+func matchBodyKeys(m *keyMatcher, bodyParams map[string]interface{}) (map[string]interface{}, error) {
+	if m == nil {
+		return bodyParams, nil
+	}
+	bodyParams, err := m.matchKeys(bodyParams)
+	if err != nil {
+		return nil, err
+	}
+	if input, ok := bodyParams["input"].(map[string]interface{}); ok {
+		if bodyParams["input"], err = m.matchKeys(input); err != nil {
+			return nil, err
+		}
+	}
+	return bodyParams, nil
+}
@@ -0,0 +1,62 @@
+package handlerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFoldedKey(t *testing.T) {
+	for _, key := range []string{"user_id", "userId", "userID", "User-Id"} {
+		assert.Equal(t, "userid", FoldedKey(key))
+	}
+}
+
+func TestSetKeyNormalizer(t *testing.T) {
+	setupTestMapping()
+	defer SetKeyNormalizer(nil)
+
+	tests := []struct {
+		Name       string
+		Normalizer func(string) string
+		Method     string
+		Target     string
+		Body       string
+		Contains   []string
+		Excludes   []string
+		Code       int
+	}{
+		{Name: "exact by default", Method: http.MethodGet, Target: "/todos?Limit=5", Excludes: []string{`limit:5`}},
+		{Name: "query key", Normalizer: FoldedKey, Method: http.MethodGet, Target: "/todos?Limit=5", Contains: []string{`limit:5`}},
+		{Name: "unknown key kept", Normalizer: FoldedKey, Method: http.MethodGet, Target: "/todos?limit=5&page_size=2", Contains: []string{`limit:5`}},
+		{Name: "ambiguous query keys", Normalizer: FoldedKey, Method: http.MethodGet, Target: "/todos?limit=5&LIMIT=6", Code: http.StatusBadRequest},
+		{Name: "same key repeated", Normalizer: FoldedKey, Method: http.MethodGet, Target: "/todos?ids=1&ids=2", Contains: []string{`ids:["1","2"]`}},
+		{Name: "body keys", Normalizer: FoldedKey, Method: http.MethodPost, Target: "/todos", Body: `{"Text":"x","user_id":"u1"}`, Contains: []string{`text:"x"`, `userId:"u1"`}},
+		{Name: "input keys", Normalizer: FoldedKey, Method: http.MethodPost, Target: "/todos", Body: `{"input":{"text":"x","userID":"u1"}}`, Contains: []string{`userId:"u1"`}},
+		{Name: "ambiguous body keys", Normalizer: FoldedKey, Method: http.MethodPost, Target: "/todos", Body: `{"text":"x","userId":"u1","user_id":"u2"}`, Code: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetKeyNormalizer(tt.Normalizer)
+			r := WithRoute(httptest.NewRequest(tt.Method, tt.Target, strings.NewReader(tt.Body)), "/todos")
+			query, err := convertHTTPRequestToGraphQLQuery(r, &graphql.RawParams{}, []byte(tt.Body))
+			if tt.Code != 0 {
+				assert.Error(t, err)
+				assert.Equal(t, tt.Code, convertErrorCode(err))
+				return
+			}
+			assert.NoError(t, err)
+			for _, c := range tt.Contains {
+				assert.Contains(t, query, c)
+			}
+			for _, c := range tt.Excludes {
+				assert.NotContains(t, query, c)
+			}
+		})
+	}
+}
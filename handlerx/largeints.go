@@ -0,0 +1,67 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// maxSafeInteger is the largest integer exactly representable by IEEE 754 doubles, Number.MAX_SAFE_INTEGER of JavaScript
+const maxSafeInteger = 1<<53 - 1
+
+var (
+	stringifyLargeInts bool
+	largeIntThreshold  uint64 = maxSafeInteger
+)
+
+// SetStringifyLargeInts makes RESTful responses report the integers of the `data` beyond the large int
+// threshold (2^53-1 by default) as strings, eg. int64 IDs, which JavaScript clients can't parse without
+// losing precision. It's applied after the registered data transforms.
+func SetStringifyLargeInts(enabled bool) {
+	stringifyLargeInts = enabled
+}
+
+// SetLargeIntThreshold sets the largest absolute value of the integers kept as numbers when
+// SetStringifyLargeInts is enabled. Pass 0 to restore the default 2^53-1.
+func SetLargeIntThreshold(n uint64) {
+	if n == 0 {
+		n = maxSafeInteger
+	}
+	largeIntThreshold = n
+}
+
+// stringifyLargeIntValues converts the integers of data beyond the large int threshold to strings,
+// keeping the order of the object members
+func stringifyLargeIntValues(data json.RawMessage) (json.RawMessage, error) {
+	w := &jsonWalk{}
+	stringifyLargeIntWalk(w)
+	return w.walk(data)
+}
+
+// stringifyLargeIntWalk makes w convert the integers beyond the large int threshold to strings
+func stringifyLargeIntWalk(w *jsonWalk) {
+	w.leaf = func(value json.RawMessage) json.RawMessage {
+		if isLargeInt(value) {
+			return json.RawMessage(`"` + string(value) + `"`)
+		}
+		return value
+	}
+}
+
+// isLargeInt reports whether the JSON value v is an integer beyond the large int threshold,
+// numbers with a fraction or an exponent are kept as-is
+func isLargeInt(v []byte) bool {
+	digits := v
+	if len(digits) > 0 && digits[0] == '-' {
+		digits = digits[1:]
+	}
+	if len(digits) == 0 {
+		return false
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	n, err := strconv.ParseUint(string(digits), 10, 64)
+	return err != nil || n > largeIntThreshold // err is only out of the uint64 range
+}
@@ -0,0 +1,47 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringifyLargeIntValues(t *testing.T) {
+	defer SetLargeIntThreshold(0)
+
+	tests := []struct {
+		Name      string
+		Threshold uint64
+		Data      string
+		Expected  string
+	}{
+		{Name: "safe ints kept", Data: `{"id":9007199254740991,"n":-9007199254740991}`, Expected: `{"id":9007199254740991,"n":-9007199254740991}`},
+		{Name: "large ints", Data: `{"id":9007199254740993,"n":-9007199254740992}`, Expected: `{"id":"9007199254740993","n":"-9007199254740992"}`},
+		{Name: "beyond uint64", Data: `[18446744073709551616]`, Expected: `["18446744073709551616"]`},
+		{Name: "nested", Data: `{"todos":[{"id":12345678901234567890,"user":{"id":1}}]}`, Expected: `{"todos":[{"id":"12345678901234567890","user":{"id":1}}]}`},
+		{Name: "floats kept", Data: `[1e20,12345678901234567890.5]`, Expected: `[1e20,12345678901234567890.5]`},
+		{Name: "strings kept", Data: `{"id":"12345678901234567890"}`, Expected: `{"id":"12345678901234567890"}`},
+		{Name: "threshold", Threshold: 100, Data: `{"a":100,"b":101}`, Expected: `{"a":100,"b":"101"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetLargeIntThreshold(tt.Threshold)
+			data, err := stringifyLargeIntValues(json.RawMessage(tt.Data))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.Expected, string(data))
+		})
+	}
+}
+
+func TestSetStringifyLargeInts(t *testing.T) {
+	SetStringifyLargeInts(true)
+	defer SetStringifyLargeInts(false)
+
+	w := httptest.NewRecorder()
+	writeJSON(w, nil, &graphql.Response{Data: json.RawMessage(`{"todo":{"id":9223372036854775807,"done":1}}`)}, true)
+	assert.Equal(t, `{"code":0,"data":{"id":"9223372036854775807","done":1}}`, w.Body.String())
+}
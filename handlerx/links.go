@@ -0,0 +1,83 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PageInfo is the `pageInfo` of a Relay-style connection, see https://relay.dev/graphql/connections.htm
+type PageInfo struct {
+	HasNextPage     bool   `json:"hasNextPage"`
+	HasPreviousPage bool   `json:"hasPreviousPage"`
+	StartCursor     string `json:"startCursor"`
+	EndCursor       string `json:"endCursor"`
+}
+
+// LinkBuilder builds the URLs of the next and previous pages of a RESTful request from the `pageInfo`
+// of its connection, an empty URL is left out of the Link header.
+type LinkBuilder func(req *http.Request, pageInfo PageInfo) (next, prev string)
+
+var linkBuilders = make(map[string]LinkBuilder)
+
+// SetLinkBuilder registers fn to build the RFC 5988 Link header of successful RESTful responses of
+// operation opName, with rel="next" and rel="prev", from the `pageInfo` of the connection in the
+// response `data`, eg. SetLinkBuilder("todos", CursorLinks("after", "before")).
+// The connection is looked up before the response transforms, so it works with ConnectionToOffset.
+// Pass a nil fn to remove the builder.
+func SetLinkBuilder(opName string, fn LinkBuilder) {
+	if fn == nil {
+		delete(linkBuilders, opName)
+	} else {
+		linkBuilders[opName] = fn
+	}
+}
+
+// CursorLinks returns a LinkBuilder linking to the request URL with the query parameter afterParam set
+// to `endCursor` for the next page, and beforeParam set to `startCursor` for the previous page.
+// The other query parameters, eg. the page size, are kept.
+func CursorLinks(afterParam, beforeParam string) LinkBuilder {
+	link := func(req *http.Request, set, del, cursor string) string {
+		query := req.URL.Query()
+		query.Del(del)
+		query.Set(set, cursor)
+		u := url.URL{Path: req.URL.Path, RawQuery: query.Encode()}
+		return u.String()
+	}
+	return func(req *http.Request, pageInfo PageInfo) (next, prev string) {
+		if pageInfo.HasNextPage && pageInfo.EndCursor != "" {
+			next = link(req, afterParam, beforeParam, pageInfo.EndCursor)
+		}
+		if pageInfo.HasPreviousPage && pageInfo.StartCursor != "" {
+			prev = link(req, beforeParam, afterParam, pageInfo.StartCursor)
+		}
+		return next, prev
+	}
+}
+
+// setLinks sets the Link header built from the connection in the response data of operation opName
+func setLinks(w http.ResponseWriter, req *http.Request, opName string, data json.RawMessage) {
+	fn, ok := linkBuilders[opName]
+	if !ok || req == nil {
+		return
+	}
+	var conn struct {
+		PageInfo *PageInfo `json:"pageInfo"`
+	}
+	if err := json.Unmarshal(data, &conn); err != nil || conn.PageInfo == nil {
+		return
+	}
+
+	next, prev := fn(req, *conn.PageInfo)
+	links := make([]string, 0, 2)
+	if next != "" {
+		links = append(links, "<"+next+`>; rel="next"`)
+	}
+	if prev != "" {
+		links = append(links, "<"+prev+`>; rel="prev"`)
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
@@ -0,0 +1,69 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetLinkBuilder(t *testing.T) {
+	setupTestMapping()
+	SetLinkBuilder("todos", CursorLinks("after", "before"))
+	defer SetLinkBuilder("todos", nil)
+
+	tests := []struct {
+		Name      string
+		Target    string
+		Data      string
+		Transform bool
+		Link      string
+	}{
+		{
+			Name:   "next and prev",
+			Target: "/todos?first=2&after=MQ==",
+			Data:   `{"todos":{"edges":[],"pageInfo":{"hasNextPage":true,"hasPreviousPage":true,"startCursor":"Mg==","endCursor":"Mw=="}}}`,
+			Link:   `</todos?after=Mw%3D%3D&first=2>; rel="next", </todos?before=Mg%3D%3D&first=2>; rel="prev"`,
+		},
+		{
+			Name:   "last page",
+			Target: "/todos?first=2",
+			Data:   `{"todos":{"edges":[],"pageInfo":{"hasNextPage":false,"hasPreviousPage":true,"startCursor":"Mg==","endCursor":null}}}`,
+			Link:   `</todos?before=Mg%3D%3D&first=2>; rel="prev"`,
+		},
+		{
+			Name:      "flattened by ConnectionToOffset",
+			Target:    "/todos",
+			Data:      `{"todos":{"edges":[],"pageInfo":{"hasNextPage":true,"endCursor":"Mw=="}}}`,
+			Transform: true,
+			Link:      `</todos?after=Mw%3D%3D>; rel="next"`,
+		},
+		{
+			Name:   "single page",
+			Target: "/todos",
+			Data:   `{"todos":{"edges":[],"pageInfo":{"hasNextPage":false,"hasPreviousPage":false}}}`,
+		},
+		{
+			Name:   "not a connection",
+			Target: "/todos",
+			Data:   `{"todos":[]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			if tt.Transform {
+				RegisterResponseTransform("todos", ConnectionToOffset)
+				defer delete(responseTransforms, "todos")
+			}
+			r := WithRoute(httptest.NewRequest(http.MethodGet, tt.Target, nil), "/todos")
+			w := httptest.NewRecorder()
+			writeJSON(w, r, &graphql.Response{Data: json.RawMessage(tt.Data)}, true)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.Link, w.Header().Get("Link"))
+		})
+	}
+}
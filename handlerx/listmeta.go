@@ -0,0 +1,27 @@
+package handlerx
+
+import (
+	"encoding/json"
+)
+
+var listMetaBuilders = make(map[string]func(data json.RawMessage) map[string]interface{})
+
+// SetListMetaBuilder registers fn to build the `meta` member of successful RESTful responses of operation
+// opName from the unwrapped `data`, eg. {"count": 20, "total": 135} of a list. It's called before the
+// response transforms, no `meta` is added if fn returns an empty map. Pass a nil fn to remove it.
+func SetListMetaBuilder(opName string, fn func(data json.RawMessage) map[string]interface{}) {
+	if fn == nil {
+		delete(listMetaBuilders, opName)
+	} else {
+		listMetaBuilders[opName] = fn
+	}
+}
+
+// applyListMeta sets the `meta` built from the result data of operation opName
+func applyListMeta(response *RESTResponse, opName string, data json.RawMessage) {
+	fn, ok := listMetaBuilders[opName]
+	if !ok || len(data) == 0 {
+		return
+	}
+	response.Meta = fn(data)
+}
@@ -0,0 +1,65 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestSetListMetaBuilder(t *testing.T) {
+	setupTestMapping()
+	SetListMetaBuilder("todos", func(data json.RawMessage) map[string]interface{} {
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil
+		}
+		return map[string]interface{}{"count": len(items), "total": 42}
+	})
+	defer SetListMetaBuilder("todos", nil)
+
+	tests := []struct {
+		Name     string
+		Route    string
+		Response *graphql.Response
+		Expected string
+	}{
+		{
+			Name:     "list",
+			Route:    "/todos",
+			Response: &graphql.Response{Data: json.RawMessage(`{"todos":[{"id":"1"},{"id":"2"}]}`)},
+			Expected: `{"code":0,"data":[{"id":"1"},{"id":"2"}],"meta":{"count":2,"total":42}}`,
+		},
+		{
+			Name:     "not a list",
+			Route:    "/todos",
+			Response: &graphql.Response{Data: json.RawMessage(`{"todos":{"id":"1"}}`)},
+			Expected: `{"code":0,"data":{"id":"1"}}`,
+		},
+		{
+			Name:     "error",
+			Route:    "/todos",
+			Response: &graphql.Response{Errors: gqlerror.List{{Message: "denied", Extensions: map[string]interface{}{"code": 403}}}},
+			Expected: `{"code":403,"message":"denied","data":null}`,
+		},
+		{
+			Name:     "unset",
+			Route:    "/todos/{id}",
+			Response: &graphql.Response{Data: json.RawMessage(`{"todo":{"id":"1"}}`)},
+			Expected: `{"code":0,"data":{"id":"1"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos/1", nil), tt.Route, "1")
+			w := httptest.NewRecorder()
+			writeJSON(w, r, tt.Response, true)
+			assert.Equal(t, tt.Expected, w.Body.String())
+		})
+	}
+}
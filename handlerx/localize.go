@@ -0,0 +1,33 @@
+package handlerx
+
+import (
+	"net/http"
+	"strings"
+)
+
+var messageLocalizer func(code int, lang string) (string, bool)
+
+// SetMessageLocalizer sets the localizer of RESTful error messages. It's called with the response code
+// and the languages of the Accept-Language header, by descending q-value, until a message is found
+// (eg. "zh-CN", and then "zh"); the GraphQL messages are reported if there's none. Pass nil to disable it.
+func SetMessageLocalizer(localizer func(code int, lang string) (string, bool)) {
+	messageLocalizer = localizer
+}
+
+// localizeMessage returns the localized message of code for req, or msg if there's none
+func localizeMessage(req *http.Request, code int, msg string) string {
+	if messageLocalizer == nil {
+		return msg
+	}
+	for _, lang := range acceptLanguages(req) {
+		if localized, ok := messageLocalizer(code, lang); ok {
+			return localized
+		}
+		if i := strings.IndexByte(lang, '-'); i > 0 {
+			if localized, ok := messageLocalizer(code, lang[:i]); ok {
+				return localized
+			}
+		}
+	}
+	return msg
+}
@@ -0,0 +1,42 @@
+package handlerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetMessageLocalizer(t *testing.T) {
+	defer SetMessageLocalizer(nil)
+	SetMessageLocalizer(func(code int, lang string) (string, bool) {
+		messages := map[string]map[int]string{
+			"zh": {404: "未找到"},
+			"fr": {404: "introuvable"},
+		}
+		msg, ok := messages[lang][code]
+		return msg, ok
+	})
+
+	tests := []struct {
+		Name           string
+		AcceptLanguage string
+		Code           int
+		Expected       string
+	}{
+		{Name: "base language", AcceptLanguage: "zh-CN", Code: 404, Expected: "未找到"},
+		{Name: "highest q", AcceptLanguage: "zh;q=0.5, fr;q=0.8, en", Code: 404, Expected: "introuvable"},
+		{Name: "excluded", AcceptLanguage: "fr;q=0, zh;q=0.1", Code: 404, Expected: "未找到"},
+		{Name: "no localization", AcceptLanguage: "en", Code: 404, Expected: "not found"},
+		{Name: "unknown code", AcceptLanguage: "zh", Code: 500, Expected: "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/todos", nil)
+			r.Header.Set("Accept-Language", tt.AcceptLanguage)
+			assert.Equal(t, tt.Expected, localizeMessage(r, tt.Code, "not found"))
+		})
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/99designs/gqlgen/graphql"
@@ -37,6 +40,29 @@ func SetupHTTP2GraphQLMapping(operations StringMap, selections StringMap,
 	restOperation2Arguments = arguments
 	inputType2FieldDefinitions = inputTypes
 	typeName2TypeKinds = typeKinds
+	variableTypes = buildVariableTypes(arguments, inputTypes)
+}
+
+// restOperationName returns the GraphQL operation r is routed to
+func restOperationName(r *http.Request) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return "", false
+	}
+	pattern := rctx.RoutePattern()
+	method := r.Method
+	if method == http.MethodHead {
+		method = http.MethodGet // HEAD mirrors GET
+	}
+	operationName, ok := restURL2GraphOperation[method+":"+pattern]
+	if !ok && len(pattern) > 1 && strings.HasSuffix(pattern, "/") {
+		// the root route of a mounted sub router, eg. "/todos" + "/"
+		operationName, ok = restURL2GraphOperation[method+":"+strings.TrimSuffix(pattern, "/")]
+	}
+	return operationName, ok
 }
 
 func convertHTTPRequestToGraphQLQuery(r *http.Request, params *graphql.RawParams, body []byte) (string, error) {
@@ -53,45 +79,90 @@ func convertHTTPRequestToGraphQLQuery(r *http.Request, params *graphql.RawParams
 		bodyParams = make(map[string]interface{})
 	}
 
-	queryString := "mutation { "
-	if r.Method == "GET" {
-		queryString = "query { "
-	}
+	return convertHTTPRequestParamsToGraphQLQuery(r, params, bodyParams)
+}
 
+// convertHTTPRequestParamsToGraphQLQuery is like convertHTTPRequestToGraphQLQuery, with body parameters already decoded
+func convertHTTPRequestParamsToGraphQLQuery(r *http.Request, params *graphql.RawParams, bodyParams map[string]interface{}) (string, error) {
 	// 1. Operation Name
 	rctx := chi.RouteContext(r.Context())
-	routePattern := rctx.RoutePattern()
-	operationName, ok := restURL2GraphOperation[r.Method+":"+routePattern]
+	operationName, ok := restOperationName(r)
 	if !ok {
 		err := errors.New("unknown operation: " + rctx.RoutePattern())
 		return "", err
 	}
+
+	// the @skip and @include directives of the selection toggled by query parameters
+	toggles := selectionToggles(graphOperation2RESTSelection[operationName])
+	if err := bindToggleVariables(r, toggles, params); err != nil {
+		return "", err
+	}
+	definitions := variableDefinitions(params.Variables, toggleDefinitions(toggles)...)
+
+	queryString := "mutation" + definitions + " { "
+	if isSubscription(operationName) {
+		queryString = "subscription" + definitions + " { "
+	} else if r.Method == "GET" || r.Method == "HEAD" {
+		queryString = "query" + definitions + " { "
+	}
 	queryString += operationName // eg. "query { todos"
 
 	// 2. Query Parameters
 	if argTypes, ok := restOperation2Arguments[operationName]; ok {
 		queryParams := make(map[string]interface{})
 		inputParams := make(map[string]interface{})
+		matcher := newKeyMatcher(argTypes)
 		// 2.1 Query Parameters (GET/POST/PUT/DELETE)
+		bracketParams := make(map[string][]string)
+		seenKeys := make(map[string]string)
 		for k, v := range r.URL.Query() {
-			// convert "k=v1&k=v2&k=v3" to "k=v1,v2,v3"
-			val := strings.Join(v, ",")
+			if k == "query" && isAutomaticPersistedQuery(r) {
+				continue // the automatic persisted query
+			}
+			k, err := matcher.match(k, seenKeys)
+			if err != nil {
+				return "", err
+			}
+			if _, ok := toggles[k]; ok {
+				if _, isArg := argTypes[k]; !isArg {
+					continue // bound to the directive variable
+				}
+			}
+			if isBracketParam(k) {
+				bracketParams[k] = v
+				continue
+			}
+			var val interface{}
+			if len(v) > 1 && isListParam(argTypes, k) {
+				// keep "k=v1&k=v2&k=v3" as a list, so that values may contain ","
+				vals := make([]interface{}, 0, len(v))
+				for _, vv := range v {
+					vals = append(vals, vv)
+				}
+				val = vals
+			} else {
+				// convert "k=v1&k=v2&k=v3" to "k=v1,v2,v3"
+				val = strings.Join(v, ",")
+			}
 			inputParams[k] = val
 			queryParams[k] = val
 		}
-		// 2.2 Path Parameters (GET/POST/PUT/DELETE)
-		for i, k := range rctx.URLParams.Keys {
-			v := rctx.URLParams.Values[i]
-			// if strings.HasPrefix(v, "input.") {
-			// 	v = strings.Replace(v, "input.", "", 1)
-			// 	inputParams[k] = v
-			// } else {
-			// 	queryParams[k] = v
-			// }
-			inputParams[k] = v
-			queryParams[k] = v
+		if err := decodeBracketParams(bracketParams, queryParams, inputParams); err != nil {
+			return "", err
+		}
+		// 2.2 Body Parameters (POST/PUT)
+		var err error
+		if bodyParams, err = matchBodyKeys(matcher, bodyParams); err != nil {
+			return "", err
+		}
+		if strictDecoding {
+			if err := checkUnknownFields(argTypes, bodyParams); err != nil {
+				return "", err
+			}
+		}
+		if err := validateRequestSchema(operationName, bodyParams); err != nil {
+			return "", err
 		}
-		// 2.3 Body Parameters (POST/PUT)
 		for k, v := range bodyParams {
 			if k == "input" {
 				innerParams, _ := v.(map[string]interface{})
@@ -103,8 +174,60 @@ func convertHTTPRequestToGraphQLQuery(r *http.Request, params *graphql.RawParams
 			inputParams[k] = v
 			queryParams[k] = v
 		}
+		// 2.3 Header Parameters, they take precedence over query and body parameters
+		if err := applyHeaderVariables(r, operationName, argTypes, queryParams, inputParams); err != nil {
+			return "", err
+		}
+		if err := applyConcurrencyVariable(r, operationName, queryParams, inputParams); err != nil {
+			return "", err
+		}
+		// 2.4 Path Parameters (GET/POST/PUT/DELETE), they take precedence over body parameters
+		for i, k := range rctx.URLParams.Keys {
+			v := rctx.URLParams.Values[i]
+			if old, ok := inputParams[k]; ok && fmt.Sprint(old) != v {
+				dbgPrintf("path parameter %s=%v overrides body parameter %s=%v", k, v, k, old)
+			}
+			inputParams[k] = v
+			queryParams[k] = v
+		}
+		// 2.5 Enum Mappings, from the external values of the RESTful API
+		if err := applyEnumMappings(operationName, queryParams, inputParams); err != nil {
+			return "", err
+		}
+		// 2.6 Variable Defaults, for the parameters missing from the request
+		applyVariableDefaults(operationName, queryParams, inputParams)
 		queryParams["input"] = inputParams
 
+		if err := validateArguments(operationName, argTypes, queryParams); err != nil {
+			return "", err
+		}
+
+		if isAutomaticPersistedQuery(r) {
+			query, err := automaticPersistedQuery(r, operationName, argTypes)
+			if err != nil {
+				return "", err
+			}
+			// the single operation of the query is picked by gqlgen, it may be named otherwise
+			if err := bindPersistedQuery(params, "", query, argTypes, queryParams); err != nil {
+				return "", err
+			}
+			if err := bindToggleVariables(r, selectionToggles(query), params); err != nil {
+				return "", err
+			}
+			recordDebugEcho(r, params)
+			return params.Query, nil
+		}
+		if hash, ok := operationPersistedQueries[operationName]; ok {
+			if err := convertToPersistedQuery(params, operationName, hash, argTypes, queryParams); err != nil {
+				return "", err
+			}
+			if err := bindToggleVariables(r, selectionToggles(params.Query), params); err != nil {
+				return "", err
+			}
+			recordDebugEcho(r, params)
+			return params.Query, nil
+		}
+
 		queryParamsString := make([]string, 0)
 		for k, v := range queryParams {
 			if paramKV, err := formatInputsToGraphQL(argTypes, k, v); err != nil {
@@ -131,10 +254,121 @@ func convertHTTPRequestToGraphQLQuery(r *http.Request, params *graphql.RawParams
 
 	// DbgPrintf(r, "ADE:http.POST: %s", queryString)
 	params.Query = queryString
+	recordDebugEcho(r, params)
 
 	return queryString, nil
 }
 
+// isListParam reports whether parameter k is a list argument, or a list field of the input argument
+func isListParam(argTypes StringMap, k string) bool {
+	argType, ok := argTypes[k]
+	if !ok {
+		_, inputType := getUnderlayingArgType(argTypes["input"])
+		argType = inputType2FieldDefinitions[inputType][k]
+	}
+	isArray, _ := getUnderlayingArgType(argType)
+	return isArray
+}
+
+// paramError is returned when a request parameter can't be coerced to its argument type
+type paramError struct {
+	param string
+	msg   string
+}
+
+func (e *paramError) Error() string {
+	return e.msg
+}
+
+// convertErrorCode returns the response code for an error of convertHTTPRequestToGraphQLQuery
+func convertErrorCode(err error) int {
+	var pe *paramError
+	if errors.As(err, &pe) {
+		return http.StatusBadRequest
+	}
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		return http.StatusUnprocessableEntity
+	}
+	var pqe *persistedQueryError
+	if errors.As(err, &pqe) {
+		return http.StatusInternalServerError
+	}
+	var nfe *persistedQueryNotFoundError
+	if errors.As(err, &nfe) {
+		return http.StatusNotFound
+	}
+	var pre *preconditionRequiredError
+	if errors.As(err, &pre) {
+		return http.StatusPreconditionRequired
+	}
+	var pfe *preconditionFailedError
+	if errors.As(err, &pfe) {
+		return http.StatusPreconditionFailed
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// checkUnknownFields reports body fields matching neither an operation argument nor a field of its input
+func checkUnknownFields(argTypes StringMap, bodyParams map[string]interface{}) error {
+	_, inputType := getUnderlayingArgType(argTypes["input"])
+	for k, v := range bodyParams {
+		if k == "input" {
+			if _, ok := argTypes[k]; !ok {
+				return fmt.Errorf("json: unknown field %q", k)
+			}
+			innerParams, _ := v.(map[string]interface{})
+			for ik, iv := range innerParams {
+				if err := checkUnknownInputField(inputType, ik, iv, "input."); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if argType, ok := argTypes[k]; ok {
+			_, underlayingType := getUnderlayingArgType(argType)
+			if err := checkUnknownInputValue(underlayingType, v, k+"."); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := checkUnknownInputField(inputType, k, v, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkUnknownInputField(inputType string, k string, v interface{}, prefix string) error {
+	fieldType, ok := inputType2FieldDefinitions[inputType][k]
+	if !ok {
+		return fmt.Errorf("json: unknown field %q", prefix+k)
+	}
+	_, underlayingType := getUnderlayingArgType(fieldType)
+	return checkUnknownInputValue(underlayingType, v, prefix+k+".")
+}
+
+func checkUnknownInputValue(typeName string, v interface{}, prefix string) error {
+	if _, ok := inputType2FieldDefinitions[typeName]; !ok {
+		return nil
+	}
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for k, iv := range vv {
+			if err := checkUnknownInputField(typeName, k, iv, prefix); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, iv := range vv {
+			if err := checkUnknownInputValue(typeName, iv, prefix); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // func getMapStringInterface(v interface{}) map[string]interface{} {
 // 	if v == nil {
 // 		return make(map[string]interface{})
@@ -169,6 +403,9 @@ func formatInputsToGraphQL(argTypes StringMap, k string, v interface{}) (string,
 		//dbgPrintf("ignore param %v %v=%v", argTypes, k, v)
 		return "", nil
 	}
+	if v == nil {
+		return k + ":null", nil
+	}
 	isArray, underlayingType := getUnderlayingArgType(argType)
 
 	if !isArray {
@@ -196,26 +433,71 @@ func formatInputsToGraphQL(argTypes StringMap, k string, v interface{}) (string,
 	return fmt.Sprintf(`%s:[%s]`, k, strings.Join(vals, ",")), nil
 }
 
+// getUnderlayingArgType returns whether argType is a list and its underlying named type, see variableTypes
 func getUnderlayingArgType(argType string) (bool, string) {
-	isArray := strings.HasPrefix(argType, "[")
+	typ := lookupVariableType(argType)
+	return typ.isList, typ.named
+}
+
+var enumValueRegexp = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
 
-	argType = strings.ReplaceAll(argType, "[", "")
-	argType = strings.ReplaceAll(argType, "]", "")
-	argType = strings.ReplaceAll(argType, "!", "")
+// coerceArgValue coerces a string parameter (from path, query string or body) to the argument type:
+//   - Int: decimal integer, eg. "10"
+//   - Float: decimal or exponent notation, eg. "1.5" or "1e3", NaN and Inf are rejected
+//   - Boolean: "true"/"false" (also "1"/"0", "t"/"f", case insensitive)
+//   - ENUM: a valid enum value name, eg. "DONE"
+//
+// Values of other types, and non-string values, are formatted as-is.
+func coerceArgValue(underlayingType string, k string, v interface{}) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Sprintf(`%v`, v), nil
+	}
 
-	return isArray, argType
+	switch underlayingType {
+	case "Int":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return "", &paramError{k, fmt.Sprintf("mapping: parameter %s requires Int but got %q", k, s)}
+		}
+		return strconv.FormatInt(n, 10), nil
+	case "Float":
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", &paramError{k, fmt.Sprintf("mapping: parameter %s requires Float but got %q", k, s)}
+		}
+		return strconv.FormatFloat(f, 'g', -1, 64), nil
+	case "Boolean":
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return "", &paramError{k, fmt.Sprintf("mapping: parameter %s requires Boolean but got %q", k, s)}
+		}
+		return strconv.FormatBool(b), nil
+	default:
+		if !enumValueRegexp.MatchString(s) {
+			return "", &paramError{k, fmt.Sprintf("mapping: parameter %s requires %s but got %q", k, underlayingType, s)}
+		}
+		return s, nil
+	}
 }
 
 func formatArgValueToGraphQL(underlayingType string, k string, v interface{}) (string, error) {
+	if v == nil {
+		return "null", nil // eg. cleared by a merge patch
+	}
+	if name, ok := v.(uploadVariable); ok {
+		return "$" + string(name), nil
+	}
+
 	switch underlayingType {
 	case "Boolean", "Int", "Float":
-		return fmt.Sprintf(`%v`, v), nil
+		return coerceArgValue(underlayingType, k, v)
 	case "ID", "String", "Time", "IP", "IPRange", "MAC":
 		return fmt.Sprintf("%q", v), nil
 	default:
 		if typeKind, ok := typeName2TypeKinds[underlayingType]; ok {
 			if typeKind == "ENUM" {
-				return fmt.Sprintf(`%v`, v), nil
+				return coerceArgValue(underlayingType, k, v)
 			}
 
 			if typeKind == "INPUT_OBJECT" {
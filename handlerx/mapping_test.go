@@ -1 +1,162 @@
 package handlerx
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestMapping() {
+	SetupHTTP2GraphQLMapping(
+		StringMap{"POST:/todos": "createTodo", "GET:/todos": "todos", "PUT:/todos/{id}": "updateTodo", "PATCH:/todos/{id}": "updateTodo", "GET:/todos/{id}": "todo"},
+		StringMap{"createTodo": "{id,text}", "todos": "{id,text}", "updateTodo": "{id,text}", "todo": "{id,text}"},
+		ArgTypeMap{
+			"createTodo": {"input": "NewTodoInput!"},
+			"todos":      {"ids": "[ID!]", "limit": "Int", "done": "Boolean", "state": "TodoState"},
+			"updateTodo": {"input": "UpdateTodoInput!"},
+			"todo":       {"id": "Int!"},
+		},
+		ArgTypeMap{
+			"NewTodoInput":    {"text": "String!", "userId": "ID!", "tags": "[TagInput!]"},
+			"TagInput":        {"name": "String!"},
+			"UpdateTodoInput": {"id": "ID!", "text": "String"},
+		},
+		StringMap{"NewTodoInput": "INPUT_OBJECT", "TagInput": "INPUT_OBJECT", "UpdateTodoInput": "INPUT_OBJECT", "TodoState": "ENUM"},
+	)
+}
+
+func TestCheckUnknownFields(t *testing.T) {
+	setupTestMapping()
+
+	tests := []struct {
+		Name     string
+		Body     map[string]interface{}
+		Expected string
+	}{
+		{
+			Name: "known fields",
+			Body: map[string]interface{}{"input": map[string]interface{}{"text": "x", "userId": "1"}},
+		},
+		{
+			Name: "known fields without input wrapper",
+			Body: map[string]interface{}{"text": "x", "tags": []interface{}{map[string]interface{}{"name": "a"}}},
+		},
+		{
+			Name:     "unknown input field",
+			Body:     map[string]interface{}{"input": map[string]interface{}{"titel": "x"}},
+			Expected: `json: unknown field "input.titel"`,
+		},
+		{
+			Name:     "unknown nested field",
+			Body:     map[string]interface{}{"tags": []interface{}{map[string]interface{}{"nmae": "a"}}},
+			Expected: `json: unknown field "tags.nmae"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := checkUnknownFields(restOperation2Arguments["createTodo"], tt.Body)
+			if tt.Expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.Expected)
+			}
+		})
+	}
+}
+
+// convertTestRequest routes a request through chi and converts it to a GraphQL query
+func convertTestRequest(method, target, body string) (string, error) {
+	var query string
+	var err error
+
+	r := chi.NewRouter()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		query, err = convertHTTPRequestToGraphQLQuery(r, &graphql.RawParams{}, b)
+	}
+	for pattern := range restURL2GraphOperation {
+		parts := strings.SplitN(pattern, ":", 2)
+		r.MethodFunc(parts[0], parts[1], handler)
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, target, strings.NewReader(body)))
+	return query, err
+}
+
+func TestConvertHTTPRequestToGraphQLQuery(t *testing.T) {
+	setupTestMapping()
+
+	tests := []struct {
+		Name        string
+		Method      string
+		Target      string
+		Body        string
+		Contains    []string
+		ShouldError bool
+	}{
+		{
+			Name:     "typed query parameters",
+			Method:   http.MethodGet,
+			Target:   "/todos?limit=10&done=TRUE&state=OPEN&ids=1&ids=2",
+			Contains: []string{`limit:10`, `done:true`, `state:OPEN`, `ids:["1","2"]`},
+		},
+		{
+			Name:     "repeated list parameter",
+			Method:   http.MethodGet,
+			Target:   "/todos?ids=a,b&ids=c",
+			Contains: []string{`ids:["a,b","c"]`},
+		},
+		{
+			Name:        "invalid Int parameter",
+			Method:      http.MethodGet,
+			Target:      "/todos?limit=ten",
+			ShouldError: true,
+		},
+		{
+			Name:        "invalid enum parameter",
+			Method:      http.MethodGet,
+			Target:      "/todos?state=OPEN)%7B__typename%7D",
+			ShouldError: true,
+		},
+		{
+			Name:     "typed path parameter",
+			Method:   http.MethodGet,
+			Target:   "/todos/42",
+			Contains: []string{`id:42`},
+		},
+		{
+			Name:        "invalid path parameter",
+			Method:      http.MethodGet,
+			Target:      "/todos/abc",
+			ShouldError: true,
+		},
+		{
+			Name:     "path parameter overrides body",
+			Method:   http.MethodPut,
+			Target:   "/todos/7",
+			Body:     `{"input":{"id":"8","text":"x"}}`,
+			Contains: []string{`id:"7"`, `text:"x"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			query, err := convertTestRequest(tt.Method, tt.Target, tt.Body)
+			if tt.ShouldError {
+				assert.Error(t, err)
+				assert.Equal(t, http.StatusBadRequest, convertErrorCode(err))
+				return
+			}
+			assert.NoError(t, err)
+			for _, c := range tt.Contains {
+				assert.Contains(t, query, c)
+			}
+		})
+	}
+}
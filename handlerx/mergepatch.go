@@ -0,0 +1,128 @@
+package handlerx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// mergePatchError is returned when a JSON Merge Patch document can't be applied to the arguments
+type mergePatchError struct {
+	msg string
+}
+
+func (e *mergePatchError) Error() string {
+	return "merge patch: " + e.msg
+}
+
+// doMergePatch executes a PATCH request with a JSON Merge Patch (RFC 7396) body. Only the members
+// present in the document are passed to the mutation, and null members are passed as null to clear
+// the fields, so an absent field is never confused with its zero value.
+func (h POST) doMergePatch(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
+	isRESTful := true
+	start := graphql.Now()
+
+	if r.Method != http.MethodPatch {
+		writeHeader(w, r, http.StatusUnsupportedMediaType, isRESTful)
+		writeJSONErrorf(w, r, http.StatusUnsupportedMediaType, isRESTful, "merge patch requires PATCH but got %s", r.Method)
+		return
+	}
+
+	body, ok := readRequestBody(w, r)
+	if !ok {
+		return
+	}
+	bodyParams, err := mergePatchBodyParams(body)
+	if err != nil {
+		writeHeader(w, r, http.StatusBadRequest, isRESTful)
+		writeJSONErrorf(w, r, http.StatusUnprocessableEntity, isRESTful, "json body could not be decoded: %s", err.Error())
+		return
+	}
+
+	h.doRESTful(w, r, exec, new(graphql.RawParams), bodyParams, start)
+}
+
+// mergePatchBodyParams decodes a merge patch document to body parameters. The document must be an object,
+// members must not be duplicated, and a member given both inside and outside `input` must have the same value.
+func mergePatchBodyParams(body []byte) (map[string]interface{}, error) {
+	bodyParams := make(map[string]interface{})
+	if len(bytes.TrimSpace(body)) == 0 {
+		return bodyParams, nil
+	}
+	if err := checkDuplicateMembers(json.NewDecoder(bytes.NewReader(body)), ""); err != nil {
+		return nil, err
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		return nil, &mergePatchError{"document must be a JSON object"}
+	}
+	for k, raw := range members {
+		v, err := decodeRawMessage(raw)
+		if err != nil {
+			return nil, err
+		}
+		bodyParams[k] = v
+	}
+
+	input, _ := bodyParams["input"].(map[string]interface{})
+	for k, v := range input {
+		if old, ok := bodyParams[k]; ok && !reflect.DeepEqual(old, v) {
+			return nil, &mergePatchError{fmt.Sprintf("conflicting values of %s and input.%s", k, k)}
+		}
+	}
+	return bodyParams, nil
+}
+
+func decodeRawMessage(raw json.RawMessage) (interface{}, error) {
+	var v interface{}
+	if err := jsonDecode(bytes.NewReader(raw), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// checkDuplicateMembers walks the next JSON value of dec and rejects objects with duplicated members
+func checkDuplicateMembers(dec *json.Decoder, path string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		members := make(map[string]bool)
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			name, _ := tok.(string)
+			if path != "" {
+				name = path + "." + name
+			}
+			if members[name] {
+				return &mergePatchError{"conflicting values of " + name}
+			}
+			members[name] = true
+			if err := checkDuplicateMembers(dec, name); err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for i := 0; dec.More(); i++ {
+			if err := checkDuplicateMembers(dec, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return nil
+	}
+
+	_, err = dec.Token() // the closing delimiter
+	return err
+}
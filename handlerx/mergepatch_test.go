@@ -0,0 +1,83 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatchBodyParams(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Body     string
+		Expected map[string]interface{}
+		Error    string
+	}{
+		{Name: "empty body", Expected: map[string]interface{}{}},
+		{
+			Name:     "provided fields only",
+			Body:     `{"input":{"text":"x","done":null}}`,
+			Expected: map[string]interface{}{"input": map[string]interface{}{"text": "x", "done": nil}},
+		},
+		{
+			Name:     "same value inside and outside input",
+			Body:     `{"text":"x","input":{"text":"x"}}`,
+			Expected: map[string]interface{}{"text": "x", "input": map[string]interface{}{"text": "x"}},
+		},
+		{Name: "not an object", Body: `["x"]`, Error: "merge patch: document must be a JSON object"},
+		{Name: "duplicated member", Body: `{"input":{"text":"x","text":"y"}}`, Error: "merge patch: conflicting values of input.text"},
+		{Name: "different value inside and outside input", Body: `{"text":"x","input":{"text":"y"}}`, Error: "merge patch: conflicting values of text and input.text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			bodyParams, err := mergePatchBodyParams([]byte(tt.Body))
+			if tt.Error != "" {
+				assert.EqualError(t, err, tt.Error)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.Expected, bodyParams)
+		})
+	}
+}
+
+func TestMergePatchRequest(t *testing.T) {
+	setupTestMapping()
+
+	bodyParams, err := mergePatchBodyParams([]byte(`{"text":null}`))
+	assert.NoError(t, err)
+	r := WithRoute(httptest.NewRequest(http.MethodPatch, "/todos/1", nil), "/todos/{id}", "1")
+	query, err := convertHTTPRequestParamsToGraphQLQuery(r, &graphql.RawParams{}, bodyParams)
+	assert.NoError(t, err)
+	assert.Contains(t, query, `text:null`)
+	assert.NotContains(t, query, `done:`)
+
+	tests := []struct {
+		Name   string
+		Method string
+		Body   string
+		Code   int
+	}{
+		{Name: "conflicting fields", Method: http.MethodPatch, Body: `{"text":"x","text":"y"}`, Code: http.StatusUnprocessableEntity},
+		{Name: "not PATCH", Method: http.MethodPut, Body: `{"text":"x"}`, Code: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(tt.Method, "/todos/1", strings.NewReader(tt.Body)), "/todos/{id}", "1")
+			r.Header.Set("Content-Type", "application/merge-patch+json")
+			w := httptest.NewRecorder()
+			POST{}.Do(w, r, nil)
+
+			var resp map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, float64(tt.Code), resp["code"])
+		})
+	}
+}
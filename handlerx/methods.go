@@ -0,0 +1,174 @@
+package handlerx
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// methodNotAllowedError is returned for a RESTful route requested with a method it's not mapped for
+type methodNotAllowedError struct {
+	method  string
+	allowed []string
+}
+
+func (e *methodNotAllowedError) Error() string {
+	return fmt.Sprintf("method %s is not allowed, allowed methods: %s", e.method, strings.Join(e.allowed, ", "))
+}
+
+// allowedMethods returns the sorted HTTP methods operations are mapped for on the RESTful route pattern,
+// HEAD is allowed along with GET
+func allowedMethods(pattern string) []string {
+	methods := make([]string, 0)
+	for key := range restURL2GraphOperation {
+		method, p := splitRouteKey(key)
+		if p == pattern || (len(pattern) > 1 && strings.TrimSuffix(pattern, "/") == p) {
+			methods = appendMethod(methods, method)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// matchAllowedMethods is like allowedMethods, for the patterns matching the request path
+func matchAllowedMethods(path string) []string {
+	methods := make([]string, 0)
+	for key := range restURL2GraphOperation {
+		method, pattern := splitRouteKey(key)
+		if matchRoutePattern(pattern, path) {
+			methods = appendMethod(methods, method)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+func splitRouteKey(key string) (method, pattern string) {
+	if i := strings.IndexByte(key, ':'); i >= 0 {
+		return key[:i], key[i+1:]
+	}
+	return "", key
+}
+
+func appendMethod(methods []string, method string) []string {
+	for _, m := range methods {
+		if m == method {
+			return methods
+		}
+	}
+	methods = append(methods, method)
+	if method == http.MethodGet {
+		methods = appendMethod(methods, http.MethodHead)
+	}
+	return methods
+}
+
+// matchRoutePattern reports whether path matches the route pattern, a path parameter matches a segment
+func matchRoutePattern(pattern, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if pathSegments[i] == "" {
+				return false
+			}
+		} else if segment != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// matchRESTOperation returns the operation and the route pattern a RESTful request of method and path is routed to,
+// the most specific pattern wins when several match, see preferRoutePattern
+func matchRESTOperation(method, path string) (opName, pattern string, ok bool) {
+	if method == http.MethodHead {
+		method = http.MethodGet // HEAD mirrors GET
+	}
+	for key, op := range restURL2GraphOperation {
+		m, p := splitRouteKey(key)
+		if m != method || !matchRoutePattern(p, path) {
+			continue
+		}
+		if !ok || preferRoutePattern(p, pattern) {
+			opName, pattern, ok = op, p, true
+		}
+	}
+	return opName, pattern, ok
+}
+
+// preferRoutePattern reports whether the route pattern p is preferred to q, both matching the same path:
+// the pattern with fewer path parameters, then the one with a static segment where the other has a path
+// parameter, eg. "/todos/search/{id}" to "/todos/{id}/{action}", then the longer one, then the lesser one
+func preferRoutePattern(p, q string) bool {
+	if np, nq := strings.Count(p, "{"), strings.Count(q, "{"); np != nq {
+		return np < nq
+	}
+	ps := strings.Split(strings.Trim(p, "/"), "/")
+	qs := strings.Split(strings.Trim(q, "/"), "/")
+	for i := 0; i < len(ps) && i < len(qs); i++ {
+		if pStatic, qStatic := !strings.HasPrefix(ps[i], "{"), !strings.HasPrefix(qs[i], "{"); pStatic != qStatic {
+			return pStatic
+		}
+	}
+	if len(p) != len(q) {
+		return len(p) > len(q)
+	}
+	return p < q
+}
+
+// MethodNotAllowed responds 405 Method Not Allowed with an Allow header listing the methods of the
+// RESTful routes matching the request path, eg. for chi routers: r.MethodNotAllowed(handlerx.MethodNotAllowed).
+// Requests of other paths get a bare 405. CORS preflights of routes with a CORS policy are answered, see SetCORS.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	if handlePreflight(w, r) {
+		return
+	}
+	allowed := matchAllowedMethods(r.URL.Path)
+	if len(allowed) == 0 {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", jsonContentType)
+	r = withRequestID(w, r)
+	writeMethodNotAllowed(w, r, &methodNotAllowedError{method: r.Method, allowed: allowed})
+}
+
+// writeMethodNotAllowed writes the 405 response of err
+func writeMethodNotAllowed(w http.ResponseWriter, r *http.Request, err *methodNotAllowedError) {
+	w.Header().Set("Allow", strings.Join(err.allowed, ", "))
+	writeHeader(w, r, http.StatusMethodNotAllowed, true)
+	writeJSONError(w, r, http.StatusMethodNotAllowed, true, err.Error())
+}
+
+// routeMethodNotAllowed returns a methodNotAllowedError if the route of r is a RESTful route pattern
+// mapped for other methods only, eg. when it's registered for every method
+func routeMethodNotAllowed(r *http.Request) *methodNotAllowedError {
+	if isRESTRoute(r) {
+		return nil
+	}
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return nil
+	}
+	if allowed := allowedMethods(rctx.RoutePattern()); len(allowed) > 0 {
+		return &methodNotAllowedError{method: r.Method, allowed: allowed}
+	}
+	return nil
+}
+
+// allowRouteMethod responds 405 if the route of r isn't mapped for its method, see routeMethodNotAllowed
+func allowRouteMethod(w http.ResponseWriter, r *http.Request) bool {
+	if err := routeMethodNotAllowed(r); err != nil {
+		writeMethodNotAllowed(w, r, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package handlerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMethodNotAllowed(t *testing.T) {
+	setupTestMapping()
+
+	tests := []struct {
+		Name   string
+		Method string
+		Target string
+		Status int
+		Allow  string
+	}{
+		{Name: "collection", Method: http.MethodDelete, Target: "/todos", Status: http.StatusMethodNotAllowed, Allow: "GET, HEAD, POST"},
+		{Name: "item", Method: http.MethodDelete, Target: "/todos/1", Status: http.StatusMethodNotAllowed, Allow: "GET, HEAD, PATCH, PUT"},
+		{Name: "other path", Method: http.MethodDelete, Target: "/users", Status: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := chi.NewRouter()
+			r.MethodNotAllowed(MethodNotAllowed)
+			r.Get("/todos", func(w http.ResponseWriter, r *http.Request) {})
+			r.Get("/todos/{id}", func(w http.ResponseWriter, r *http.Request) {})
+			r.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.Method, tt.Target, nil))
+			assert.Equal(t, tt.Status, w.Code)
+			assert.Equal(t, tt.Allow, w.Header().Get("Allow"))
+			if tt.Allow != "" {
+				assert.Contains(t, w.Body.String(), `"code":405`)
+			}
+		})
+	}
+}
+
+func TestAllowRouteMethod(t *testing.T) {
+	setupTestMapping()
+
+	// a route registered for every method
+	r := WithRoute(httptest.NewRequest(http.MethodPatch, "/todos", strings.NewReader(`{}`)), "/todos")
+	assert.True(t, POST{}.Supports(r))
+	w := httptest.NewRecorder()
+	POST{}.Do(w, r, nil)
+	assert.Equal(t, "GET, HEAD, POST", w.Header().Get("Allow"))
+	assert.Contains(t, w.Body.String(), `"code":405`)
+
+	r = WithRoute(httptest.NewRequest(http.MethodPost, "/todos", nil), "/todos")
+	assert.True(t, allowRouteMethod(httptest.NewRecorder(), r))
+}
+
+func TestMatchRESTOperation(t *testing.T) {
+	setupTestMapping()
+	defer setupTestMapping()
+	for key, op := range map[string]string{
+		"GET:/todos/search":          "searchTodos",
+		"GET:/todos/search/{id}":     "searchTodo",
+		"GET:/todos/{id}/{action}":   "todoAction",
+		"GET:/todos/{id}/done":       "todoDone",
+		"GET:/{kind}/{id}/done":      "itemDone",
+		"GET:/users/{userId}":        "userByID",
+		"GET:/users/{id}":            "user",
+		"GET:/users/{id}/":           "userSlash",
+		"GET:/tags/{a}":              "tagA",
+		"GET:/tags/{b}":              "tagB",
+		"DELETE:/todos/{id}/archive": "archiveTodo",
+	} {
+		restURL2GraphOperation[key] = op
+	}
+
+	tests := []struct {
+		Name    string
+		Method  string
+		Path    string
+		OpName  string
+		Pattern string
+	}{
+		{Name: "fewer path parameters", Method: http.MethodGet, Path: "/todos/search", OpName: "searchTodos", Pattern: "/todos/search"},
+		{Name: "static segment first", Method: http.MethodGet, Path: "/todos/search/1", OpName: "searchTodo", Pattern: "/todos/search/{id}"},
+		{Name: "leftmost static segment", Method: http.MethodGet, Path: "/todos/1/done", OpName: "todoDone", Pattern: "/todos/{id}/done"},
+		{Name: "longer pattern", Method: http.MethodGet, Path: "/users/1", OpName: "userByID", Pattern: "/users/{userId}"},
+		{Name: "same length", Method: http.MethodGet, Path: "/tags/1", OpName: "tagA", Pattern: "/tags/{a}"},
+		{Name: "HEAD", Method: http.MethodHead, Path: "/todos/1", OpName: "todo", Pattern: "/todos/{id}"},
+		{Name: "other method", Method: http.MethodDelete, Path: "/todos/1/done"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			for i := 0; i < 20; i++ { // the operations are iterated in random order
+				opName, pattern, ok := matchRESTOperation(tt.Method, tt.Path)
+				assert.Equal(t, tt.OpName != "", ok)
+				assert.Equal(t, tt.OpName, opName)
+				assert.Equal(t, tt.Pattern, pattern)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package handlerx
+
+import (
+	"net/http"
+	"time"
+)
+
+// Metrics observes handled requests, eg. to export Prometheus counters and histograms
+type Metrics interface {
+	// ObserveRequest is called once per request. op is the GraphQL operation of RESTful
+	// requests or the URL path of GraphQL requests, code is the response code of RESTful
+	// requests or the HTTP status of GraphQL requests.
+	ObserveRequest(op string, code int, dur time.Duration)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveRequest(string, int, time.Duration) {}
+
+var _metrics Metrics = noopMetrics{}
+
+// RegisterMetrics registers the metrics observer of all requests, pass nil to disable it
+func RegisterMetrics(metrics Metrics) {
+	if metrics == nil {
+		metrics = noopMetrics{}
+	}
+	_metrics = metrics
+}
+
+// statusRecorder records the HTTP status written to a response, and reads its response code from state
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+	state  *responseState
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Flush implements http.Flusher
+func (w *statusRecorder) Flush() {
+	flushResponse(w.ResponseWriter)
+}
+
+// recordedCode returns the response code recorded by recordCode, if any
+func (w *statusRecorder) recordedCode() (int, bool) {
+	if w.state == nil || w.state.code == nil {
+		return 0, false
+	}
+	return *w.state.code, true
+}
+
+// responseCode returns the recorded response code, or the HTTP status if there's none
+func (w *statusRecorder) responseCode() int {
+	if code, ok := w.recordedCode(); ok {
+		return code
+	}
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// wrapMetrics returns a writer recording the response, the returned func reports the request to
+// the registered Metrics and must be called once the response is written. It must be called after
+// withResponseState, the response code is recorded in the state of r.
+func wrapMetrics(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, func()) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, state: responseStateFromContext(r.Context())}
+	return rec, func() {
+		dur := time.Since(start)
+		_metrics.ObserveRequest(observedOperation(r), rec.responseCode(), dur)
+		logAccess(r, rec, dur)
+	}
+}
+
+// observedOperation returns the GraphQL operation of RESTful requests, or the URL path of GraphQL requests
+func observedOperation(r *http.Request) string {
+	if op, ok := restOperationName(r); ok {
+		return op
+	}
+	return r.URL.Path
+}
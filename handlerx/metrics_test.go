@@ -0,0 +1,130 @@
+package handlerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+type testMetrics struct {
+	op   string
+	code int
+}
+
+func (m *testMetrics) ObserveRequest(op string, code int, dur time.Duration) {
+	m.op = op
+	m.code = code
+}
+
+func TestRegisterMetrics(t *testing.T) {
+	defer RegisterMetrics(nil)
+
+	metrics := &testMetrics{}
+	RegisterMetrics(metrics)
+
+	tests := []struct {
+		Name      string
+		IsRESTful bool
+		Response  *graphql.Response
+		Expected  int
+	}{
+		{
+			Name:      "restful error",
+			IsRESTful: true,
+			Response:  &graphql.Response{Errors: gqlerror.List{{Message: "not found", Extensions: map[string]interface{}{"code": "404"}}}},
+			Expected:  404,
+		},
+		{
+			Name:     "graphql",
+			Response: &graphql.Response{Errors: gqlerror.List{{Message: "not found"}}},
+			Expected: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := withResponseState(httptest.NewRequest(http.MethodGet, "/query", nil))
+			w, observe := wrapMetrics(httptest.NewRecorder(), r)
+			writeJSON(w, r, tt.Response, tt.IsRESTful)
+			observe()
+
+			assert.Equal(t, "/query", metrics.op)
+			assert.Equal(t, tt.Expected, metrics.code)
+		})
+	}
+}
+
+func TestTransportMetricsCode(t *testing.T) {
+	setupTestMapping()
+	metrics := &testMetrics{}
+	RegisterMetrics(metrics)
+	defer RegisterMetrics(nil)
+	var logged []int
+	SetAccessLogger(func(e AccessLogEntry) { logged = append(logged, e.Code) })
+	defer SetAccessLogger(nil)
+	for _, op := range []string{"todos", "createTodo"} {
+		SetCaptureSink(op, func(req, resp []byte) {})
+		defer SetCaptureSink(op, nil)
+	}
+	SetIdempotencyStore(NewMemoryIdempotencyStore(), time.Minute)
+	defer SetIdempotencyStore(nil, 0)
+	SetResponseCache(NewMemoryResponseCache())
+	defer SetResponseCache(nil)
+
+	notFound := gqlerror.List{{Message: "not found", Extensions: map[string]interface{}{"code": 404}}}
+	get := func(exec graphql.GraphExecutor) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		GET{}.Do(w, WithRoute(httptest.NewRequest(http.MethodGet, "/todos", nil), "/todos"), exec)
+		return w
+	}
+	post := func() *httptest.ResponseRecorder {
+		r := WithRoute(httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(`{"text":"abc","userId":"1"}`)), "/todos")
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set(IdempotencyKeyHeader, "k1")
+		w := httptest.NewRecorder()
+		POST{}.Do(w, r, &slowExecutor{canceled: make(chan bool, 1)})
+		return w
+	}
+
+	tests := []struct {
+		Name     string
+		Serve    func() *httptest.ResponseRecorder
+		Header   string
+		Value    string
+		Expected int
+	}{
+		{Name: "error", Serve: func() *httptest.ResponseRecorder {
+			return get(&flakyQueryExecutor{flakyExecutor{failures: 1, errs: notFound}})
+		}, Expected: http.StatusNotFound},
+		{Name: "idempotency first use", Serve: post, Expected: 0},
+		{Name: "idempotency replay", Serve: post, Header: "Idempotent-Replayed", Value: "true", Expected: 0},
+		{Name: "cache miss", Serve: func() *httptest.ResponseRecorder {
+			SetResponseCacheTTL("todos", time.Minute)
+			return get(&paramsExecutor{})
+		}, Header: "X-Cache", Value: "MISS", Expected: 0},
+		{Name: "cache hit", Serve: func() *httptest.ResponseRecorder {
+			defer SetResponseCacheTTL("todos", 0)
+			return get(&paramsExecutor{})
+		}, Header: "X-Cache", Value: "HIT", Expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			metrics.code = -1
+			logged = nil
+			w := tt.Serve()
+			assert.Equal(t, http.StatusOK, w.Code)
+			if tt.Header != "" {
+				assert.Equal(t, tt.Value, w.Header().Get(tt.Header))
+			}
+			assert.Equal(t, tt.Expected, metrics.code)
+			assert.Equal(t, []int{tt.Expected}, logged)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// MockHandler returns a handler responding data as the result of operation opName in the RESTful
+// envelope without executing it, eg. for the mock server generated by restgen. The response is shaped
+// like a real one (unwrapping, transforms, fields filtering, content negotiation), so the routes must be
+// registered with their RESTful mapping, eg. after SetupRESTMapping.
+func MockHandler(opName string, data json.RawMessage) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", jsonContentType)
+		r = withRequestID(w, r)
+		r = withResponseState(r)
+
+		key, _ := json.Marshal(opName)
+		value := data
+		if len(value) == 0 {
+			value = json.RawMessage("null")
+		}
+		writeJSON(w, r, &graphql.Response{Data: json.RawMessage(`{` + string(key) + `:` + string(value) + `}`)}, true)
+	})
+}
@@ -0,0 +1,37 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockHandler(t *testing.T) {
+	setupTestMapping()
+
+	tests := []struct {
+		Name     string
+		Target   string
+		Data     string
+		Expected string
+	}{
+		{Name: "enveloped", Target: "/todos", Data: `[{"id":"1","text":"text"}]`, Expected: `{"code":0,"data":[{"id":"1","text":"text"}]}`},
+		{Name: "shaped like a real response", Target: "/todos?fields=id", Data: `[{"id":"1","text":"text"}]`, Expected: `{"code":0,"data":[{"id":"1"}]}`},
+		{Name: "no data", Target: "/todos", Expected: `{"code":0,"data":null}`},
+	}
+
+	SetFieldFiltering(true)
+	defer SetFieldFiltering(false)
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(http.MethodGet, tt.Target, nil), "/todos")
+			w := httptest.NewRecorder()
+			MockHandler("todos", json.RawMessage(tt.Data)).ServeHTTP(w, r)
+			assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.Expected, w.Body.String())
+		})
+	}
+}
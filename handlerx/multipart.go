@@ -0,0 +1,148 @@
+package handlerx
+
+import (
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+var (
+	multipartMaxMemory int64 = 32 << 20
+	multipartMaxBytes  int64
+)
+
+// SetMultipartLimits configures RESTful multipart/form-data requests: maxMemory bytes of the
+// files are kept in memory (the rest is stored on disk), and bodies larger than maxBytes, or the
+// SetMaxRequestBytes limit if it's smaller, are rejected with 413. A maxBytes of 0 means unlimited (default).
+func SetMultipartLimits(maxMemory int64, maxBytes int64) {
+	multipartMaxMemory = maxMemory
+	multipartMaxBytes = maxBytes
+}
+
+// multipartLimit returns the size limit of multipart bodies, the smaller of the SetMultipartLimits
+// and SetMaxRequestBytes ones, 0 if both are unlimited
+func multipartLimit() int64 {
+	if maxRequestBytes > 0 && (multipartMaxBytes <= 0 || maxRequestBytes < multipartMaxBytes) {
+		return maxRequestBytes
+	}
+	return multipartMaxBytes
+}
+
+// uploadVariable is a placeholder of an uploaded file in body parameters,
+// it's formatted as a reference to the GraphQL variable holding the graphql.Upload.
+type uploadVariable string
+
+// isRESTRoute reports whether r is routed to a RESTful operation
+func isRESTRoute(r *http.Request) bool {
+	_, ok := restOperationName(r)
+	return ok
+}
+
+// isBodyTooLarge reports whether err is caused by http.MaxBytesReader
+func isBodyTooLarge(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "request body too large")
+}
+
+// multipartBodyParams converts a multipart form to body parameters, values are converted like
+// form-urlencoded bodies (see formBodyParams). Files are stored as graphql.Upload
+// variables of params, mapped to the argument (or input field) with the same name as the form field.
+// The returned func closes the uploaded files.
+func multipartBodyParams(form *multipart.Form, params *graphql.RawParams) (map[string]interface{}, func(), error) {
+	bodyParams := formBodyParams(form.Value)
+
+	files := make([]multipart.File, 0)
+	closeFiles := func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}
+
+	keys := make([]string, 0, len(form.File))
+	for k := range form.File {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if params.Variables == nil {
+		params.Variables = make(map[string]interface{})
+	}
+	for _, k := range keys {
+		vals := make([]interface{}, 0, len(form.File[k]))
+		for _, header := range form.File[k] {
+			f, err := header.Open()
+			if err != nil {
+				closeFiles()
+				return nil, func() {}, err
+			}
+			files = append(files, f)
+
+			name := "upload" + strconv.Itoa(len(files)-1)
+			params.Variables[name] = graphql.Upload{
+				File:        f,
+				Filename:    header.Filename,
+				Size:        header.Size,
+				ContentType: header.Header.Get("Content-Type"),
+			}
+			vals = append(vals, uploadVariable(name))
+		}
+		if len(vals) == 1 {
+			bodyParams[k] = vals[0]
+		} else {
+			bodyParams[k] = vals
+		}
+	}
+	return bodyParams, closeFiles, nil
+}
+
+// variableDefinitions returns the variable definitions of a generated query with the extra ones, eg. "($upload0:Upload!)"
+func variableDefinitions(variables map[string]interface{}, extras ...string) string {
+	defs := append(make([]string, 0), extras...)
+	for k, v := range variables {
+		if _, ok := v.(graphql.Upload); ok {
+			defs = append(defs, "$"+k+":Upload!")
+		}
+	}
+	if len(defs) == 0 {
+		return ""
+	}
+	sort.Strings(defs)
+	return "(" + strings.Join(defs, ",") + ")"
+}
+
+func (h POST) doMultipart(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
+	isRESTful := true
+	start := graphql.Now()
+
+	maxBytes := multipartLimit()
+	if maxBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	}
+	if err := r.ParseMultipartForm(multipartMaxMemory); err != nil {
+		if isBodyTooLarge(err) {
+			writeHeader(w, r, http.StatusRequestEntityTooLarge, isRESTful)
+			writeJSONErrorWithExtensions(w, r, http.StatusRequestEntityTooLarge, isRESTful, fmt.Sprintf("multipart body exceeds %d bytes", maxBytes),
+				map[string]interface{}{"reason": "BODY_TOO_LARGE", "retryable": false})
+			return
+		}
+		writeHeader(w, r, http.StatusBadRequest, isRESTful)
+		writeJSONErrorf(w, r, http.StatusBadRequest, isRESTful, "multipart body could not be parsed: "+err.Error())
+		return
+	}
+	defer func() { _ = r.MultipartForm.RemoveAll() }()
+
+	params := new(graphql.RawParams)
+	bodyParams, closeFiles, err := multipartBodyParams(r.MultipartForm, params)
+	if err != nil {
+		writeHeader(w, r, http.StatusBadRequest, isRESTful)
+		writeJSONErrorf(w, r, http.StatusBadRequest, isRESTful, "multipart file could not be opened: "+err.Error())
+		return
+	}
+	defer closeFiles()
+
+	h.doRESTful(w, r, exec, params, bodyParams, start)
+}
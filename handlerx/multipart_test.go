@@ -0,0 +1,134 @@
+package handlerx
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+// newMultipartRequest returns a multipart/form-data POST /todos request with the form values and files
+func newMultipartRequest(t *testing.T, values, files map[string]string) *http.Request {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range values {
+		assert.NoError(t, mw.WriteField(k, v))
+	}
+	for k, v := range files {
+		fw, err := mw.CreateFormFile(k, k+".txt")
+		assert.NoError(t, err)
+		_, _ = fw.Write([]byte(v))
+	}
+	assert.NoError(t, mw.Close())
+
+	r := WithRoute(httptest.NewRequest(http.MethodPost, "/todos", &body), "/todos")
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func TestSetMultipartLimits(t *testing.T) {
+	setupTestMapping()
+	defer SetMultipartLimits(32<<20, 0)
+	defer SetMaxRequestBytes(0)
+	defer SetVerboseErrors(false)
+	SetVerboseErrors(true)
+
+	tests := []struct {
+		Name            string
+		MaxBytes        int64
+		MaxRequestBytes int64
+		Code            int
+		Executed        bool
+	}{
+		{Name: "unlimited", Code: http.StatusOK, Executed: true},
+		{Name: "within the limit", MaxBytes: 1 << 10, Code: http.StatusOK, Executed: true},
+		{Name: "too large", MaxBytes: 64, Code: http.StatusRequestEntityTooLarge},
+		{Name: "too large for the request limit", MaxRequestBytes: 64, Code: http.StatusRequestEntityTooLarge},
+		{Name: "request limit smaller", MaxBytes: 1 << 10, MaxRequestBytes: 64, Code: http.StatusRequestEntityTooLarge},
+		{Name: "multipart limit smaller", MaxBytes: 64, MaxRequestBytes: 1 << 10, Code: http.StatusRequestEntityTooLarge},
+		{Name: "within both limits", MaxBytes: 1 << 10, MaxRequestBytes: 1 << 10, Code: http.StatusOK, Executed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetMultipartLimits(32<<20, tt.MaxBytes)
+			SetMaxRequestBytes(tt.MaxRequestBytes)
+			r := newMultipartRequest(t, map[string]string{"userId": "1"}, map[string]string{"text": strings.Repeat("x", 128)})
+			w := httptest.NewRecorder()
+			exec := &paramsExecutor{}
+			POST{}.Do(w, r, exec)
+			assert.Equal(t, tt.Code, w.Code)
+			assert.Equal(t, tt.Executed, len(exec.params) == 1)
+			if !tt.Executed {
+				assert.Contains(t, w.Body.String(), `"code":413,"message":"multipart body exceeds 64 bytes"`)
+				assert.Contains(t, w.Body.String(), `"extensions":{"code":413,"reason":"BODY_TOO_LARGE","retryable":false}`)
+			}
+		})
+	}
+}
+
+func TestMultipartBodyParams(t *testing.T) {
+	setupTestMapping()
+
+	tests := []struct {
+		Name      string
+		Values    map[string]string
+		Files     map[string]string
+		Query     string
+		Variables []string
+	}{
+		{Name: "file", Values: map[string]string{"userId": "1"}, Files: map[string]string{"text": "hello"},
+			Query: `($upload0:Upload!)`, Variables: []string{"upload0"}},
+		{Name: "files", Files: map[string]string{"text": "hello", "userId": "1"},
+			Query: `($upload0:Upload!,$upload1:Upload!)`, Variables: []string{"upload0", "upload1"}},
+		{Name: "missing file", Values: map[string]string{"userId": "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := newMultipartRequest(t, tt.Values, tt.Files)
+			w := httptest.NewRecorder()
+			exec := &paramsExecutor{}
+			POST{}.Do(w, r, exec)
+			assert.Equal(t, http.StatusOK, w.Code)
+			if !assert.Len(t, exec.params, 1) {
+				return
+			}
+			params := exec.params[0]
+			assert.Len(t, params.Variables, len(tt.Variables))
+			for i, name := range tt.Variables {
+				upload, ok := params.Variables[name].(graphql.Upload)
+				if assert.True(t, ok, name) {
+					assert.Contains(t, params.Query, "$"+name)
+					assert.Equal(t, tt.Files[strings.TrimSuffix(upload.Filename, ".txt")], readUpload(t, upload), i)
+				}
+			}
+			if tt.Query != "" {
+				assert.Contains(t, params.Query, tt.Query)
+			} else {
+				assert.NotContains(t, params.Query, "Upload!")
+			}
+		})
+	}
+}
+
+func TestMultipartBodyParamsOpenError(t *testing.T) {
+	form := &multipart.Form{File: map[string][]*multipart.FileHeader{"text": {{Filename: "gone.txt"}}}}
+	params := new(graphql.RawParams)
+	_, closeFiles, err := multipartBodyParams(form, params)
+	assert.Error(t, err)
+	assert.NotNil(t, closeFiles)
+	assert.Empty(t, params.Variables)
+}
+
+func readUpload(t *testing.T, upload graphql.Upload) string {
+	b, err := ioutil.ReadAll(upload.File)
+	assert.NoError(t, err)
+	return string(b)
+}
@@ -0,0 +1,114 @@
+package handlerx
+
+import (
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// negotiateContentType picks the offer that best matches the Accept header of req.
+// The first offer is the default, it's returned when req is nil, the header is
+// missing or nothing matches.
+func negotiateContentType(req *http.Request, offers ...string) string {
+	if req == nil {
+		return offers[0]
+	}
+	accept := req.Header.Get("Accept")
+	if accept == "" {
+		return offers[0]
+	}
+
+	best, bestQ := offers[0], 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+		for _, offer := range offers {
+			if matchMediaType(mediaType, offer) {
+				best, bestQ = offer, q
+				break
+			}
+		}
+	}
+	return best
+}
+
+var contentNegotiation bool
+
+// SetContentNegotiation makes RESTful responses negotiate their content type from the Accept header:
+// XML (application/xml or text/xml) and CSV (text/csv) are sent to the requests preferring them.
+// It's disabled by default, as browsers prefer XML to `*/*`: all responses are sent as JSON.
+func SetContentNegotiation(enabled bool) {
+	contentNegotiation = enabled
+}
+
+// responseContentType returns the content type of the RESTful response to req
+func responseContentType(req *http.Request) string {
+	if !contentNegotiation {
+		return "application/json"
+	}
+	return negotiateContentType(req, "application/json", "application/xml", "text/xml", "text/csv")
+}
+
+// matchMediaType reports whether offer matches the (possibly wildcard) media type
+func matchMediaType(mediaType, offer string) bool {
+	if mediaType == "*/*" || mediaType == offer {
+		return true
+	}
+	if strings.HasSuffix(mediaType, "/*") {
+		return strings.HasPrefix(offer, strings.TrimSuffix(mediaType, "*"))
+	}
+	return false
+}
+
+// acceptLanguages returns the language tags of the Accept-Language header of req, by descending q-value.
+// Tags with the same q-value keep their order, and the wildcard "*" is ignored.
+func acceptLanguages(req *http.Request) []string {
+	if req == nil {
+		return nil
+	}
+
+	type tag struct {
+		lang string
+		q    float64
+	}
+	tags := make([]tag, 0)
+	for _, part := range strings.Split(req.Header.Get("Accept-Language"), ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		lang := strings.TrimSpace(fields[0])
+		if lang == "" || lang == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			tags = append(tags, tag{lang, q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	langs := make([]string, 0, len(tags))
+	for _, t := range tags {
+		langs = append(langs, t.lang)
+	}
+	return langs
+}
@@ -0,0 +1,28 @@
+package handlerx
+
+import (
+	"encoding/json"
+)
+
+var omitNulls bool
+
+// SetOmitNulls makes RESTful responses drop the members of the `data` objects whose values are null,
+// in nested objects and lists of objects too. Null list items and a null `data` itself are kept.
+// It's applied after the registered data transforms.
+func SetOmitNulls(enabled bool) {
+	omitNulls = enabled
+}
+
+// omitNullMembers removes the null members of the objects of data, keeping the order of the members
+func omitNullMembers(data json.RawMessage) (json.RawMessage, error) {
+	w := &jsonWalk{}
+	omitNullWalk(w)
+	return w.walk(data)
+}
+
+// omitNullWalk makes w drop the null members of objects
+func omitNullWalk(w *jsonWalk) {
+	w.member = func(name string, value json.RawMessage) (*jsonWalk, bool) {
+		return w, string(value) != "null"
+	}
+}
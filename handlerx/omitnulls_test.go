@@ -0,0 +1,46 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOmitNullMembers(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Data     string
+		Expected string
+	}{
+		{Name: "object", Data: `{"id":"1","text":null,"done":false}`, Expected: `{"id":"1","done":false}`},
+		{Name: "nested object", Data: `{"id":"1","user":{"id":"u","ip":null}}`, Expected: `{"id":"1","user":{"id":"u"}}`},
+		{Name: "list of objects", Data: `[{"id":"1","text":null},null,{"id":"2","tags":[{"name":null}]}]`, Expected: `[{"id":"1"},null,{"id":"2","tags":[{}]}]`},
+		{Name: "all null", Data: `{"text":null}`, Expected: `{}`},
+		{Name: "null", Data: `null`, Expected: `null`},
+		{Name: "scalar", Data: `42`, Expected: `42`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			data, err := omitNullMembers(json.RawMessage(tt.Data))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.Expected, string(data))
+		})
+	}
+}
+
+func TestSetOmitNulls(t *testing.T) {
+	SetOmitNulls(true)
+	defer SetOmitNulls(false)
+
+	w := httptest.NewRecorder()
+	writeJSON(w, nil, &graphql.Response{Data: json.RawMessage(`{"todo":{"id":"1","text":null}}`)}, true)
+	assert.Equal(t, `{"code":0,"data":{"id":"1"}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	writeJSON(w, nil, &graphql.Response{Data: json.RawMessage(`{"todo":null}`)}, true)
+	assert.Equal(t, `{"code":0,"data":null}`, w.Body.String())
+}
@@ -0,0 +1,115 @@
+package handlerx
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RegisterOperation maps the RESTful route of method and pathTemplate (eg. "/api/v1/todos/{id}") to the
+// GraphQL operation opName, selection is its field selection, eg. "{id,text}", and argTypes the types of
+// its arguments, eg. {"id": "ID!"}. An empty selection and nil argTypes keep the ones of the operation
+// registered before, eg. by the generated SetupRESTMapping, call it after it to add or override routes.
+// It returns an error if opName isn't registered and no selection is given, or if a path parameter of
+// pathTemplate is neither an argument nor a field of the input argument of the operation.
+func RegisterOperation(method, pathTemplate, opName, selection string, argTypes StringMap) error {
+	if selection == "" {
+		if _, ok := graphOperation2RESTSelection[opName]; !ok {
+			return fmt.Errorf("handlerx: unknown operation %s, its selection is required", opName)
+		}
+	}
+	if argTypes == nil {
+		argTypes = restOperation2Arguments[opName]
+	}
+	if err := checkPathParams(pathTemplate, opName, argTypes); err != nil {
+		return err
+	}
+
+	registerRoute(method, pathTemplate, opName)
+	if selection != "" {
+		if graphOperation2RESTSelection == nil {
+			graphOperation2RESTSelection = make(StringMap)
+		}
+		graphOperation2RESTSelection[opName] = selection
+	}
+	if argTypes != nil {
+		if restOperation2Arguments == nil {
+			restOperation2Arguments = make(ArgTypeMap)
+		}
+		restOperation2Arguments[opName] = argTypes
+	}
+	return nil
+}
+
+// registerRoute maps the RESTful route of method and pathTemplate to the GraphQL operation opName
+func registerRoute(method, pathTemplate, opName string) {
+	if restURL2GraphOperation == nil {
+		restURL2GraphOperation = make(StringMap)
+	}
+	restURL2GraphOperation[strings.ToUpper(method)+":"+pathTemplate] = opName
+}
+
+// checkPathParams checks that the path parameters of pathTemplate are arguments (or input fields) of argTypes,
+// as the parameters matching none are dropped
+func checkPathParams(pathTemplate, opName string, argTypes StringMap) error {
+	_, inputType := getUnderlayingArgType(argTypes["input"])
+	for _, segment := range strings.Split(strings.Trim(pathTemplate, "/"), "/") {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		param := strings.TrimSuffix(strings.TrimPrefix(segment, "{"), "}")
+		if _, ok := argTypes[param]; ok {
+			continue
+		}
+		if _, ok := inputType2FieldDefinitions[inputType][param]; ok {
+			continue
+		}
+		return fmt.Errorf("handlerx: path parameter %s of %s is not an argument of %s", param, pathTemplate, opName)
+	}
+	return nil
+}
+
+// MatchOperation resolves r to the registered RESTful route of its method and path, and returns it routed
+// to the route with the path parameters extracted, like WithRoute. Static segments are preferred to path
+// parameters, eg. "/todos/search" to "/todos/{id}".
+func MatchOperation(r *http.Request) (*http.Request, bool) {
+	_, pattern, ok := matchRESTOperation(r.Method, r.URL.Path)
+	if !ok {
+		return r, false
+	}
+	return WithRoute(r, pattern, extractPathParams(pattern, r.URL.Path)...), true
+}
+
+// OperationHandler routes the requests to srv by the registered RESTful routes, for routers without path
+// parameters, eg. http.Handle("/api/", handlerx.OperationHandler(srv)). Requests of paths matching no
+// route are responded with 404, and of methods a path isn't mapped for with 405.
+func OperationHandler(srv http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if routed, ok := MatchOperation(r); ok {
+			srv.ServeHTTP(w, routed)
+			return
+		}
+		if len(matchAllowedMethods(r.URL.Path)) > 0 {
+			MethodNotAllowed(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", jsonContentType)
+		r = withRequestID(w, r)
+		writeHeader(w, r, http.StatusNotFound, true)
+		writeJSONErrorf(w, r, http.StatusNotFound, true, "no operation is mapped for %s %s", r.Method, r.URL.Path)
+	})
+}
+
+// extractPathParams returns the values of the path parameters of pattern in path, in the order of the pattern
+func extractPathParams(pattern, path string) []string {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	values := make([]string, 0)
+	for i, segment := range patternSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") && i < len(pathSegments) {
+			values = append(values, pathSegments[i])
+		}
+	}
+	return values
+}
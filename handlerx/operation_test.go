@@ -0,0 +1,85 @@
+package handlerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterOperation(t *testing.T) {
+	setupTestMapping()
+	defer setupTestMapping()
+	assert.NoError(t, RegisterOperation("get", "/todos/search", "searchTodos", "{id}", nil))
+	assert.NoError(t, RegisterOperation(http.MethodGet, "/users/{userId}/todos/{id}", "userTodo", "{id,text}", StringMap{"userId": "ID!", "id": "ID!"}))
+
+	tests := []struct {
+		Name      string
+		Method    string
+		Path      string
+		Status    int
+		Operation string
+		Params    map[string]string
+		Body      string
+	}{
+		{Name: "static route", Method: http.MethodGet, Path: "/todos", Status: http.StatusOK, Operation: "todos"},
+		{Name: "path param", Method: http.MethodGet, Path: "/todos/1", Status: http.StatusOK, Operation: "todo", Params: map[string]string{"id": "1"}},
+		{Name: "static preferred", Method: http.MethodGet, Path: "/todos/search", Status: http.StatusOK, Operation: "searchTodos"},
+		{Name: "several path params", Method: http.MethodGet, Path: "/users/u1/todos/2", Status: http.StatusOK, Operation: "userTodo", Params: map[string]string{"userId": "u1", "id": "2"}},
+		{Name: "method not allowed", Method: http.MethodDelete, Path: "/todos/1", Status: http.StatusMethodNotAllowed, Body: `{"code":405,"message":"method DELETE is not allowed, allowed methods: GET, HEAD, PATCH, PUT","data":null}`},
+		{Name: "not found", Method: http.MethodGet, Path: "/unknown", Status: http.StatusNotFound, Body: `{"code":404,"message":"no operation is mapped for GET /unknown","data":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			var operation string
+			var params map[string]string
+			h := OperationHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				operation, _ = restOperationName(r)
+				rctx := chi.RouteContext(r.Context())
+				params = make(map[string]string)
+				for i, k := range rctx.URLParams.Keys {
+					params[k] = rctx.URLParams.Values[i]
+				}
+			}))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tt.Method, tt.Path, nil))
+
+			assert.Equal(t, tt.Status, w.Code)
+			assert.Equal(t, tt.Operation, operation)
+			if tt.Operation != "" {
+				if tt.Params == nil {
+					tt.Params = map[string]string{}
+				}
+				assert.Equal(t, tt.Params, params)
+			}
+			assert.Equal(t, tt.Body, w.Body.String())
+		})
+	}
+	assert.Equal(t, "{id,text}", graphOperation2RESTSelection["userTodo"])
+}
+
+func TestRegisterOperationArguments(t *testing.T) {
+	setupTestMapping()
+	defer setupTestMapping()
+
+	assert.EqualError(t, RegisterOperation(http.MethodGet, "/tasks", "tasks", "", nil), "handlerx: unknown operation tasks, its selection is required")
+	assert.EqualError(t, RegisterOperation(http.MethodGet, "/todos/{id}/user", "todoUser", "{id}", nil), "handlerx: path parameter id of /todos/{id}/user is not an argument of todoUser")
+	assert.EqualError(t, RegisterOperation(http.MethodPut, "/todos/{todoId}/text", "updateTodo", "", nil), "handlerx: path parameter todoId of /todos/{todoId}/text is not an argument of updateTodo")
+	_, ok := restURL2GraphOperation["GET:/todos/{id}/user"]
+	assert.False(t, ok)
+
+	// the argument types of a registered operation are kept, the id of its input argument is a path parameter
+	assert.NoError(t, RegisterOperation(http.MethodPut, "/todos/{id}/text", "updateTodo", "", nil))
+	assert.NoError(t, RegisterOperation(http.MethodGet, "/todos/{id}/user", "todoUser", "{id}", StringMap{"id": "ID!"}))
+
+	r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos/1/user", nil), "/todos/{id}/user", "1")
+	query, err := convertHTTPRequestToGraphQLQuery(r, &graphql.RawParams{}, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(query, "query { todoUser("), query)
+	assert.Contains(t, query, `id:"1"`)
+}
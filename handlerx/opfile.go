@@ -0,0 +1,127 @@
+package handlerx
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// operationFile is a GraphQL operation loaded from a file, see RegisterOperationFromFile
+type operationFile struct {
+	opName  string
+	path    string
+	modTime time.Time
+}
+
+var (
+	operationFilesMu sync.Mutex
+	operationFiles   = make(map[string]*operationFile)
+)
+
+// RegisterOperationFromFile maps the RESTful route of method and pathTemplate to the GraphQL operation opName of
+// the .graphql file filePath, eg. "query todos($limit: Int) { todos(limit: $limit) { id text } }". The operation is
+// executed like a persisted query, with the request parameters passed as the variables it defines. The file is
+// loaded and parsed at registration, its errors are returned. Call it after SetupRESTMapping and SetupPersistedQueries,
+// like RegisterOperation. See WatchOperationFiles to reload the file in development.
+func RegisterOperationFromFile(method, pathTemplate, opName, filePath string) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return fmt.Errorf("handlerx: operation file of %s: %w", opName, err)
+	}
+	doc, err := loadOperationFile(opName, filePath)
+	if err != nil {
+		return err
+	}
+
+	argTypes := make(StringMap)
+	for k, v := range restOperation2Arguments[opName] {
+		argTypes[k] = v
+	}
+	for _, def := range doc.Operations.ForName(opName).VariableDefinitions {
+		argTypes[def.Variable] = def.Type.String()
+	}
+	if err := checkPathParams(pathTemplate, opName, argTypes); err != nil {
+		return err
+	}
+
+	if restOperation2Arguments == nil {
+		restOperation2Arguments = make(ArgTypeMap)
+	}
+	restOperation2Arguments[opName] = argTypes
+	registerRoute(method, pathTemplate, opName)
+	if operationPersistedQueries == nil {
+		operationPersistedQueries = make(StringMap)
+	}
+	operationPersistedQueries[opName] = operationFileHash(filePath)
+
+	operationFilesMu.Lock()
+	defer operationFilesMu.Unlock()
+	operationFiles[filePath] = &operationFile{opName: opName, path: filePath, modTime: info.ModTime()}
+	return nil
+}
+
+// WatchOperationFiles reloads the operation files registered by RegisterOperationFromFile when they're modified,
+// checking them every interval, until the returned func is called. It's meant for development: a file which fails
+// to load keeps the operation loaded before, and variables added to the operation need a restart to be bound.
+func WatchOperationFiles(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				reloadOperationFiles()
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// reloadOperationFiles reloads the operation files modified since they were loaded
+func reloadOperationFiles() {
+	operationFilesMu.Lock()
+	defer operationFilesMu.Unlock()
+	for _, f := range operationFiles {
+		info, err := os.Stat(f.path)
+		if err != nil || !info.ModTime().After(f.modTime) {
+			continue
+		}
+		f.modTime = info.ModTime()
+		if _, err := loadOperationFile(f.opName, f.path); err != nil {
+			dbgPrintf("operation file %s is not reloaded: %v", f.path, err)
+			continue
+		}
+		dbgPrintf("operation file %s is reloaded", f.path)
+	}
+}
+
+// loadOperationFile parses the operation opName of the file filePath and registers it as a persisted query
+func loadOperationFile(opName, filePath string) (*ast.QueryDocument, error) {
+	b, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("handlerx: operation file of %s: %w", opName, err)
+	}
+	doc, gqlErr := parser.ParseQuery(&ast.Source{Name: filePath, Input: string(b)})
+	if gqlErr != nil {
+		return nil, fmt.Errorf("handlerx: operation file of %s: %w", opName, gqlErr)
+	}
+	if doc.Operations.ForName(opName) == nil {
+		return nil, fmt.Errorf("handlerx: operation file of %s: %s defines no operation named %s", opName, filePath, opName)
+	}
+	RegisterPersistedQuery(operationFileHash(filePath), string(b))
+	return doc, nil
+}
+
+// operationFileHash is the key of the persisted query of the operation file filePath
+func operationFileHash(filePath string) string {
+	return "file:" + filePath
+}
@@ -0,0 +1,78 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterOperationFromFile(t *testing.T) {
+	setupTestMapping()
+	defer setupTestMapping()
+	defer SetupPersistedQueries(StringMap{})
+
+	dir, err := ioutil.TempDir("", "opfile")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	write := func(name, query string) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, ioutil.WriteFile(path, []byte(query), 0644))
+		return path
+	}
+
+	query := "query openTodos($limit: Int) { todos(limit: $limit, state: OPEN) { id text } }"
+	path := write("open.graphql", query)
+	assert.NoError(t, RegisterOperationFromFile(http.MethodGet, "/todos/open", "openTodos", path))
+
+	params := &graphql.RawParams{}
+	r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos/open?limit=5", nil), "/todos/open")
+	_, err = convertHTTPRequestToGraphQLQuery(r, params, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, query, params.Query)
+	assert.Equal(t, "openTodos", params.OperationName)
+	assert.Equal(t, map[string]interface{}{"limit": json.Number("5")}, params.Variables)
+
+	err = RegisterOperationFromFile(http.MethodGet, "/todos/bad", "badTodos", write("bad.graphql", "query badTodos { todos { id "))
+	assert.EqualError(t, err, "handlerx: operation file of badTodos: "+filepath.Join(dir, "bad.graphql")+":1: Expected Name, found <EOF>")
+	err = RegisterOperationFromFile(http.MethodGet, "/todos/other", "otherTodos", path)
+	assert.EqualError(t, err, "handlerx: operation file of otherTodos: "+path+" defines no operation named otherTodos")
+	err = RegisterOperationFromFile(http.MethodGet, "/todos/missing", "missingTodos", filepath.Join(dir, "missing.graphql"))
+	assert.Error(t, err)
+}
+
+func TestWatchOperationFiles(t *testing.T) {
+	setupTestMapping()
+	defer setupTestMapping()
+	defer SetupPersistedQueries(StringMap{})
+
+	dir, err := ioutil.TempDir("", "opfile")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "open.graphql")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("query openTodos { todos { id } }"), 0644))
+	assert.NoError(t, RegisterOperationFromFile(http.MethodGet, "/todos/open", "openTodos", path))
+
+	stop := WatchOperationFiles(time.Millisecond)
+	defer stop()
+
+	reload := func(query string, modTime time.Time) {
+		assert.NoError(t, ioutil.WriteFile(path, []byte(query), 0644))
+		assert.NoError(t, os.Chtimes(path, modTime, modTime))
+		time.Sleep(20 * time.Millisecond)
+	}
+	reload("query openTodos { todos { id text } }", time.Now().Add(time.Second))
+	loaded, _ := persistedQuery(operationFileHash(path))
+	assert.Equal(t, "query openTodos { todos { id text } }", loaded)
+
+	reload("query openTodos { todos {", time.Now().Add(2*time.Second))
+	loaded, _ = persistedQuery(operationFileHash(path))
+	assert.Equal(t, "query openTodos { todos { id text } }", loaded, "a broken file keeps the operation loaded before")
+}
@@ -0,0 +1,174 @@
+package handlerx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// DataTransform reshapes the unwrapped `data` of a RESTful response.
+// It must return data untouched if it doesn't apply.
+type DataTransform func(data json.RawMessage) (json.RawMessage, error)
+
+var dataTransforms []DataTransform
+
+// defaultMaxTransformDepth is the default nesting depth of the `data` walked by the response transforms
+const defaultMaxTransformDepth = 64
+
+var maxTransformDepth = defaultMaxTransformDepth
+
+// ErrTransformDepthExceeded is returned by the response transforms walking `data` nested deeper than
+// the maximum transform depth, it's reported as an internal error.
+var ErrTransformDepthExceeded = errors.New("response data exceeds the maximum transform depth")
+
+// SetMaxTransformDepth sets the maximum nesting depth of the `data` of RESTful responses walked by the
+// transforms reshaping it (eg. omitting nulls and fields filtering), deeper data is reported as 500
+// instead of recursing further. Pass a non-positive n to restore the default 64.
+func SetMaxTransformDepth(n int) {
+	if n <= 0 {
+		n = defaultMaxTransformDepth
+	}
+	maxTransformDepth = n
+}
+
+// checkTransformDepth returns ErrTransformDepthExceeded if data is an object or a list enclosed by depth
+// others, which makes it nested beyond the maximum transform depth
+func checkTransformDepth(data json.RawMessage, depth int) error {
+	if depth >= maxTransformDepth && (data[0] == '{' || data[0] == '[') {
+		return ErrTransformDepthExceeded
+	}
+	return nil
+}
+
+// RegisterDataTransform registers a transform applied to the unwrapped `data` of every RESTful
+// response, transforms are applied in registration order, eg. RegisterDataTransform(ConnectionToOffset).
+// An error of the transform is reported as an internal error.
+func RegisterDataTransform(fn DataTransform) {
+	dataTransforms = append(dataTransforms, fn)
+}
+
+var responseTransforms = make(map[string]DataTransform)
+
+// RegisterResponseTransform registers a transform applied to the unwrapped `data` of RESTful responses
+// of operation opName, eg. to rename fields or drop internal-only keys. It's applied before the
+// transforms registered by RegisterDataTransform.
+// An error of the transform is reported as an internal error.
+func RegisterResponseTransform(opName string, fn DataTransform) {
+	responseTransforms[opName] = fn
+}
+
+// reshapeData applies the transforms of operation opName and the registered data transforms to data
+func reshapeData(opName string, data json.RawMessage) (json.RawMessage, error) {
+	var err error
+	if fn, ok := responseTransforms[opName]; ok {
+		data, err = fn(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, fn := range dataTransforms {
+		data, err = fn(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// transformValues omits the null members and stringifies the large ints of data in a single pass,
+// if they're enabled
+func transformValues(data json.RawMessage) (json.RawMessage, error) {
+	if !omitNulls && !stringifyLargeInts {
+		return data, nil
+	}
+	w := &jsonWalk{}
+	if omitNulls {
+		omitNullWalk(w)
+	}
+	if stringifyLargeInts {
+		stringifyLargeIntWalk(w)
+	}
+	return w.walk(data)
+}
+
+// connection is a Relay-style connection, see https://relay.dev/graphql/connections.htm
+type connection struct {
+	Edges []struct {
+		Node json.RawMessage `json:"node"`
+	} `json:"edges"`
+	PageInfo *struct {
+		HasNextPage     bool   `json:"hasNextPage"`
+		HasPreviousPage bool   `json:"hasPreviousPage"`
+		Page            *int64 `json:"page"`
+		PageSize        *int64 `json:"pageSize"`
+	} `json:"pageInfo"`
+	TotalCount *int64 `json:"totalCount"`
+}
+
+// offsetPage is the REST representation of a connection
+type offsetPage struct {
+	Items           []json.RawMessage `json:"items"`
+	Total           *int64            `json:"total"`
+	Page            *int64            `json:"page"`
+	PageSize        *int64            `json:"pageSize"`
+	HasNextPage     bool              `json:"hasNextPage"`
+	HasPreviousPage bool              `json:"hasPreviousPage"`
+}
+
+// isConnection reports whether data looks like a connection: an object with `edges`,
+// and `pageInfo` or `totalCount`
+func isConnection(data json.RawMessage) bool {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return false
+	}
+
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return false
+	}
+	if _, ok := m["edges"]; !ok {
+		return false
+	}
+	_, hasPageInfo := m["pageInfo"]
+	_, hasTotalCount := m["totalCount"]
+	return hasPageInfo || hasTotalCount
+}
+
+// ConnectionToOffset is a DataTransform flattening a Relay-style connection into
+// {"items", "total", "page", "pageSize", "hasNextPage", "hasPreviousPage"}. `items` are the nodes of
+// `edges`, `total` is `totalCount`, and `page` and `pageSize` are the `page` and `pageSize` members of
+// `pageInfo`, which the schema has to add to the Relay ones: a DataTransform doesn't see the request,
+// and the number of items is not the requested page size on the last page. Members which aren't
+// selected are null. Null `edges` is reported as empty `items`. The cursors are left out, use
+// SetLinkBuilder to link to the next and previous pages.
+// Responses which don't look like a connection pass through untouched.
+func ConnectionToOffset(data json.RawMessage) (json.RawMessage, error) {
+	if !isConnection(data) {
+		return data, nil
+	}
+
+	var conn connection
+	if err := json.Unmarshal(data, &conn); err != nil {
+		return nil, err
+	}
+
+	page := offsetPage{
+		Items: make([]json.RawMessage, 0, len(conn.Edges)),
+		Total: conn.TotalCount,
+	}
+	for _, edge := range conn.Edges {
+		node := edge.Node
+		if len(node) == 0 {
+			node = json.RawMessage("null")
+		}
+		page.Items = append(page.Items, node)
+	}
+	if conn.PageInfo != nil {
+		page.Page = conn.PageInfo.Page
+		page.PageSize = conn.PageInfo.PageSize
+		page.HasNextPage = conn.PageInfo.HasNextPage
+		page.HasPreviousPage = conn.PageInfo.HasPreviousPage
+	}
+	return json.Marshal(page)
+}
@@ -0,0 +1,120 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionToOffset(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Data     string
+		Expected string
+	}{
+		{
+			Name:     "connection",
+			Data:     `{"edges":[{"node":{"id":"1"}},{"node":{"id":"2"}}],"pageInfo":{"hasNextPage":true,"endCursor":"Mg=="},"totalCount":5}`,
+			Expected: `{"items":[{"id":"1"},{"id":"2"}],"total":5,"page":null,"pageSize":null,"hasNextPage":true,"hasPreviousPage":false}`,
+		},
+		{
+			Name:     "last page",
+			Data:     `{"edges":[{"node":{"id":"5"}}],"pageInfo":{"hasPreviousPage":true,"page":3,"pageSize":2},"totalCount":5}`,
+			Expected: `{"items":[{"id":"5"}],"total":5,"page":3,"pageSize":2,"hasNextPage":false,"hasPreviousPage":true}`,
+		},
+		{
+			Name:     "empty edges",
+			Data:     `{"edges":[],"totalCount":0}`,
+			Expected: `{"items":[],"total":0,"page":null,"pageSize":null,"hasNextPage":false,"hasPreviousPage":false}`,
+		},
+		{
+			Name:     "null edges",
+			Data:     `{"edges":null,"pageInfo":{"hasNextPage":false,"page":1,"pageSize":10}}`,
+			Expected: `{"items":[],"total":null,"page":1,"pageSize":10,"hasNextPage":false,"hasPreviousPage":false}`,
+		},
+		{
+			Name:     "not a connection",
+			Data:     `{"edges":[1,2]}`,
+			Expected: `{"edges":[1,2]}`,
+		},
+		{
+			Name:     "list",
+			Data:     `[{"id":"1"}]`,
+			Expected: `[{"id":"1"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			data, err := ConnectionToOffset(json.RawMessage(tt.Data))
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.Expected, string(data))
+		})
+	}
+}
+
+func TestRegisterResponseTransform(t *testing.T) {
+	setupTestMapping()
+	defer delete(responseTransforms, "todos")
+
+	RegisterResponseTransform("todos", func(data json.RawMessage) (json.RawMessage, error) {
+		var todos []map[string]interface{}
+		if err := json.Unmarshal(data, &todos); err != nil {
+			return nil, err
+		}
+		for _, todo := range todos {
+			delete(todo, "secret")
+		}
+		return json.Marshal(todos)
+	})
+
+	serve := func(target string, data string) string {
+		r := chi.NewRouter()
+		handler := func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, r, &graphql.Response{Data: json.RawMessage(data)}, true)
+		}
+		r.Get("/todos", handler)
+		r.Get("/todos/{id}", handler)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w.Body.String()
+	}
+
+	assert.Equal(t, `{"code":0,"data":[{"id":"1"}]}`, serve("/todos", `{"todos":[{"id":"1","secret":"x"}]}`))
+	assert.Equal(t, `{"code":0,"data":{"id":"1","secret":"x"}}`, serve("/todos/1", `{"todo":{"id":"1","secret":"x"}}`))
+	assert.Equal(t, `{"code":500,"message":"unexpected error: unmarshal or write response error","data":null}`, serve("/todos", `{"todos":{"id":"1"}}`))
+}
+
+func TestSetMaxTransformDepth(t *testing.T) {
+	SetMaxTransformDepth(3)
+	defer SetMaxTransformDepth(0)
+	SetOmitNulls(true)
+	defer SetOmitNulls(false)
+
+	tests := []struct {
+		Name     string
+		Data     string
+		Expected string
+	}{
+		{Name: "within depth", Data: `{"todo":{"user":{"tags":["a",null]}}}`, Expected: `{"code":0,"data":{"user":{"tags":["a",null]}}}`},
+		{Name: "scalars at max depth", Data: `{"todo":{"user":{"id":"1","ip":null}}}`, Expected: `{"code":0,"data":{"user":{"id":"1"}}}`},
+		{Name: "beyond depth", Data: `{"todo":{"user":{"tags":[{}]}}}`, Expected: `{"code":500,"message":"unexpected error: unmarshal or write response error","data":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeJSON(w, nil, &graphql.Response{Data: json.RawMessage(tt.Data)}, true)
+			assert.Equal(t, tt.Expected, w.Body.String())
+		})
+	}
+
+	_, _, err := filterFields(json.RawMessage(`[[[[]]]]`), fieldTree{"id": nil})
+	assert.Equal(t, ErrTransformDepthExceeded, err)
+}
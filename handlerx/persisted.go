@@ -0,0 +1,156 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+var (
+	persistedQueriesMu sync.RWMutex
+	persistedQueries   = make(map[string]string)
+
+	// GraphQL Operation => Persisted Query Hash
+	operationPersistedQueries = make(StringMap)
+)
+
+// RegisterPersistedQuery registers a GraphQL query by its hash, it's usually called by generated code at init
+func RegisterPersistedQuery(hash, query string) {
+	persistedQueriesMu.Lock()
+	defer persistedQueriesMu.Unlock()
+	persistedQueries[hash] = query
+}
+
+// SetupPersistedQueries makes RESTful requests of the operations executed as the persisted queries of
+// the given hashes, with the request parameters passed as variables, instead of inlined queries.
+func SetupPersistedQueries(operations StringMap) {
+	operationPersistedQueries = operations
+}
+
+// persistedQuery returns the persisted query registered by hash
+func persistedQuery(hash string) (string, bool) {
+	persistedQueriesMu.RLock()
+	defer persistedQueriesMu.RUnlock()
+	query, ok := persistedQueries[hash]
+	return query, ok
+}
+
+// persistedQueryError is returned when the persisted query of an operation is not registered
+type persistedQueryError struct {
+	operation string
+	hash      string
+}
+
+func (e *persistedQueryError) Error() string {
+	return fmt.Sprintf("mapping: persisted query %s of %s not found", e.hash, e.operation)
+}
+
+// convertToPersistedQuery sets the persisted query of an operation and its variables to params
+func convertToPersistedQuery(params *graphql.RawParams, operationName string, hash string, argTypes StringMap, queryParams map[string]interface{}) error {
+	query, ok := persistedQuery(hash)
+	if !ok {
+		return &persistedQueryError{operation: operationName, hash: hash}
+	}
+	return bindPersistedQuery(params, operationName, query, argTypes, queryParams)
+}
+
+// bindPersistedQuery sets query and the request parameters as its variables to params
+func bindPersistedQuery(params *graphql.RawParams, operationName string, query string, argTypes StringMap, queryParams map[string]interface{}) error {
+	uploads := params.Variables
+	variables := make(map[string]interface{})
+	for k, v := range queryParams {
+		argType, ok := argTypes[k]
+		if !ok {
+			continue
+		}
+		value, err := coerceVariable(argType, k, v, uploads)
+		if err != nil {
+			return err
+		}
+		variables[k] = value
+	}
+
+	params.Query = query
+	params.OperationName = operationName
+	params.Variables = variables
+	return nil
+}
+
+// coerceVariable coerces a request parameter to a variable value of the argument type,
+// like formatInputsToGraphQL does for inlined arguments
+func coerceVariable(argType string, k string, v interface{}, uploads map[string]interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	isArray, underlayingType := getUnderlayingArgType(argType)
+	if !isArray {
+		return coerceVariableValue(underlayingType, k, v, uploads)
+	}
+
+	vars, err := getSliceInterface(v)
+	if err != nil {
+		return nil, err
+	}
+	vals := make([]interface{}, 0, len(vars))
+	for _, vv := range vars {
+		val, err := coerceVariableValue(underlayingType, k, vv, uploads)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, val)
+	}
+	return vals, nil
+}
+
+func coerceVariableValue(underlayingType string, k string, v interface{}, uploads map[string]interface{}) (interface{}, error) {
+	switch vv := v.(type) {
+	case uploadVariable:
+		return uploads[string(vv)], nil
+	case map[string]interface{}:
+		return coerceInputVariable(underlayingType, vv, uploads)
+	case string:
+		switch underlayingType {
+		case "Int", "Float":
+			s, err := coerceArgValue(underlayingType, k, vv)
+			if err != nil {
+				return nil, err
+			}
+			return json.Number(s), nil
+		case "Boolean":
+			s, err := coerceArgValue(underlayingType, k, vv)
+			if err != nil {
+				return nil, err
+			}
+			return strconv.ParseBool(s)
+		}
+		if typeName2TypeKinds[underlayingType] == "ENUM" {
+			return coerceArgValue(underlayingType, k, vv)
+		}
+		return vv, nil
+	default:
+		return v, nil // decoded from JSON body, eg. json.Number or bool
+	}
+}
+
+func coerceInputVariable(inputType string, queryParams map[string]interface{}, uploads map[string]interface{}) (interface{}, error) {
+	inputTypes, ok := inputType2FieldDefinitions[inputType]
+	if !ok {
+		return queryParams, nil
+	}
+	input := make(map[string]interface{})
+	for k, v := range queryParams {
+		fieldType, ok := inputTypes[k]
+		if !ok {
+			continue
+		}
+		value, err := coerceVariable(fieldType, k, v, uploads)
+		if err != nil {
+			return nil, err
+		}
+		input[k] = value
+	}
+	return input, nil
+}
@@ -0,0 +1,87 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetupPersistedQueries(t *testing.T) {
+	setupTestMapping()
+	RegisterPersistedQuery("h-todos", "query todos($ids:[ID!],$limit:Int,$done:Boolean){todos(ids:$ids,limit:$limit,done:$done){id,text}}")
+	RegisterPersistedQuery("h-createTodo", "mutation createTodo($input:NewTodoInput!){createTodo(input:$input){id,text}}")
+	SetupPersistedQueries(StringMap{"todos": "h-todos", "createTodo": "h-createTodo", "todo": "h-missing"})
+	defer SetupPersistedQueries(make(StringMap))
+
+	tests := []struct {
+		Name      string
+		Method    string
+		URL       string
+		Pattern   string
+		Body      string
+		Variables map[string]interface{}
+		Code      int
+	}{
+		{
+			Name:    "query parameters",
+			Method:  http.MethodGet,
+			URL:     "/todos?ids=1&ids=2&limit=10&done=true",
+			Pattern: "/todos",
+			Variables: map[string]interface{}{
+				"ids":   []interface{}{"1", "2"},
+				"limit": json.Number("10"),
+				"done":  true,
+			},
+		},
+		{
+			Name:    "json body",
+			Method:  http.MethodPost,
+			URL:     "/todos",
+			Pattern: "/todos",
+			Body:    `{"text":"x","userId":"1","tags":[{"name":"a"}]}`,
+			Variables: map[string]interface{}{
+				"input": map[string]interface{}{"text": "x", "userId": "1", "tags": []interface{}{map[string]interface{}{"name": "a"}}},
+			},
+		},
+		{
+			Name:    "invalid int",
+			Method:  http.MethodGet,
+			URL:     "/todos?limit=x",
+			Pattern: "/todos",
+			Code:    http.StatusBadRequest,
+		},
+		{
+			Name:    "unregistered hash",
+			Method:  http.MethodGet,
+			URL:     "/todos/42",
+			Pattern: "/todos/{id}",
+			Code:    http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			params := []string{}
+			if strings.Contains(tt.Pattern, "{id}") {
+				params = append(params, "42")
+			}
+			r := WithRoute(httptest.NewRequest(tt.Method, tt.URL, strings.NewReader(tt.Body)), tt.Pattern, params...)
+			rawParams := &graphql.RawParams{}
+			query, err := convertHTTPRequestToGraphQLQuery(r, rawParams, []byte(tt.Body))
+			if tt.Code != 0 {
+				assert.Error(t, err)
+				assert.Equal(t, tt.Code, convertErrorCode(err))
+				return
+			}
+			assert.NoError(t, err)
+			persisted, _ := persistedQuery(operationPersistedQueries[rawParams.OperationName])
+			assert.Equal(t, persisted, query)
+			assert.Equal(t, tt.Variables, rawParams.Variables)
+		})
+	}
+}
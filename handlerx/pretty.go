@@ -0,0 +1,103 @@
+package handlerx
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+const prettyJSONIndent = "  "
+
+var prettyJSON bool
+
+// SetPrettyJSON indents the JSON responses written by writeJSON with two spaces, eg. for local debugging.
+// It only changes the formatting, streaming responses are indented while they are written. Default false.
+func SetPrettyJSON(pretty bool) {
+	prettyJSON = pretty
+}
+
+// marshalJSON marshals v with the configured codec, indented if pretty JSON is enabled
+func marshalJSON(v interface{}) ([]byte, error) {
+	b, err := jsonCodec.Marshal(v)
+	if err != nil || !prettyJSON {
+		return b, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, b, "", prettyJSONIndent); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// indentWriter indents the compact JSON written to it like json.Indent, without buffering the document
+type indentWriter struct {
+	w        io.Writer
+	depth    int
+	inString bool
+	escaped  bool
+	opened   bool // the last byte opened an object or array, its newline is delayed in case it's empty
+	buf      []byte
+}
+
+func (w *indentWriter) Write(p []byte) (int, error) {
+	w.buf = w.buf[:0]
+	for _, c := range p {
+		if w.inString {
+			w.buf = append(w.buf, c)
+			if w.escaped {
+				w.escaped = false
+			} else if c == '\\' {
+				w.escaped = true
+			} else if c == '"' {
+				w.inString = false
+			}
+			continue
+		}
+
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			continue
+		}
+		if w.opened {
+			w.opened = false
+			if c == '}' || c == ']' {
+				w.depth--
+				w.buf = append(w.buf, c)
+				continue
+			}
+			w.newline()
+		}
+
+		switch c {
+		case '{', '[':
+			w.buf = append(w.buf, c)
+			w.depth++
+			w.opened = true
+		case '}', ']':
+			w.depth--
+			w.newline()
+			w.buf = append(w.buf, c)
+		case ',':
+			w.buf = append(w.buf, c)
+			w.newline()
+		case ':':
+			w.buf = append(w.buf, c, ' ')
+		case '"':
+			w.buf = append(w.buf, c)
+			w.inString = true
+		default:
+			w.buf = append(w.buf, c)
+		}
+	}
+
+	if _, err := w.w.Write(w.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *indentWriter) newline() {
+	w.buf = append(w.buf, '\n')
+	for i := 0; i < w.depth; i++ {
+		w.buf = append(w.buf, prettyJSONIndent...)
+	}
+}
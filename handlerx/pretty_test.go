@@ -0,0 +1,53 @@
+package handlerx
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndentWriter(t *testing.T) {
+	tests := []struct {
+		Name string
+		JSON string
+	}{
+		{Name: "object", JSON: `{"code":0,"data":{"id":"1","tags":["a","b"]}}`},
+		{Name: "empty members", JSON: `{"a":{},"b":[],"c":[{}]}`},
+		{Name: "escaped strings", JSON: `{"text":"a \"{[,:]}\" \\","n":null}`},
+		{Name: "scalar", JSON: `42`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			var expected bytes.Buffer
+			assert.NoError(t, json.Indent(&expected, []byte(tt.JSON), "", "  "))
+
+			// written byte by byte, like a streaming response split at any position
+			var buf bytes.Buffer
+			w := &indentWriter{w: &buf}
+			for i := range tt.JSON {
+				_, err := w.Write([]byte{tt.JSON[i]})
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, expected.String(), buf.String())
+		})
+	}
+}
+
+func TestSetPrettyJSON(t *testing.T) {
+	SetPrettyJSON(true)
+	defer SetPrettyJSON(false)
+	defer SetStreamingJSON(false)
+
+	expected := "{\n  \"code\": 0,\n  \"data\": {\n    \"id\": \"1\"\n  }\n}"
+	for _, streaming := range []bool{false, true} {
+		SetStreamingJSON(streaming)
+		w := httptest.NewRecorder()
+		writeJSON(w, nil, &graphql.Response{Data: json.RawMessage(`{"todo":{"id":"1"}}`)}, true)
+		assert.Equal(t, expected, w.Body.String())
+	}
+}
@@ -0,0 +1,93 @@
+package handlerx
+
+import (
+	"net/http"
+)
+
+// ErrorFormat is the format of the error responses of RESTful requests
+type ErrorFormat int
+
+const (
+	// EnvelopeErrors reports errors in the {code,message,data} envelope (default)
+	EnvelopeErrors ErrorFormat = iota
+	// ProblemJSON reports errors as RFC 7807 problem details, with the Content-Type application/problem+json
+	// and the HTTP status of their code, see https://datatracker.ietf.org/doc/html/rfc7807
+	ProblemJSON
+)
+
+var errorFormat = EnvelopeErrors
+
+// SetErrorFormat sets the format of the error responses of RESTful requests, successful and partially
+// successful responses keep the envelope. The default is EnvelopeErrors.
+func SetErrorFormat(format ErrorFormat) {
+	errorFormat = format
+}
+
+// ProblemDetails is an RFC 7807 problem details object, extended with the envelope `code`, the
+// `fields` and `errors` of the error, and the `request_id` and `error_id` if they're enabled.
+type ProblemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	Code      int               `json:"code"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	Errors    []RESTError       `json:"errors,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+	ErrorID   string            `json:"error_id,omitempty"`
+}
+
+// ProblemMapper returns the `type` and `instance` URIs of the problem details of an error code,
+// empty values keep the defaults.
+type ProblemMapper func(req *http.Request, code int) (problemType, instance string)
+
+var problemMapper ProblemMapper
+
+// SetProblemMapper sets the mapping of error codes to the `type` and `instance` of problem details,
+// eg. to documentation pages of the errors. By default `type` is "about:blank" and `instance` is the
+// request path. Pass nil to restore the defaults.
+func SetProblemMapper(fn ProblemMapper) {
+	problemMapper = fn
+}
+
+// writeProblem writes the error response as problem details
+func writeProblem(w http.ResponseWriter, req *http.Request, response *RESTResponse) {
+	status := errorResponseStatus(response)
+	problem := &ProblemDetails{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    response.Message,
+		Code:      response.Code,
+		Fields:    response.Fields,
+		Errors:    response.Errors,
+		RequestID: response.RequestID,
+		ErrorID:   response.ErrorID,
+	}
+	if req != nil {
+		problem.Instance = req.URL.Path
+	}
+	if problemMapper != nil {
+		problemType, instance := problemMapper(req, response.Code)
+		if problemType != "" {
+			problem.Type = problemType
+		}
+		if instance != "" {
+			problem.Instance = instance
+		}
+	}
+
+	b, err := marshalJSON(problem)
+	if err != nil {
+		panic(err)
+	}
+	recordCode(req, response.Code)
+	setCacheControl(w, req, false)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	if _, err := w.Write(b); err != nil {
+		panic(err)
+	}
+}
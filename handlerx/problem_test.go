@@ -0,0 +1,73 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestSetErrorFormat(t *testing.T) {
+	setupTestMapping()
+	SetErrorFormat(ProblemJSON)
+	defer SetErrorFormat(EnvelopeErrors)
+	defer SetProblemMapper(nil)
+
+	tests := []struct {
+		Name        string
+		Mapper      ProblemMapper
+		Response    *graphql.Response
+		Status      int
+		ContentType string
+		Body        string
+	}{
+		{
+			Name:        "success keeps the envelope",
+			Response:    &graphql.Response{Data: json.RawMessage(`{"todo":{"id":"1"}}`)},
+			Status:      http.StatusOK,
+			ContentType: "application/json; charset=utf-8",
+			Body:        `{"code":0,"data":{"id":"1"}}`,
+		},
+		{
+			Name:        "problem details",
+			Response:    &graphql.Response{Errors: gqlerror.List{{Message: "todo not found", Extensions: map[string]interface{}{"code": 404}}}},
+			Status:      http.StatusNotFound,
+			ContentType: "application/problem+json",
+			Body:        `{"type":"about:blank","title":"Not Found","status":404,"detail":"todo not found","instance":"/todos/1","code":404}`,
+		},
+		{
+			Name: "mapped type",
+			Mapper: func(req *http.Request, code int) (string, string) {
+				return "https://example.com/errors/" + http.StatusText(code), ""
+			},
+			Response:    &graphql.Response{Errors: gqlerror.List{{Message: "denied", Extensions: map[string]interface{}{"code": 403}}}},
+			Status:      http.StatusForbidden,
+			ContentType: "application/problem+json",
+			Body:        `{"type":"https://example.com/errors/Forbidden","title":"Forbidden","status":403,"detail":"denied","instance":"/todos/1","code":403}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetProblemMapper(tt.Mapper)
+			r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos/1", nil), "/todos/{id}", "1")
+			w := httptest.NewRecorder()
+			writeJSON(w, r, tt.Response, true)
+			assert.Equal(t, tt.Status, w.Code)
+			assert.Equal(t, tt.ContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.Body, w.Body.String())
+		})
+	}
+
+	// errors of converting requests are reshaped too, with the status of their code
+	r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos/x", nil), "/todos/{id}", "x")
+	w := httptest.NewRecorder()
+	writeHeader(w, r, http.StatusBadRequest, true)
+	writeJSONError(w, r, http.StatusUnprocessableEntity, true, "invalid id")
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":422`)
+}
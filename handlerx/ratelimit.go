@@ -0,0 +1,139 @@
+package handlerx
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxRateLimitBuckets bounds the buckets kept per operation, idle full buckets are dropped beyond it
+const maxRateLimitBuckets = 10000
+
+// rateLimiter is a token bucket limiter per client key
+type rateLimiter struct {
+	rps   float64
+	burst int
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// allow takes a token of the bucket of key, or returns how long to wait for the next one
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxRateLimitBuckets {
+			l.prune(now)
+		}
+		b = &tokenBucket{tokens: float64(l.burst), last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(l.burst), b.tokens+now.Sub(b.last).Seconds()*l.rps)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rps * float64(time.Second))
+}
+
+// prune drops the buckets refilled up to the burst, they're the same as new ones
+func (l *rateLimiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rps >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+var (
+	rateLimitersMu sync.RWMutex
+	rateLimiters   = make(map[string]*rateLimiter)
+
+	rateLimitKey = remoteIPKey
+)
+
+// SetRateLimit limits the RESTful requests of operation opName to rps requests per second for each
+// client, with bursts of up to burst requests. Throttled requests are rejected with 429 and a
+// Retry-After header, without executing the operation. Pass rps <= 0 to remove the limit.
+func SetRateLimit(opName string, rps float64, burst int) {
+	rateLimitersMu.Lock()
+	defer rateLimitersMu.Unlock()
+
+	if rps <= 0 {
+		delete(rateLimiters, opName)
+		return
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	rateLimiters[opName] = &rateLimiter{rps: rps, burst: burst, buckets: make(map[string]*tokenBucket)}
+}
+
+// SetRateLimitKey sets how clients are identified by the rate limiter, the default is the remote IP.
+// Pass nil to restore the default.
+func SetRateLimitKey(extractor func(*http.Request) string) {
+	if extractor == nil {
+		extractor = remoteIPKey
+	}
+	rateLimitKey = extractor
+}
+
+// HeaderRateLimitKey returns a rate limit key extractor using the header name (eg. an API key),
+// requests without the header are identified by the remote IP.
+func HeaderRateLimitKey(name string) func(*http.Request) string {
+	return func(r *http.Request) string {
+		if v := r.Header.Get(name); v != "" {
+			return name + ":" + v
+		}
+		return remoteIPKey(r)
+	}
+}
+
+func remoteIPKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// allowRequest applies the rate limit of the operation of r, and writes a 429 response if it's throttled
+func allowRequest(w http.ResponseWriter, r *http.Request) bool {
+	opName, ok := restOperationName(r)
+	if !ok {
+		return true
+	}
+	rateLimitersMu.RLock()
+	limiter, ok := rateLimiters[opName]
+	rateLimitersMu.RUnlock()
+	if !ok {
+		return true
+	}
+
+	allowed, wait := limiter.allow(rateLimitKey(r), time.Now())
+	if allowed {
+		return true
+	}
+
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	writeHeader(w, r, http.StatusTooManyRequests, true)
+	writeJSONErrorWithExtensions(w, r, http.StatusTooManyRequests, true,
+		fmt.Sprintf("rate limit of %s exceeded, retry after %d seconds", opName, retryAfter),
+		map[string]interface{}{"reason": "RATE_LIMITED", "retryable": true})
+	return false
+}
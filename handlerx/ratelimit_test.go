@@ -0,0 +1,73 @@
+package handlerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := &rateLimiter{rps: 2, burst: 2, buckets: make(map[string]*tokenBucket)}
+	now := time.Unix(0, 0)
+
+	tests := []struct {
+		Name    string
+		Key     string
+		Elapsed time.Duration
+		Allowed bool
+		Wait    time.Duration
+	}{
+		{Name: "burst 1", Key: "a", Allowed: true},
+		{Name: "burst 2", Key: "a", Allowed: true},
+		{Name: "throttled", Key: "a", Wait: 500 * time.Millisecond},
+		{Name: "another client", Key: "b", Allowed: true},
+		{Name: "refilled", Key: "a", Elapsed: 500 * time.Millisecond, Allowed: true},
+		{Name: "throttled again", Key: "a", Elapsed: 250 * time.Millisecond, Wait: 250 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			now = now.Add(tt.Elapsed)
+			allowed, wait := l.allow(tt.Key, now)
+			assert.Equal(t, tt.Allowed, allowed)
+			assert.Equal(t, tt.Wait, wait)
+		})
+	}
+}
+
+func TestSetRateLimit(t *testing.T) {
+	setupTestMapping()
+	SetRateLimit("todos", 0.5, 1)
+	defer SetRateLimit("todos", 0, 0)
+	SetRateLimitKey(HeaderRateLimitKey("X-API-Key"))
+	defer SetRateLimitKey(nil)
+
+	newRequest := func(apiKey string) *http.Request {
+		r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos", nil), "/todos")
+		r.Header.Set("X-API-Key", apiKey)
+		return r
+	}
+
+	assert.True(t, allowRequest(httptest.NewRecorder(), newRequest("k1")))
+	assert.True(t, allowRequest(httptest.NewRecorder(), newRequest("k2")))
+
+	w := httptest.NewRecorder()
+	assert.False(t, allowRequest(w, newRequest("k1")))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), `"code":429`)
+
+	SetVerboseErrors(true)
+	defer SetVerboseErrors(false)
+	w = httptest.NewRecorder()
+	assert.False(t, allowRequest(w, newRequest("k1")))
+	assert.Contains(t, w.Body.String(), `"extensions":{"code":429,"reason":"RATE_LIMITED","retryable":true}`)
+
+	// operations without a limit
+	r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos/1", nil), "/todos/{id}", "1")
+	assert.True(t, allowRequest(httptest.NewRecorder(), r))
+	assert.True(t, allowRequest(httptest.NewRecorder(), r))
+}
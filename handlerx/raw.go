@@ -0,0 +1,54 @@
+package handlerx
+
+import (
+	"net/http"
+)
+
+var rawOutputOperations = make(map[string]bool)
+
+// SetRawOutput makes the RESTful responses of operation opName the bare `data`, without the
+// {code,message,data} envelope, eg. for webhooks with a fixed payload. Errors are reported with
+// the real HTTP status and the error message as a plain text body.
+func SetRawOutput(opName string, raw bool) {
+	if raw {
+		rawOutputOperations[opName] = true
+	} else {
+		delete(rawOutputOperations, opName)
+	}
+}
+
+// isRawOutput reports whether the response to req is written in raw mode
+func isRawOutput(req *http.Request) bool {
+	if len(rawOutputOperations) == 0 {
+		return false
+	}
+	opName, ok := restOperationName(req)
+	return ok && rawOutputOperations[opName]
+}
+
+// writeRawOutput writes response in raw mode
+func writeRawOutput(w http.ResponseWriter, req *http.Request, response *RESTResponse, success bool) {
+	recordCode(req, response.Code)
+	setCacheControl(w, req, success)
+
+	var b []byte
+	if success {
+		b = response.Data
+		if len(b) == 0 {
+			b = []byte("null")
+		}
+		w.Header().Set("Content-Type", jsonContentType)
+		if status, ok := successStatus(req, response); ok {
+			w.WriteHeader(status)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+	} else {
+		b = []byte(response.Message)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(errorResponseStatus(response))
+	}
+	if _, err := w.Write(b); err != nil {
+		panic(err)
+	}
+}
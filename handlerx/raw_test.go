@@ -0,0 +1,68 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestSetRawOutput(t *testing.T) {
+	setupTestMapping()
+	SetRawOutput("todo", true)
+	defer SetRawOutput("todo", false)
+
+	tests := []struct {
+		Name        string
+		Pattern     string
+		Response    *graphql.Response
+		Status      int
+		ContentType string
+		Body        string
+	}{
+		{
+			Name:        "raw data",
+			Pattern:     "/todos/{id}",
+			Response:    &graphql.Response{Data: json.RawMessage(`{"todo":{"id":"1","text":"x"}}`)},
+			Status:      http.StatusOK,
+			ContentType: "application/json; charset=utf-8",
+			Body:        `{"id":"1","text":"x"}`,
+		},
+		{
+			Name:        "raw error",
+			Pattern:     "/todos/{id}",
+			Response:    &graphql.Response{Errors: gqlerror.List{{Message: "not found", Extensions: map[string]interface{}{"code": 404}}}},
+			Status:      http.StatusNotFound,
+			ContentType: "text/plain; charset=utf-8",
+			Body:        "not found",
+		},
+		{
+			Name:        "enveloped by default",
+			Pattern:     "/todos",
+			Response:    &graphql.Response{Data: json.RawMessage(`{"todos":[]}`)},
+			Status:      http.StatusOK,
+			ContentType: "application/json; charset=utf-8",
+			Body:        `{"code":0,"data":[]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos/1", nil), tt.Pattern, "1")
+			w := httptest.NewRecorder()
+			w.Header().Set("Content-Type", "application/json")
+			if len(tt.Response.Errors) > 0 {
+				writeHeader(w, r, http.StatusBadRequest, true) // skipped in raw mode
+			}
+			writeJSON(w, r, tt.Response, true)
+
+			assert.Equal(t, tt.Status, w.Code)
+			assert.Equal(t, tt.ContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.Body, w.Body.String())
+		})
+	}
+}
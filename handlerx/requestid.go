@@ -0,0 +1,56 @@
+package handlerx
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// RequestIDHeader is the header carrying the request ID, it's echoed back in responses
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+var requestIDInBody bool
+
+// SetRequestIDInBody enables the `request_id` field of RESTful responses
+func SetRequestIDInBody(enabled bool) {
+	requestIDInBody = enabled
+}
+
+// RequestIDFromContext returns the request ID of the current request, if any
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// withRequestID reads the request ID from the request header (or generates a new one),
+// echoes it in the response header and stores it in the request context.
+func withRequestID(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := r.Header.Get(RequestIDHeader)
+	if id == "" {
+		id = newRequestID()
+	}
+	w.Header().Set(RequestIDHeader, id)
+	return r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+}
+
+// bodyRequestID returns the request ID to report in the response body
+func bodyRequestID(req *http.Request) string {
+	if !requestIDInBody || req == nil {
+		return ""
+	}
+	return RequestIDFromContext(req.Context())
+}
+
+// newRequestID generates a random UUID (version 4)
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,71 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRequestID(t *testing.T) {
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	tests := []struct {
+		Name     string
+		Header   string
+		Expected string
+	}{
+		{Name: "echoed", Header: "req-42", Expected: "req-42"},
+		{Name: "generated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/todos", nil)
+			if tt.Header != "" {
+				r.Header.Set(RequestIDHeader, tt.Header)
+			}
+			w := httptest.NewRecorder()
+			r = withRequestID(w, r)
+
+			id := RequestIDFromContext(r.Context())
+			if tt.Expected != "" {
+				assert.Equal(t, tt.Expected, id)
+			} else {
+				assert.Regexp(t, uuid, id)
+			}
+			assert.Equal(t, id, w.Header().Get(RequestIDHeader))
+		})
+	}
+
+	assert.NotEqual(t, newRequestID(), newRequestID())
+}
+
+func TestSetRequestIDInBody(t *testing.T) {
+	defer SetRequestIDInBody(false)
+
+	tests := []struct {
+		Name     string
+		Enabled  bool
+		Expected string
+	}{
+		{Name: "disabled", Expected: `{"code":0,"data":{"id":"1"}}`},
+		{Name: "enabled", Enabled: true, Expected: `{"code":0,"data":{"id":"1"},"request_id":"req-42"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetRequestIDInBody(tt.Enabled)
+			r := httptest.NewRequest(http.MethodGet, "/todos", nil)
+			r.Header.Set(RequestIDHeader, "req-42")
+			w := httptest.NewRecorder()
+			r = withRequestID(w, r)
+			writeJSON(w, r, &graphql.Response{Data: json.RawMessage(`{"todo":{"id":"1"}}`)}, true)
+			assert.Equal(t, tt.Expected, w.Body.String())
+		})
+	}
+}
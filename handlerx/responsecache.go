@@ -0,0 +1,217 @@
+package handlerx
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse is a response cached by a ResponseCache
+type CachedResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// ResponseCache caches the responses of RESTful GET requests, eg. in Redis to share them between instances
+type ResponseCache interface {
+	// Get returns the response cached by key
+	Get(key string) (*CachedResponse, bool)
+	// Set caches the response of key for ttl
+	Set(key string, resp *CachedResponse, ttl time.Duration)
+}
+
+var (
+	responseCache     ResponseCache
+	responseCacheTTLs = make(map[string]time.Duration)
+	responseCacheKey  = DefaultResponseCacheKey
+)
+
+// SetResponseCache enables caching the successful responses of RESTful GET requests in store, for the
+// operations with a TTL set by SetResponseCacheTTL. A cached response is written without executing the
+// operation, with an X-Cache: HIT header. Responses with errors (even with a 200 status) and responses
+// of subscriptions are not cached, and requests are not cached while the request ID is reported in the
+// body (SetRequestIDInBody) or the debug echo is requested. Pass a nil store to disable it.
+func SetResponseCache(store ResponseCache) {
+	responseCache = store
+}
+
+// SetResponseCacheTTL sets how long the responses of operation opName are cached, pass ttl <= 0 to stop caching them
+func SetResponseCacheTTL(opName string, ttl time.Duration) {
+	if ttl <= 0 {
+		delete(responseCacheTTLs, opName)
+		return
+	}
+	responseCacheTTLs[opName] = ttl
+}
+
+// SetResponseCacheKey sets the func returning the cache key of a request, the request bypasses the cache
+// if it returns false, eg. if the response varies by user. The negotiated content type of the response
+// and the persisted query hash of the request are always part of the key.
+// Pass nil to restore DefaultResponseCacheKey.
+func SetResponseCacheKey(fn func(r *http.Request) (string, bool)) {
+	if fn == nil {
+		fn = DefaultResponseCacheKey
+	}
+	responseCacheKey = fn
+}
+
+// DefaultResponseCacheKey keys r by its path and its sorted query parameters,
+// authenticated requests (with an Authorization or a Cookie header) bypass the cache.
+func DefaultResponseCacheKey(r *http.Request) (string, bool) {
+	if r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != "" {
+		return "", false
+	}
+	return http.MethodGet + " " + r.URL.Path + "?" + r.URL.Query().Encode(), true
+}
+
+// NewMemoryResponseCache returns a ResponseCache keeping the responses in memory
+func NewMemoryResponseCache() ResponseCache {
+	return &memoryResponseCache{entries: make(map[string]memoryResponseEntry)}
+}
+
+type memoryResponseEntry struct {
+	resp    *CachedResponse
+	expires time.Time
+}
+
+type memoryResponseCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryResponseEntry
+}
+
+func (c *memoryResponseCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.resp, true
+}
+
+func (c *memoryResponseCache) Set(key string, resp *CachedResponse, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = memoryResponseEntry{resp: resp, expires: now.Add(ttl)}
+}
+
+// withResponseCache writes the cached response of r, or returns a writer caching the response. It returns
+// false if the response is already written, otherwise the returned func must be called once the response is written.
+func withResponseCache(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, func(), bool) {
+	store := responseCache
+	if store == nil || (r.Method != http.MethodGet && r.Method != http.MethodHead) || DryRunFromContext(r.Context()) {
+		return w, func() {}, true
+	}
+	opName, ok := restOperationName(r)
+	ttl := responseCacheTTLs[opName]
+	if !ok || ttl <= 0 || isSubscription(opName) || !isCacheableRequest(r) {
+		return w, func() {}, true
+	}
+	key, ok := responseCacheKey(r)
+	if !ok {
+		return w, func() {}, true
+	}
+	// the response varies by the negotiated content type and the persisted query, see SetResponseCacheKey
+	key += " " + responseContentType(r)
+	if hash := r.Header.Get(PersistedQueryHashHeader); hash != "" {
+		key += " " + strings.ToLower(hash)
+	}
+
+	if resp, ok := store.Get(key); ok {
+		writeCachedResponse(w, r, resp)
+		return w, nil, false
+	}
+
+	w.Header().Set("X-Cache", "MISS")
+	rec := &responseCacheRecorder{ResponseWriter: w, resp: &CachedResponse{}}
+	return rec, func() {
+		if rec.resp.Status == http.StatusOK && r.Method == http.MethodGet && !responseHasErrors(r) { // HEAD responses may have no body
+			rec.resp.Header.Del("X-Cache")
+			store.Set(key, rec.resp, ttl)
+		}
+	}, true
+}
+
+// responseCacheRecorder records the response written to be cached
+type responseCacheRecorder struct {
+	http.ResponseWriter
+	resp *CachedResponse
+}
+
+func (w *responseCacheRecorder) WriteHeader(status int) {
+	w.record(status)
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseCacheRecorder) Write(b []byte) (int, error) {
+	w.record(http.StatusOK)
+	w.resp.Body = append(w.resp.Body, b...)
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher
+func (w *responseCacheRecorder) Flush() {
+	flushResponse(w.ResponseWriter)
+}
+
+func (w *responseCacheRecorder) record(status int) {
+	if w.resp.Status == 0 {
+		w.resp.Status = status
+		w.resp.Header = w.Header().Clone()
+	}
+}
+
+// isCacheableRequest reports whether the response of r has no per-request fields in its body
+func isCacheableRequest(r *http.Request) bool {
+	if requestIDInBody {
+		return false // the request_id field
+	}
+	if debugEcho && r.Header.Get(DebugEchoHeader) != "" {
+		return false // the _debug field
+	}
+	return true
+}
+
+// writeCachedResponse writes a cached response, or 304 Not Modified if its ETag matches the If-None-Match header of r.
+// Only successful responses are cached, their response code is successCode.
+func writeCachedResponse(w http.ResponseWriter, r *http.Request, resp *CachedResponse) {
+	recordCode(r, successCode)
+	requestID := w.Header().Get(RequestIDHeader)
+	for k, v := range resp.Header {
+		if k == "Content-Encoding" || k == "Content-Length" {
+			continue // the body is cached uncompressed
+		}
+		if k == "Vary" || strings.HasPrefix(k, "Access-Control-") {
+			continue // set by the CORS policy of r
+		}
+		w.Header()[k] = v
+	}
+	if requestID != "" {
+		w.Header().Set(RequestIDHeader, requestID)
+	}
+	w.Header().Set("X-Cache", "HIT")
+	dbgPrintf("HTTP %s %s: cached response", r.Method, r.URL.Path)
+
+	if etag := resp.Header.Get("ETag"); etag != "" && etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(resp.Status)
+	if _, err := w.Write(resp.Body); err != nil {
+		panic(err)
+	}
+}
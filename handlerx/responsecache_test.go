@@ -0,0 +1,175 @@
+package handlerx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestSetResponseCache(t *testing.T) {
+	setupTestMapping()
+	SetResponseCache(NewMemoryResponseCache())
+	SetResponseCacheTTL("todos", time.Minute)
+	defer SetResponseCache(nil)
+	defer SetResponseCacheTTL("todos", 0)
+
+	executions := 0
+	serve := func(target string, header http.Header, status int) *httptest.ResponseRecorder {
+		r := WithRoute(httptest.NewRequest(http.MethodGet, target, nil), "/todos")
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		ww, finish, ok := withResponseCache(w, r)
+		if !ok {
+			return w
+		}
+		defer finish()
+		executions++
+		ww.Header().Set("ETag", `"v1"`)
+		ww.WriteHeader(status)
+		_, _ = ww.Write([]byte(`{"code":0}`))
+		return w
+	}
+
+	tests := []struct {
+		Name       string
+		Target     string
+		Header     http.Header
+		Status     int
+		Executions int
+		Cache      string
+		Code       int
+	}{
+		{Name: "miss", Target: "/todos?b=2&a=1", Status: http.StatusOK, Executions: 1, Cache: "MISS", Code: http.StatusOK},
+		{Name: "hit", Target: "/todos?b=2&a=1", Status: http.StatusOK, Executions: 1, Cache: "HIT", Code: http.StatusOK},
+		{Name: "normalized params", Target: "/todos?a=1&b=2", Status: http.StatusOK, Executions: 1, Cache: "HIT", Code: http.StatusOK},
+		{Name: "not modified", Target: "/todos?a=1&b=2", Header: http.Header{"If-None-Match": {`"v1"`}}, Executions: 1, Cache: "HIT", Code: http.StatusNotModified},
+		{Name: "other params", Target: "/todos?a=2", Status: http.StatusOK, Executions: 2, Cache: "MISS", Code: http.StatusOK},
+		{Name: "authenticated", Target: "/todos?a=1&b=2", Header: http.Header{"Authorization": {"Bearer t"}}, Status: http.StatusOK, Executions: 3, Code: http.StatusOK},
+		{Name: "error", Target: "/todos?a=3", Status: http.StatusNotFound, Executions: 4, Cache: "MISS", Code: http.StatusNotFound},
+		{Name: "error not cached", Target: "/todos?a=3", Status: http.StatusOK, Executions: 5, Cache: "MISS", Code: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			w := serve(tt.Target, tt.Header, tt.Status)
+			assert.Equal(t, tt.Executions, executions)
+			assert.Equal(t, tt.Cache, w.Header().Get("X-Cache"))
+			assert.Equal(t, tt.Code, w.Code)
+			if tt.Code == http.StatusOK {
+				assert.Equal(t, `{"code":0}`, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSetResponseCacheKey(t *testing.T) {
+	setupTestMapping()
+	SetResponseCache(NewMemoryResponseCache())
+	SetResponseCacheTTL("todos", time.Minute)
+	SetResponseCacheKey(func(r *http.Request) (string, bool) {
+		return r.Header.Get("X-Tenant") + " " + r.URL.Path, true
+	})
+	defer SetResponseCache(nil)
+	defer SetResponseCacheTTL("todos", 0)
+	defer SetResponseCacheKey(nil)
+
+	serve := func(tenant string) string {
+		r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos", nil), "/todos")
+		r.Header.Set("X-Tenant", tenant)
+		w := httptest.NewRecorder()
+		ww, finish, ok := withResponseCache(w, r)
+		if ok {
+			_, _ = ww.Write([]byte(tenant))
+			finish()
+		}
+		return w.Header().Get("X-Cache")
+	}
+
+	assert.Equal(t, "MISS", serve("a"))
+	assert.Equal(t, "HIT", serve("a"))
+	assert.Equal(t, "MISS", serve("b"))
+}
+
+// flakyQueryExecutor is a flakyExecutor of query operations, as executed by the transports
+type flakyQueryExecutor struct {
+	flakyExecutor
+}
+
+func (e *flakyQueryExecutor) CreateOperationContext(ctx context.Context, params *graphql.RawParams) (*graphql.OperationContext, gqlerror.List) {
+	return (&paramsExecutor{}).CreateOperationContext(ctx, params)
+}
+
+func TestResponseCacheSkipsErrors(t *testing.T) {
+	setupTestMapping()
+	SetResponseCache(NewMemoryResponseCache())
+	SetResponseCacheTTL("todos", time.Minute)
+	defer SetResponseCache(nil)
+	defer SetResponseCacheTTL("todos", 0)
+
+	// the first execution fails, written with a 200 status as the HTTP status is disabled
+	exec := &flakyQueryExecutor{flakyExecutor{failures: 1, errs: gqlerror.List{{Message: "boom", Extensions: map[string]interface{}{"code": 500}}}}}
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		GET{}.Do(w, WithRoute(httptest.NewRequest(http.MethodGet, "/todos", nil), "/todos"), exec)
+		return w
+	}
+
+	w := serve()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":500`)
+	w = serve()
+	assert.Equal(t, "MISS", w.Header().Get("X-Cache"))
+	assert.Equal(t, `{"code":0,"data":[]}`, w.Body.String())
+	assert.Equal(t, "HIT", serve().Header().Get("X-Cache"))
+	assert.Equal(t, 2, exec.calls)
+}
+
+func TestResponseCacheKeyVariants(t *testing.T) {
+	SetContentNegotiation(true)
+	defer SetContentNegotiation(false)
+	setupTestMapping()
+	SetResponseCache(NewMemoryResponseCache())
+	SetResponseCacheTTL("todos", time.Minute)
+	defer SetResponseCache(nil)
+	defer SetResponseCacheTTL("todos", 0)
+
+	serve := func(header http.Header) string {
+		r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos", nil), "/todos")
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		ww, finish, ok := withResponseCache(w, r)
+		if ok {
+			_, _ = ww.Write([]byte(`{"code":0}`))
+			finish()
+		}
+		return w.Header().Get("X-Cache")
+	}
+
+	assert.Equal(t, "MISS", serve(http.Header{"Accept": {"text/csv"}}))
+	assert.Equal(t, "HIT", serve(http.Header{"Accept": {"text/csv"}}))
+	assert.Equal(t, "MISS", serve(http.Header{"Accept": {"application/json"}}))
+	assert.Equal(t, "MISS", serve(http.Header{PersistedQueryHashHeader: {"abc"}}))
+	assert.Equal(t, "HIT", serve(http.Header{PersistedQueryHashHeader: {"ABC"}}))
+
+	SetRequestIDInBody(true)
+	assert.Equal(t, "", serve(nil))
+	SetRequestIDInBody(false)
+
+	SetDebugEcho(true)
+	assert.Equal(t, "", serve(http.Header{DebugEchoHeader: {"1"}}))
+	SetDebugEcho(false)
+
+	SetupRESTSubscriptions([]string{"todos"})
+	assert.Equal(t, "", serve(nil))
+	SetupRESTSubscriptions(nil)
+}
@@ -0,0 +1,30 @@
+package handlerx
+
+import (
+	"encoding/json"
+)
+
+var resultStatusMappers = make(map[string]func(data json.RawMessage) int)
+
+// SetResultStatusMapper registers fn to choose the HTTP status of RESTful responses of operation opName
+// without GraphQL errors from the unwrapped `data`, eg. by the `__typename` of a union of the result and
+// the domain errors. It's called before the response transforms, a status <= 0 keeps the default
+// (SetSuccessStatus, or 200). The `code` of the response body is not changed. Pass a nil fn to remove it.
+func SetResultStatusMapper(opName string, fn func(data json.RawMessage) int) {
+	if fn == nil {
+		delete(resultStatusMappers, opName)
+	} else {
+		resultStatusMappers[opName] = fn
+	}
+}
+
+// applyResultStatus sets the HTTP status mapped from the result data of operation opName
+func applyResultStatus(response *RESTResponse, opName string, data json.RawMessage) {
+	fn, ok := resultStatusMappers[opName]
+	if !ok || len(data) == 0 {
+		return
+	}
+	if status := fn(data); status > 0 {
+		response.status = status
+	}
+}
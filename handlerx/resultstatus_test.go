@@ -0,0 +1,76 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestSetResultStatusMapper(t *testing.T) {
+	setupTestMapping()
+	SetResultStatusMapper("createTodo", func(data json.RawMessage) int {
+		var result struct {
+			Typename string `json:"__typename"`
+		}
+		_ = json.Unmarshal(data, &result)
+		switch result.Typename {
+		case "DuplicateTodo":
+			return http.StatusConflict
+		case "Todo":
+			return http.StatusCreated
+		}
+		return 0
+	})
+	defer SetResultStatusMapper("createTodo", nil)
+
+	tests := []struct {
+		Name          string
+		Response      *graphql.Response
+		SuccessStatus int
+		Status        int
+		Body          string
+	}{
+		{
+			Name:     "domain error",
+			Response: &graphql.Response{Data: json.RawMessage(`{"createTodo":{"__typename":"DuplicateTodo","id":"1"}}`)},
+			Status:   http.StatusConflict,
+			Body:     `{"code":0,"data":{"__typename":"DuplicateTodo","id":"1"}}`,
+		},
+		{
+			Name:     "success",
+			Response: &graphql.Response{Data: json.RawMessage(`{"createTodo":{"__typename":"Todo","id":"1"}}`)},
+			Status:   http.StatusCreated,
+			Body:     `{"code":0,"data":{"__typename":"Todo","id":"1"}}`,
+		},
+		{
+			Name:          "unmapped falls back to the success status",
+			Response:      &graphql.Response{Data: json.RawMessage(`{"createTodo":{"id":"1"}}`)},
+			SuccessStatus: http.StatusAccepted,
+			Status:        http.StatusAccepted,
+			Body:          `{"code":0,"data":{"id":"1"}}`,
+		},
+		{
+			Name:     "not applied to errors",
+			Response: &graphql.Response{Errors: gqlerror.List{{Message: "denied", Extensions: map[string]interface{}{"code": 403}}}},
+			Status:   http.StatusOK,
+			Body:     `{"code":403,"message":"denied","data":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetSuccessStatus("createTodo", tt.SuccessStatus)
+			defer SetSuccessStatus("createTodo", 0)
+			r := WithRoute(httptest.NewRequest(http.MethodPost, "/todos", nil), "/todos")
+			w := httptest.NewRecorder()
+			writeJSON(w, r, tt.Response, true)
+			assert.Equal(t, tt.Status, w.Code)
+			assert.Equal(t, tt.Body, w.Body.String())
+		})
+	}
+}
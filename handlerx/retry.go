@@ -0,0 +1,95 @@
+package handlerx
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+type retryPolicy struct {
+	attempts  int
+	backoff   func(int) time.Duration
+	retryable func(*gqlerror.Error) bool
+}
+
+var retryPolicies = make(map[string]*retryPolicy)
+
+// SetRetryPolicy marks operation opName as idempotent and executes it up to attempts times while all the errors
+// of its response are retryable, eg. database deadlocks. backoff(n) is the delay before the n-th retry (from 1),
+// nil retries immediately; retryable nil treats all errors as retryable.
+// Each attempt executes in a fresh context, retries stop when the request or its operation timeout is done.
+// opName is the GraphQL operation of RESTful requests, or the operation name of GraphQL requests.
+// Pass attempts <= 1 to remove the policy.
+func SetRetryPolicy(opName string, attempts int, backoff func(int) time.Duration, retryable func(*gqlerror.Error) bool) {
+	if attempts <= 1 {
+		delete(retryPolicies, opName)
+		return
+	}
+	retryPolicies[opName] = &retryPolicy{attempts: attempts, backoff: backoff, retryable: retryable}
+}
+
+// operationRetryPolicy returns the retry policy of the operation of r, nil if it isn't retried
+func operationRetryPolicy(r *http.Request, rc *graphql.OperationContext) *retryPolicy {
+	opName, ok := restOperationName(r)
+	if !ok {
+		opName = rc.OperationName
+	}
+	return retryPolicies[opName]
+}
+
+// shouldRetry reports whether resp failed with retryable errors only
+func (p *retryPolicy) shouldRetry(resp *graphql.Response) bool {
+	if resp == nil || len(resp.Errors) == 0 {
+		return false
+	}
+	if p.retryable == nil {
+		return true
+	}
+	for _, err := range resp.Errors {
+		if !p.retryable(err) {
+			return false
+		}
+	}
+	return true
+}
+
+// dispatchOperation executes rc and returns its response, retrying it by its retry policy
+func dispatchOperation(ctx context.Context, r *http.Request, exec graphql.GraphExecutor, rc *graphql.OperationContext) *graphql.Response {
+	policy := operationRetryPolicy(r, rc)
+	if policy == nil {
+		responses, ctx := exec.DispatchOperation(ctx, rc)
+		return responses(ctx)
+	}
+
+	var resp *graphql.Response
+	for attempt := 1; ; attempt++ {
+		resp = dispatchAttempt(ctx, exec, rc)
+		if attempt >= policy.attempts || !policy.shouldRetry(resp) {
+			return resp
+		}
+
+		var delay time.Duration
+		if policy.backoff != nil {
+			delay = policy.backoff(attempt)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return resp
+		}
+		dbgPrintf("HTTP %s %s: retrying operation %s (%d/%d): %s", r.Method, r.URL.Path, rc.OperationName, attempt+1, policy.attempts, resp.Errors.Error())
+	}
+}
+
+// dispatchAttempt executes rc in a context of its own, canceled when the attempt is done
+func dispatchAttempt(ctx context.Context, exec graphql.GraphExecutor, rc *graphql.OperationContext) *graphql.Response {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	responses, ctx := exec.DispatchOperation(ctx, rc)
+	return responses(ctx)
+}
@@ -0,0 +1,94 @@
+package handlerx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// flakyExecutor fails with errs until it has been executed failures times
+type flakyExecutor struct {
+	failures int
+	errs     gqlerror.List
+	calls    int
+	contexts []context.Context
+}
+
+func (e *flakyExecutor) CreateOperationContext(ctx context.Context, params *graphql.RawParams) (*graphql.OperationContext, gqlerror.List) {
+	return &graphql.OperationContext{}, nil
+}
+
+func (e *flakyExecutor) DispatchOperation(ctx context.Context, rc *graphql.OperationContext) (graphql.ResponseHandler, context.Context) {
+	return func(ctx context.Context) *graphql.Response {
+		e.calls++
+		e.contexts = append(e.contexts, ctx)
+		if e.calls <= e.failures {
+			return &graphql.Response{Errors: e.errs}
+		}
+		return &graphql.Response{Data: json.RawMessage(`{"todos":[]}`)}
+	}, ctx
+}
+
+func (e *flakyExecutor) DispatchError(ctx context.Context, list gqlerror.List) *graphql.Response {
+	return &graphql.Response{Errors: list}
+}
+
+func TestSetRetryPolicy(t *testing.T) {
+	setupTestMapping()
+	defer SetRetryPolicy("todos", 0, nil, nil)
+
+	deadlock := &gqlerror.Error{Message: "deadlock", Extensions: map[string]interface{}{"code": 503}}
+	notFound := &gqlerror.Error{Message: "not found", Extensions: map[string]interface{}{"code": 404}}
+	retryable := func(err *gqlerror.Error) bool { return err.Message == "deadlock" }
+
+	tests := []struct {
+		Name     string
+		Attempts int
+		Failures int
+		Errors   gqlerror.List
+		Calls    int
+		Expected string
+	}{
+		{Name: "no policy", Failures: 1, Errors: gqlerror.List{deadlock}, Calls: 1, Expected: `{"code":503,"message":"deadlock","data":null}`},
+		{Name: "retried", Attempts: 3, Failures: 2, Errors: gqlerror.List{deadlock}, Calls: 3, Expected: `{"code":0,"data":[]}`},
+		{Name: "attempts exhausted", Attempts: 2, Failures: 2, Errors: gqlerror.List{deadlock}, Calls: 2, Expected: `{"code":503,"message":"deadlock","data":null}`},
+		{Name: "not retryable", Attempts: 3, Failures: 1, Errors: gqlerror.List{deadlock, notFound}, Calls: 1, Expected: `{"code":404,"message":"deadlock; not found","data":null}`},
+		{Name: "no errors", Attempts: 3, Calls: 1, Expected: `{"code":0,"data":[]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetRetryPolicy("todos", tt.Attempts, func(int) time.Duration { return time.Millisecond }, retryable)
+			exec := &flakyExecutor{failures: tt.Failures, errs: tt.Errors}
+			r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos", nil), "/todos")
+			w := httptest.NewRecorder()
+			writeOperation(w, r, exec, &graphql.OperationContext{}, true)
+			assert.Equal(t, tt.Expected, w.Body.String())
+			assert.Equal(t, tt.Calls, exec.calls)
+			if tt.Calls > 1 {
+				assert.Error(t, exec.contexts[0].Err(), "the failed attempt context is canceled")
+			}
+		})
+	}
+}
+
+func TestSetRetryPolicyDeadline(t *testing.T) {
+	setupTestMapping()
+	defer SetOperationTimeout("todos", 0)
+	defer SetRetryPolicy("todos", 0, nil, nil)
+
+	SetOperationTimeout("todos", 20*time.Millisecond)
+	SetRetryPolicy("todos", 5, func(int) time.Duration { return time.Second }, nil)
+	exec := &flakyExecutor{failures: 5, errs: gqlerror.List{{Message: "deadlock"}}}
+	r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos", nil), "/todos")
+	w := httptest.NewRecorder()
+	writeOperation(w, r, exec, &graphql.OperationContext{}, true)
+	assert.Equal(t, `{"code":504,"message":"operation timed out after 20ms","data":null}`, w.Body.String())
+}
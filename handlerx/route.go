@@ -0,0 +1,51 @@
+package handlerx
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// WithRoute returns a request routed to the RESTful endpoint pattern (eg. "/api/v1/todos/{id}"),
+// for routers other than chi. params are the values of the path parameters, in the order of the pattern.
+//
+//	r.GET("/api/v1/todos/:id", func(c *gin.Context) {
+//		srv.ServeHTTP(c.Writer, handlerx.WithRoute(c.Request, "/api/v1/todos/{id}", c.Param("id")))
+//	})
+func WithRoute(r *http.Request, pattern string, params ...string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.RoutePatterns = []string{pattern}
+	rctx.RoutePath = r.URL.Path
+	for i, name := range pathParamNames(pattern) {
+		if i < len(params) {
+			rctx.URLParams.Add(name, params[i])
+		}
+	}
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+// pathParamNames returns the names of the path parameters of pattern, eg. ["id"] of "/todos/{id}"
+func pathParamNames(pattern string) []string {
+	names := make([]string, 0)
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '{' {
+			continue
+		}
+		end := i + 1
+		for end < len(pattern) && pattern[end] != '}' {
+			end++
+		}
+		if end == len(pattern) {
+			break
+		}
+		name := pattern[i+1 : end]
+		if colon := strings.IndexByte(name, ':'); colon >= 0 {
+			name = name[:colon] // regexp params, eg. {id:[0-9]+}
+		}
+		names = append(names, name)
+		i = end
+	}
+	return names
+}
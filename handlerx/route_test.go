@@ -0,0 +1,26 @@
+package handlerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRoute(t *testing.T) {
+	setupTestMapping()
+
+	r := httptest.NewRequest(http.MethodGet, "/todos/42", nil)
+	query, err := convertHTTPRequestToGraphQLQuery(WithRoute(r, "/todos/{id}", "42"), &graphql.RawParams{}, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, query, "todo(")
+	assert.Contains(t, query, "id:42")
+
+	op, ok := restOperationName(WithRoute(httptest.NewRequest(http.MethodGet, "/todos", nil), "/todos/"))
+	assert.True(t, ok)
+	assert.Equal(t, "todos", op)
+
+	assert.Equal(t, []string{"id", "name"}, pathParamNames("/todos/{id:[0-9]+}/tags/{name}"))
+}
@@ -0,0 +1,46 @@
+package handlerx
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+var errorSanitizer func(code int, msg string) string
+
+// SetErrorSanitizer sets a hook rewriting the error messages of RESTful responses before they're joined,
+// eg. to replace the internal details of 5xx errors with a generic message while keeping 4xx ones.
+// It's called with the response code of each error; the original messages are still reported to
+// the access logger and the debug log. Pass nil to remove the hook.
+func SetErrorSanitizer(sanitizer func(code int, msg string) string) {
+	errorSanitizer = sanitizer
+}
+
+// sanitizeMessage returns the message of e reported to the client of req
+func sanitizeMessage(req *http.Request, e *gqlerror.Error) string {
+	if errorSanitizer == nil {
+		return e.Message
+	}
+	msg := errorSanitizer(errorCode(e), e.Message)
+	if msg != e.Message && req != nil {
+		dbgPrintf("HTTP %s %s: sanitized error message: %s", req.Method, req.URL.Path, e.Message)
+	}
+	return msg
+}
+
+// recordErrorMessage records the original error messages of the response of req for the access logger
+func recordErrorMessage(req *http.Request, errs gqlerror.List) {
+	if req == nil {
+		return
+	}
+	state := responseStateFromContext(req.Context())
+	if state == nil {
+		return
+	}
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msgs = append(msgs, e.Message)
+	}
+	state.errorMessage = strings.Join(msgs, "; ")
+}
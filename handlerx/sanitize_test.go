@@ -0,0 +1,75 @@
+package handlerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestSetErrorSanitizer(t *testing.T) {
+	setupTestMapping()
+	SetErrorSanitizer(func(code int, msg string) string {
+		if code >= http.StatusInternalServerError {
+			return "internal error"
+		}
+		return msg
+	})
+	defer SetErrorSanitizer(nil)
+	var entries []AccessLogEntry
+	SetAccessLogger(func(entry AccessLogEntry) {
+		entries = append(entries, entry)
+	})
+	defer SetAccessLogger(nil)
+
+	tests := []struct {
+		Name     string
+		Errors   gqlerror.List
+		Verbose  bool
+		Expected string
+		Logged   string
+	}{
+		{
+			Name:     "internal error sanitized",
+			Errors:   gqlerror.List{{Message: "pq: deadlock detected", Extensions: map[string]interface{}{"code": 500}}},
+			Expected: `{"code":500,"message":"internal error","data":null}`,
+			Logged:   "pq: deadlock detected",
+		},
+		{
+			Name:     "client error kept",
+			Errors:   gqlerror.List{{Message: "todo not found", Extensions: map[string]interface{}{"code": 404}}},
+			Expected: `{"code":404,"message":"todo not found","data":null}`,
+			Logged:   "todo not found",
+		},
+		{
+			Name: "verbose errors sanitized",
+			Errors: gqlerror.List{
+				{Message: "invalid id", Extensions: map[string]interface{}{"code": 400}},
+				{Message: "dial tcp 10.0.0.1:5432", Extensions: map[string]interface{}{"code": 503}},
+			},
+			Verbose:  true,
+			Expected: `{"code":400,"message":"invalid id; internal error","data":null,"errors":[{"message":"invalid id","extensions":{"code":400}},{"message":"internal error","extensions":{"code":503}}]}`,
+			Logged:   "invalid id; dial tcp 10.0.0.1:5432",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetVerboseErrors(tt.Verbose)
+			defer SetVerboseErrors(false)
+			entries = nil
+
+			r := withResponseState(WithRoute(httptest.NewRequest(http.MethodGet, "/todos", nil), "/todos"))
+			w, observe := wrapMetrics(httptest.NewRecorder(), r)
+			writeJSON(w, r, &graphql.Response{Errors: tt.Errors}, true)
+			observe()
+
+			assert.Equal(t, tt.Expected, w.(*statusRecorder).ResponseWriter.(*httptest.ResponseRecorder).Body.String())
+			assert.Len(t, entries, 1)
+			assert.Equal(t, tt.Logged, entries[0].Error)
+		})
+	}
+}
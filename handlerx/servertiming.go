@@ -0,0 +1,140 @@
+package handlerx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+var serverTimingEnabled bool
+
+// SetServerTiming makes the responses of executed operations report the durations of the phases of
+// the request in a Server-Timing header, in milliseconds, eg. "decode;dur=0.41, validate;dur=0.18,
+// exec;dur=3.07, encode;dur=0.25": decode reads the request (and converts RESTful requests to GraphQL),
+// validate parses and validates the query, exec executes it, and encode transforms the result until
+// the response is written. It's disabled by default, as the timings are visible to any client.
+func SetServerTiming(enabled bool) {
+	serverTimingEnabled = enabled
+}
+
+// timingPhase is a phase of a request, end is zero until it's ended
+type timingPhase struct {
+	name       string
+	start, end time.Time
+}
+
+// serverTiming records the phases of a request, see SetServerTiming
+type serverTiming struct {
+	mu     sync.Mutex
+	phases []timingPhase
+}
+
+// add records the phase name, a zero end leaves it open until the header is written
+func (t *serverTiming) add(name string, start, end time.Time) {
+	if start.IsZero() {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.phases = append(t.phases, timingPhase{name: name, start: start, end: end})
+}
+
+// header returns the value of the Server-Timing header, the open phases end at now
+func (t *serverTiming) header(now time.Time) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	metrics := make([]string, 0, len(t.phases))
+	for _, p := range t.phases {
+		end := p.end
+		if end.IsZero() {
+			end = now
+		}
+		ms := float64(end.Sub(p.start)) / float64(time.Millisecond)
+		metrics = append(metrics, fmt.Sprintf("%s;dur=%.2f", p.name, ms))
+	}
+	return strings.Join(metrics, ", ")
+}
+
+// serverTimingFromContext returns the serverTiming of the current request, or nil if it's disabled
+func serverTimingFromContext(ctx context.Context) *serverTiming {
+	if state := responseStateFromContext(ctx); state != nil {
+		return state.timing
+	}
+	return nil
+}
+
+// startPhase starts the phase name of the request of ctx, the returned func ends it
+func startPhase(ctx context.Context, name string) func() {
+	t := serverTimingFromContext(ctx)
+	if t == nil {
+		return func() {}
+	}
+	start := graphql.Now()
+	var once sync.Once
+	return func() {
+		once.Do(func() { t.add(name, start, graphql.Now()) })
+	}
+}
+
+// startEncodePhase starts the encode phase, which ends when the response is written
+func startEncodePhase(ctx context.Context) {
+	if t := serverTimingFromContext(ctx); t != nil {
+		t.add("encode", graphql.Now(), time.Time{})
+	}
+}
+
+// addOperationPhases records the decode and validate phases of the operation rc from its stats
+func addOperationPhases(ctx context.Context, rc *graphql.OperationContext) {
+	t := serverTimingFromContext(ctx)
+	if t == nil {
+		return
+	}
+	if !rc.Stats.Read.End.IsZero() {
+		t.add("decode", rc.Stats.Read.Start, rc.Stats.Read.End)
+	}
+	t.add("validate", rc.Stats.Parsing.Start, rc.Stats.Validation.End)
+}
+
+// serverTimingWriter sets the Server-Timing header before the response header is written
+type serverTimingWriter struct {
+	http.ResponseWriter
+	timing      *serverTiming
+	wroteHeader bool
+}
+
+func (w *serverTimingWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if value := w.timing.header(graphql.Now()); value != "" {
+			w.Header().Set("Server-Timing", value)
+		}
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *serverTimingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher, eg. for server-sent events
+func (w *serverTimingWriter) Flush() {
+	flushResponse(w.ResponseWriter)
+}
+
+// withServerTiming records the phases of r if SetServerTiming is enabled, it must be called after withResponseState
+func withServerTiming(w http.ResponseWriter, r *http.Request) http.ResponseWriter {
+	state := responseStateFromContext(r.Context())
+	if !serverTimingEnabled || state == nil {
+		return w
+	}
+	state.timing = &serverTiming{}
+	return &serverTimingWriter{ResponseWriter: w, timing: state.timing}
+}
@@ -0,0 +1,53 @@
+package handlerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetServerTiming(t *testing.T) {
+	serve := func(enabled bool) *httptest.ResponseRecorder {
+		SetServerTiming(enabled)
+		defer SetServerTiming(false)
+
+		r := withResponseState(httptest.NewRequest(http.MethodGet, "/todos", nil))
+		w := httptest.NewRecorder()
+		ww := withServerTiming(w, r)
+
+		now := time.Now()
+		rc := &graphql.OperationContext{Stats: graphql.Stats{
+			Read:       graphql.TraceTiming{Start: now.Add(-3 * time.Millisecond), End: now.Add(-2 * time.Millisecond)},
+			Parsing:    graphql.TraceTiming{Start: now.Add(-2 * time.Millisecond)},
+			Validation: graphql.TraceTiming{End: now.Add(-time.Millisecond)},
+		}}
+		addOperationPhases(r.Context(), rc)
+		endExec := startPhase(r.Context(), "exec")
+		endExec()
+		startEncodePhase(r.Context())
+		_, _ = ww.Write([]byte(`{"code":0}`))
+		return w
+	}
+
+	tests := []struct {
+		Name    string
+		Enabled bool
+		Expect  string
+	}{
+		{Name: "disabled", Enabled: false, Expect: `^$`},
+		{Name: "enabled", Enabled: true, Expect: `^decode;dur=1\.00, validate;dur=1\.00, exec;dur=\d+\.\d\d, encode;dur=\d+\.\d\d$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			w := serve(tt.Enabled)
+			assert.Regexp(t, regexp.MustCompile(tt.Expect), w.Header().Get("Server-Timing"))
+			assert.Equal(t, `{"code":0}`, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package handlerx
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// GraphQL Operations of subscriptions
+var restSubscriptions = make(map[string]bool)
+
+// SetupRESTSubscriptions sets up the operations of RESTful endpoints mapped to GraphQL subscriptions,
+// they're served as server-sent events. It's called by the generated SetupRESTMapping.
+func SetupRESTSubscriptions(operations []string) {
+	subscriptions := make(map[string]bool, len(operations))
+	for _, op := range operations {
+		subscriptions[op] = true
+	}
+	restSubscriptions = subscriptions
+}
+
+// isSubscription reports whether operationName is a subscription
+func isSubscription(operationName string) bool {
+	return restSubscriptions[operationName]
+}
+
+// writeEventStream streams the responses of a subscription as server-sent events, each event is the
+// RESTful response written by writeJSON, responses with errors are sent as `event: error`. It returns
+// when the subscription ends or the client disconnects.
+func writeEventStream(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor, rc *graphql.OperationContext) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // disable the response buffering of nginx
+	w.WriteHeader(http.StatusOK)
+	flushResponse(w)
+
+	// events are always complete responses, not conditional ones
+	er := r.WithContext(r.Context())
+	er.Header = r.Header.Clone()
+	er.Header.Del("If-None-Match")
+
+	responses, ctx := exec.DispatchOperation(r.Context(), rc)
+	events := make(chan *graphql.Response)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(events)
+		for {
+			resp := responses(ctx)
+			if resp == nil {
+				return
+			}
+			// the data buffer is reused by the next response
+			resp.Data = append(json.RawMessage(nil), resp.Data...)
+			select {
+			case events <- resp:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case resp, ok := <-events:
+			if !ok {
+				return
+			}
+			rec := &batchResponseWriter{header: make(http.Header)}
+			writeJSON(rec, er, resp, true)
+
+			event := ""
+			if len(resp.Errors) > 0 {
+				event = "error"
+			}
+			if err := writeEvent(w, event, rec.body.Bytes()); err != nil {
+				dbgPrintf("event stream of %s aborted: %v", rc.OperationName, err)
+				return
+			}
+			flushResponse(w)
+		}
+	}
+}
+
+// writeEvent writes a server-sent event, each line of data is sent as a `data:` field
+func writeEvent(w http.ResponseWriter, event string, data []byte) error {
+	var buf bytes.Buffer
+	if event != "" {
+		buf.WriteString("event: " + event + "\n")
+	}
+	for _, line := range bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// flushResponse sends the buffered response data to the client, if w supports it
+func flushResponse(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
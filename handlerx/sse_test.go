@@ -0,0 +1,53 @@
+package handlerx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteEvent(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Event    string
+		Data     string
+		Expected string
+	}{
+		{Name: "data", Data: `{"code":0}`, Expected: "data: {\"code\":0}\n\n"},
+		{Name: "error", Event: "error", Data: `{"code":500}`, Expected: "event: error\ndata: {\"code\":500}\n\n"},
+		{Name: "multiline", Data: "{\n  \"code\": 0\n}\n", Expected: "data: {\ndata:   \"code\": 0\ndata: }\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			assert.NoError(t, writeEvent(w, tt.Event, []byte(tt.Data)))
+			assert.Equal(t, tt.Expected, w.Body.String())
+		})
+	}
+}
+
+func TestSetupRESTSubscriptions(t *testing.T) {
+	setupTestMapping()
+	restURL2GraphOperation["GET:/todos/events"] = "todoEvents"
+	graphOperation2RESTSelection["todoEvents"] = "{id}"
+	restOperation2Arguments["todoEvents"] = StringMap{"limit": "Int"}
+	SetupRESTSubscriptions([]string{"todoEvents"})
+	defer SetupRESTSubscriptions(nil)
+
+	r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos/events?limit=2", nil), "/todos/events")
+	query, err := convertHTTPRequestToGraphQLQuery(r, &graphql.RawParams{}, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(query, "subscription { todoEvents("), query)
+	assert.Contains(t, query, "limit:2")
+
+	r = WithRoute(httptest.NewRequest(http.MethodGet, "/todos?limit=2", nil), "/todos")
+	query, err = convertHTTPRequestToGraphQLQuery(r, &graphql.RawParams{}, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(query, "query { todos("), query)
+	assert.Contains(t, query, "limit:2")
+}
@@ -0,0 +1,55 @@
+package handlerx
+
+import (
+	"context"
+	"net/http"
+)
+
+// responseState is the per-request state shared by resolvers and writeJSON
+type responseState struct {
+	etag         string
+	debug        *RESTDebug
+	errorMessage string        // the original error messages of the response
+	timing       *serverTiming // the phases of the request, nil unless SetServerTiming is enabled
+	hasErrors    bool          // the response has errors, even if it's written with a 200 status
+	code         *int          // the response code of RESTful responses, see recordCode
+}
+
+type responseStateKey struct{}
+
+// withResponseState stores a new responseState in the request context
+func withResponseState(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), responseStateKey{}, &responseState{}))
+}
+
+// responseStateFromContext returns the responseState of the current request, or nil if there's none
+func responseStateFromContext(ctx context.Context) *responseState {
+	state, _ := ctx.Value(responseStateKey{}).(*responseState)
+	return state
+}
+
+// markResponseErrors records that the response of req has errors, so that it's not cached
+func markResponseErrors(req *http.Request) {
+	if req == nil {
+		return
+	}
+	if state := responseStateFromContext(req.Context()); state != nil {
+		state.hasErrors = true
+	}
+}
+
+// recordCode records the response code of the RESTful response of req, whichever writer it's written to
+func recordCode(req *http.Request, code int) {
+	if req == nil {
+		return
+	}
+	if state := responseStateFromContext(req.Context()); state != nil {
+		state.code = &code
+	}
+}
+
+// responseHasErrors reports whether the response of r has errors
+func responseHasErrors(r *http.Request) bool {
+	state := responseStateFromContext(r.Context())
+	return state != nil && state.hasErrors
+}
@@ -0,0 +1,108 @@
+package handlerx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"embed"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// swaggerUIAssets are the Swagger UI dist files, scripts and styles are gzipped.
+// They're only linked into binaries calling SwaggerUIHandler.
+//
+//go:embed swaggerui
+var swaggerUIAssets embed.FS
+
+const swaggerUIIndex = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>API Documentation</title>
+  <link rel="stylesheet" href="swagger-ui.css">
+  <link rel="icon" type="image/png" href="favicon-32x32.png" sizes="32x32">
+  <link rel="icon" type="image/png" href="favicon-16x16.png" sizes="16x16">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "{{SPEC}}", dom_id: "#swagger-ui", deepLinking: true});
+  </script>
+</body>
+</html>
+`
+
+// SwaggerUIHandler returns a handler serving Swagger UI for the OpenAPI spec file at specPath
+// (eg. "graph/generated/rest.yaml"), the spec is read on each request and no CDN is required.
+// Mount it on a directory, eg. r.Mount("/docs", handlerx.SwaggerUIHandler(specPath)).
+func SwaggerUIHandler(specPath string) http.Handler {
+	specName := "openapi" + filepath.Ext(specPath)
+	index := strings.Replace(swaggerUIIndex, "{{SPEC}}", specName, 1)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Base(r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/") {
+			name = "index.html"
+		}
+
+		switch name {
+		case "index.html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = io.WriteString(w, index)
+		case specName:
+			if ext := filepath.Ext(specPath); ext == ".yaml" || ext == ".yml" {
+				w.Header().Set("Content-Type", "application/yaml")
+			}
+			w.Header().Set("Cache-Control", "no-cache")
+			http.ServeFile(w, r, specPath)
+		default:
+			serveSwaggerUIAsset(w, r, name)
+		}
+	})
+}
+
+// serveSwaggerUIAsset serves an embedded asset, gzipped assets are decompressed for clients not accepting gzip
+func serveSwaggerUIAsset(w http.ResponseWriter, r *http.Request, name string) {
+	if b, err := swaggerUIAssets.ReadFile("swaggerui/" + name); err == nil {
+		contentType := mime.TypeByExtension(path.Ext(name))
+		if contentType == "" {
+			contentType = "text/plain; charset=utf-8" // LICENSE
+		}
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(b)
+		return
+	}
+
+	b, err := swaggerUIAssets.ReadFile("swaggerui/" + name + ".gz")
+	if err != nil {
+		if name == "." || !strings.Contains(name, ".") {
+			// the mount point without a trailing slash, the relative asset URLs require one.
+			// RequestURI is used as the path may be stripped, eg. by http.StripPrefix
+			if u, err := url.ParseRequestURI(r.RequestURI); err == nil {
+				http.Redirect(w, r, u.Path+"/", http.StatusMovedPermanently)
+				return
+			}
+		}
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", mime.TypeByExtension(path.Ext(name)))
+	w.Header().Set("Vary", "Accept-Encoding")
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(b)
+		return
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		panic(err)
+	}
+	_, _ = io.Copy(w, zr)
+}
@@ -0,0 +1,55 @@
+package handlerx
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSwaggerUIHandler(t *testing.T) {
+	specPath := filepath.Join(t.TempDir(), "rest.yaml")
+	assert.NoError(t, ioutil.WriteFile(specPath, []byte("openapi: 3.0.0\n"), 0644))
+	h := http.StripPrefix("/docs", SwaggerUIHandler(specPath))
+
+	tests := []struct {
+		Name            string
+		Path            string
+		AcceptEncoding  string
+		Status          int
+		ContentType     string
+		ContentEncoding string
+		Body            string
+	}{
+		{Name: "index", Path: "/docs/", Status: http.StatusOK, ContentType: "text/html; charset=utf-8", Body: `url: "openapi.yaml"`},
+		{Name: "mount point", Path: "/docs", Status: http.StatusMovedPermanently},
+		{Name: "spec", Path: "/docs/openapi.yaml", Status: http.StatusOK, ContentType: "application/yaml", Body: "openapi: 3.0.0"},
+		{Name: "gzipped asset", Path: "/docs/swagger-ui.css", AcceptEncoding: "gzip, br", Status: http.StatusOK, ContentType: "text/css; charset=utf-8", ContentEncoding: "gzip"},
+		{Name: "decompressed asset", Path: "/docs/swagger-ui.css", Status: http.StatusOK, ContentType: "text/css; charset=utf-8", Body: ".swagger-ui"},
+		{Name: "image", Path: "/docs/favicon-16x16.png", Status: http.StatusOK, ContentType: "image/png"},
+		{Name: "not found", Path: "/docs/missing.js", Status: http.StatusNotFound},
+		{Name: "license", Path: "/docs/LICENSE", Status: http.StatusOK, ContentType: "text/plain; charset=utf-8", Body: "Apache License"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.Path, nil)
+			r.Header.Set("Accept-Encoding", tt.AcceptEncoding)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.Status, w.Code)
+			if tt.Status == http.StatusMovedPermanently {
+				assert.Equal(t, tt.Path+"/", w.Header().Get("Location"))
+			}
+			if tt.ContentType != "" {
+				assert.Equal(t, tt.ContentType, w.Header().Get("Content-Type"))
+			}
+			assert.Equal(t, tt.ContentEncoding, w.Header().Get("Content-Encoding"))
+			assert.Contains(t, w.Body.String(), tt.Body)
+		})
+	}
+}
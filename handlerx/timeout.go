@@ -0,0 +1,87 @@
+package handlerx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+var (
+	operationTimeouts       = make(map[string]time.Duration)
+	defaultOperationTimeout time.Duration
+)
+
+// SetOperationTimeout limits the execution of operation opName to d, the execution context is
+// canceled when it's exceeded and 504 Gateway Timeout is responded without waiting for the resolvers.
+// opName is the GraphQL operation of RESTful requests, or the operation name of GraphQL requests.
+// Pass d <= 0 to fall back to the default timeout.
+func SetOperationTimeout(opName string, d time.Duration) {
+	if d <= 0 {
+		delete(operationTimeouts, opName)
+	} else {
+		operationTimeouts[opName] = d
+	}
+}
+
+// SetDefaultOperationTimeout sets the timeout of operations without their own one, see SetOperationTimeout.
+// Pass d <= 0 to disable it (default).
+func SetDefaultOperationTimeout(d time.Duration) {
+	defaultOperationTimeout = d
+}
+
+// operationTimeout returns the timeout of the operation of r, 0 means no timeout
+func operationTimeout(r *http.Request, rc *graphql.OperationContext) time.Duration {
+	opName, ok := restOperationName(r)
+	if !ok {
+		opName = rc.OperationName
+	}
+	if d, ok := operationTimeouts[opName]; ok {
+		return d
+	}
+	return defaultOperationTimeout
+}
+
+// writeOperation executes rc and writes its response, or a 504 error if the operation timed out
+func writeOperation(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor, rc *graphql.OperationContext, isRESTful bool) {
+	ctx := graphql.WithOperationContext(r.Context(), rc)
+	addOperationPhases(ctx, rc)
+	d := operationTimeout(r, rc)
+	if d <= 0 {
+		endExec := startPhase(ctx, "exec")
+		resp := dispatchOperation(ctx, r, exec, rc)
+		endExec()
+		startEncodePhase(ctx)
+		writeJSON(w, r, resp, isRESTful)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	done := make(chan *graphql.Response, 1)
+	endExec := startPhase(ctx, "exec")
+	go func() {
+		resp := dispatchOperation(ctx, r, exec, rc)
+		endExec()
+		done <- resp
+	}()
+
+	select {
+	case resp := <-done:
+		startEncodePhase(ctx)
+		writeJSON(w, r, resp, isRESTful)
+	case <-ctx.Done():
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// the client is gone, let the canceled execution finish
+			writeJSON(w, r, <-done, isRESTful)
+			return
+		}
+		dbgPrintf("HTTP %s %s: operation %s timed out after %s", r.Method, r.URL.Path, rc.OperationName, d)
+		writeHeader(w, r, http.StatusGatewayTimeout, isRESTful)
+		writeJSONErrorWithExtensions(w, r, http.StatusGatewayTimeout, isRESTful, fmt.Sprintf("operation timed out after %s", d),
+			map[string]interface{}{"reason": "TIMEOUT", "retryable": true})
+	}
+}
@@ -0,0 +1,75 @@
+package handlerx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// slowExecutor responds after delay, or when the execution is canceled
+type slowExecutor struct {
+	delay    time.Duration
+	canceled chan bool
+}
+
+func (e *slowExecutor) CreateOperationContext(ctx context.Context, params *graphql.RawParams) (*graphql.OperationContext, gqlerror.List) {
+	return &graphql.OperationContext{RawQuery: params.Query, OperationName: params.OperationName, Operation: &ast.OperationDefinition{}}, nil
+}
+
+func (e *slowExecutor) DispatchOperation(ctx context.Context, rc *graphql.OperationContext) (graphql.ResponseHandler, context.Context) {
+	return func(ctx context.Context) *graphql.Response {
+		select {
+		case <-time.After(e.delay):
+			e.canceled <- false
+			return &graphql.Response{Data: json.RawMessage(`{"todos":[]}`)}
+		case <-ctx.Done():
+			e.canceled <- true
+			return &graphql.Response{Errors: gqlerror.List{{Message: ctx.Err().Error()}}}
+		}
+	}, ctx
+}
+
+func (e *slowExecutor) DispatchError(ctx context.Context, list gqlerror.List) *graphql.Response {
+	return &graphql.Response{Errors: list}
+}
+
+func TestSetOperationTimeout(t *testing.T) {
+	setupTestMapping()
+	defer SetDefaultOperationTimeout(0)
+	defer SetOperationTimeout("todos", 0)
+
+	tests := []struct {
+		Name           string
+		DefaultTimeout time.Duration
+		Timeout        time.Duration
+		Expected       string
+		Canceled       bool
+	}{
+		{Name: "no timeout", Expected: `{"code":0,"data":[]}`},
+		{Name: "in time", Timeout: time.Second, Expected: `{"code":0,"data":[]}`},
+		{Name: "timed out", Timeout: 5 * time.Millisecond, Expected: `{"code":504,"message":"operation timed out after 5ms","data":null}`, Canceled: true},
+		{Name: "default timeout", DefaultTimeout: 5 * time.Millisecond, Expected: `{"code":504,"message":"operation timed out after 5ms","data":null}`, Canceled: true},
+		{Name: "operation overrides default", DefaultTimeout: 5 * time.Millisecond, Timeout: time.Second, Expected: `{"code":0,"data":[]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetDefaultOperationTimeout(tt.DefaultTimeout)
+			SetOperationTimeout("todos", tt.Timeout)
+			exec := &slowExecutor{delay: 50 * time.Millisecond, canceled: make(chan bool, 1)}
+			r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos", nil), "/todos")
+			w := httptest.NewRecorder()
+			writeOperation(w, r, exec, &graphql.OperationContext{}, true)
+			assert.Equal(t, tt.Expected, w.Body.String())
+			assert.Equal(t, tt.Canceled, <-exec.canceled)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package handlerx
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// SetFieldToggle makes the query parameter param of RESTful requests of operation opName toggle the field
+// at fieldPath of its selection, eg. "user" or "user.friends": the field is selected with
+// @include(if: $param), so that "?param=false" skips it. It must be called after the mapping is set up,
+// the operations executed as persisted queries can't be toggled.
+//
+// The variables of the @skip and @include directives of any selection (eg. registered by RegisterOperation)
+// or persisted query are bound the same way, from the query parameters named after them: variables of
+// @include default to true, and the ones of @skip to false (unless defined otherwise by the persisted
+// query). Query parameters toggling no directive are ignored as usual.
+func SetFieldToggle(opName, fieldPath, param string) error {
+	selection, ok := graphOperation2RESTSelection[opName]
+	if !ok {
+		return fmt.Errorf("handlerx: unknown operation %s", opName)
+	}
+	if _, ok := operationPersistedQueries[opName]; ok {
+		return fmt.Errorf("handlerx: operation %s is executed as a persisted query", opName)
+	}
+	if !enumValueRegexp.MatchString(param) {
+		return fmt.Errorf("handlerx: invalid toggle parameter %q", param)
+	}
+	selection, ok = insertFieldDirective(selection, strings.Split(fieldPath, "."), " @include(if:$"+param+")")
+	if !ok {
+		return fmt.Errorf("handlerx: field %s is not selected by %s", fieldPath, opName)
+	}
+	graphOperation2RESTSelection[opName] = selection
+	return nil
+}
+
+// insertFieldDirective inserts directive after the field at path of selection, eg. "{id,user{id}}"
+func insertFieldDirective(selection string, path []string, directive string) (string, bool) {
+	parents := make([]string, 0) // the fields enclosing the current selection set, "" for the operation
+	last := ""
+	for i := 0; i < len(selection); {
+		c := selection[i]
+		switch {
+		case c == '{':
+			parents = append(parents, last)
+			last = ""
+			i++
+		case c == '}':
+			if len(parents) > 0 {
+				parents = parents[:len(parents)-1]
+			}
+			i++
+		case c == '(': // arguments of a field or a directive
+			end := strings.IndexByte(selection[i:], ')')
+			if end < 0 {
+				return selection, false
+			}
+			i += end + 1
+		case c == '_' || 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z':
+			j := i + 1
+			for j < len(selection) && (selection[j] == '_' || 'A' <= selection[j] && selection[j] <= 'Z' || 'a' <= selection[j] && selection[j] <= 'z' || '0' <= selection[j] && selection[j] <= '9') {
+				j++
+			}
+			if i > 0 && selection[i-1] == '@' {
+				i = j // a directive
+				continue
+			}
+			last = selection[i:j]
+			if matchFieldPath(parents, last, path) {
+				return selection[:j] + directive + selection[j:], true
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+	return selection, false
+}
+
+// matchFieldPath reports whether the field name within the parents fields is at path
+func matchFieldPath(parents []string, name string, path []string) bool {
+	if len(parents) != len(path) || name != path[len(path)-1] {
+		return false
+	}
+	for i, parent := range parents[1:] {
+		if parent != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+var toggleDirectiveRegexp = regexp.MustCompile(`@(skip|include)\(\s*if\s*:\s*\$([_A-Za-z][_0-9A-Za-z]*)\s*\)`)
+
+// selectionToggles returns the variables of the @skip and @include directives of selection, with their default value
+func selectionToggles(selection string) map[string]bool {
+	matches := toggleDirectiveRegexp.FindAllStringSubmatch(selection, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+	toggles := make(map[string]bool, len(matches))
+	for _, m := range matches {
+		if _, ok := toggles[m[2]]; !ok {
+			toggles[m[2]] = m[1] == "include"
+		}
+	}
+	return toggles
+}
+
+// bindToggleVariables binds the query parameters of r named after the toggles to variables
+func bindToggleVariables(r *http.Request, toggles map[string]bool, params *graphql.RawParams) error {
+	query := r.URL.Query()
+	for name := range toggles {
+		values, ok := query[name]
+		if !ok || len(values) == 0 {
+			continue
+		}
+		s, err := coerceArgValue("Boolean", name, values[0])
+		if err != nil {
+			return err
+		}
+		if params.Variables == nil {
+			params.Variables = make(map[string]interface{})
+		}
+		params.Variables[name], _ = strconv.ParseBool(s)
+	}
+	return nil
+}
+
+// toggleDefinitions returns the variable definitions of toggles, eg. "$withUser:Boolean=true"
+func toggleDefinitions(toggles map[string]bool) []string {
+	defs := make([]string, 0, len(toggles))
+	for name, value := range toggles {
+		defs = append(defs, "$"+name+":Boolean="+strconv.FormatBool(value))
+	}
+	return defs
+}
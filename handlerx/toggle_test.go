@@ -0,0 +1,97 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsertFieldDirective(t *testing.T) {
+	selection := "{id,text,user{id,friends{id}},tags(first:1){name}}"
+
+	tests := []struct {
+		Path     string
+		Expected string
+		OK       bool
+	}{
+		{Path: "text", Expected: "{id,text @x,user{id,friends{id}},tags(first:1){name}}", OK: true},
+		{Path: "user", Expected: "{id,text,user @x{id,friends{id}},tags(first:1){name}}", OK: true},
+		{Path: "user.friends", Expected: "{id,text,user{id,friends @x{id}},tags(first:1){name}}", OK: true},
+		{Path: "user.id", Expected: "{id,text,user{id @x,friends{id}},tags(first:1){name}}", OK: true},
+		{Path: "tags", Expected: "{id,text,user{id,friends{id}},tags @x(first:1){name}}", OK: true},
+		{Path: "friends", Expected: selection},
+		{Path: "user.name", Expected: selection},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Path, func(t *testing.T) {
+			s, ok := insertFieldDirective(selection, strings.Split(tt.Path, "."), " @x")
+			assert.Equal(t, tt.OK, ok)
+			assert.Equal(t, tt.Expected, s)
+		})
+	}
+}
+
+func TestSetFieldToggle(t *testing.T) {
+	setupTestMapping()
+	defer setupTestMapping()
+	assert.NoError(t, RegisterOperation(http.MethodGet, "/todos/{id}/stats", "todoStats", "{id,stats @skip(if:$noStats){count}}", StringMap{"id": "ID!"}))
+	assert.NoError(t, SetFieldToggle("todos", "text", "withText"))
+	assert.Error(t, SetFieldToggle("todos", "user", "withUser"))
+	assert.Error(t, SetFieldToggle("unknown", "text", "withText"))
+
+	tests := []struct {
+		Name      string
+		Target    string
+		Route     string
+		Params    []string
+		Prefix    string
+		Suffix    string
+		Contains  string
+		Variables map[string]interface{}
+		Code      int
+	}{
+		{Name: "default", Target: "/todos", Route: "/todos", Prefix: `query($withText:Boolean=true) { todos`, Suffix: `{id,text @include(if:$withText)} }`},
+		{Name: "toggled off", Target: "/todos?withText=false&limit=1", Route: "/todos", Prefix: `query($withText:Boolean=true) { todos`, Suffix: `){id,text @include(if:$withText)} }`, Contains: "limit:1", Variables: map[string]interface{}{"withText": false}},
+		{Name: "invalid", Target: "/todos?withText=maybe", Route: "/todos", Code: http.StatusBadRequest},
+		{Name: "skip", Target: "/todos/1/stats?noStats=1", Route: "/todos/{id}/stats", Params: []string{"1"}, Prefix: `query($noStats:Boolean=false) { todoStats`, Suffix: `){id,stats @skip(if:$noStats){count}} }`, Contains: `id:"1"`, Variables: map[string]interface{}{"noStats": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			params := &graphql.RawParams{}
+			r := WithRoute(httptest.NewRequest(http.MethodGet, tt.Target, nil), tt.Route, tt.Params...)
+			query, err := convertHTTPRequestToGraphQLQuery(r, params, nil)
+			if tt.Code != 0 {
+				assert.Error(t, err)
+				assert.Equal(t, tt.Code, convertErrorCode(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(query, tt.Prefix), query)
+			assert.True(t, strings.HasSuffix(query, tt.Suffix), query)
+			assert.Contains(t, query, tt.Contains)
+			assert.Equal(t, tt.Variables, params.Variables)
+		})
+	}
+}
+
+func TestPersistedQueryToggle(t *testing.T) {
+	setupTestMapping()
+	RegisterPersistedQuery("h1", "query todos($withText:Boolean=true){todos{id,text @include(if:$withText)}}")
+	SetupPersistedQueries(StringMap{"todos": "h1"})
+	defer SetupPersistedQueries(StringMap{})
+
+	assert.Error(t, SetFieldToggle("todos", "text", "withText"))
+
+	params := &graphql.RawParams{}
+	r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos?withText=false&limit=1", nil), "/todos")
+	_, err := convertHTTPRequestToGraphQLQuery(r, params, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"limit": json.Number("1"), "withText": false}, params.Variables)
+}
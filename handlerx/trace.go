@@ -0,0 +1,63 @@
+package handlerx
+
+import (
+	"net/http"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/speedoops/go-gqlrest/handlerx"
+
+var (
+	_tracer          trace.Tracer
+	tracePropagation propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+)
+
+// SetTracerProvider enables OpenTelemetry tracing: a span named after the operation is started
+// for every request, as a child of the trace context propagated in the request headers.
+// Pass nil to disable tracing (default).
+func SetTracerProvider(tp trace.TracerProvider) {
+	if tp == nil {
+		_tracer = nil
+		return
+	}
+	_tracer = tp.Tracer(tracerName)
+}
+
+// startSpan starts the span of r, the returned func sets the span status from the response code
+// recorded by w and ends the span. It must be called once the response is written, even on panic.
+func startSpan(w http.ResponseWriter, r *http.Request) (*http.Request, func()) {
+	if _tracer == nil {
+		return r, func() {}
+	}
+
+	ctx := tracePropagation.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := _tracer.Start(ctx, observedOperation(r),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.method", r.Method),
+			attribute.String("http.target", r.URL.Path),
+		))
+
+	return r.WithContext(ctx), func() {
+		defer span.End()
+
+		rec, ok := w.(*statusRecorder)
+		if !ok {
+			return
+		}
+		code := rec.responseCode()
+		span.SetAttributes(attribute.Int("http.status_code", rec.status), attribute.Int("response.code", code))
+		failed := code >= http.StatusInternalServerError
+		if _, ok := rec.recordedCode(); ok {
+			failed = code != successCode
+		}
+		if failed {
+			span.SetStatus(codes.Error, "response code "+strconv.Itoa(code))
+		}
+	}
+}
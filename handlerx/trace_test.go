@@ -0,0 +1,49 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetTracerProvider(t *testing.T) {
+	defer SetTracerProvider(nil)
+
+	sr := tracetest.NewSpanRecorder()
+	SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+
+	tests := []struct {
+		Name     string
+		Data     string
+		Expected codes.Code
+	}{
+		{Name: "ok", Data: `{"todos":[]}`, Expected: codes.Unset},
+		{Name: "recovered from panic", Data: `{"todos":`, Expected: codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := withResponseState(httptest.NewRequest(http.MethodGet, "/todos", nil))
+			r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+			w, observe := wrapMetrics(httptest.NewRecorder(), r)
+			r, endSpan := startSpan(w, r)
+			writeJSON(w, r, &graphql.Response{Data: json.RawMessage(tt.Data)}, true)
+			endSpan()
+			observe()
+
+			spans := sr.Ended()
+			span := spans[len(spans)-1]
+			assert.Equal(t, "/todos", span.Name())
+			assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+			assert.Equal(t, tt.Expected, span.Status().Code)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package handlerx
+
+import (
+	"strings"
+)
+
+// variableType is the parsed GraphQL type of an operation argument or an input field, eg. "[ID!]!"
+type variableType struct {
+	isList bool
+	named  string // the underlying named type, eg. "ID"
+}
+
+// variableTypes caches the parsed types of the registered operation arguments and input fields, keyed by
+// the type as registered. It's built once by SetupHTTP2GraphQLMapping and only read afterwards, so it's
+// safe for concurrent requests.
+var variableTypes = map[string]variableType{"": {}}
+
+// buildVariableTypes parses the types of the operation arguments and input fields of the mapping
+func buildVariableTypes(arguments ArgTypeMap, inputTypes ArgTypeMap) map[string]variableType {
+	types := map[string]variableType{"": {}}
+	for _, argTypes := range []ArgTypeMap{arguments, inputTypes} {
+		for _, fields := range argTypes {
+			for _, typ := range fields {
+				if _, ok := types[typ]; !ok {
+					types[typ] = parseVariableType(typ)
+				}
+			}
+		}
+	}
+	return types
+}
+
+// parseVariableType parses a GraphQL type, eg. "[ID!]!" is a list of ID
+func parseVariableType(argType string) variableType {
+	isArray := strings.HasPrefix(argType, "[")
+
+	argType = strings.ReplaceAll(argType, "[", "")
+	argType = strings.ReplaceAll(argType, "]", "")
+	argType = strings.ReplaceAll(argType, "!", "")
+
+	return variableType{isList: isArray, named: argType}
+}
+
+// lookupVariableType returns the parsed type of argType, it's parsed on a cache miss, eg. for types not
+// registered by SetupHTTP2GraphQLMapping
+func lookupVariableType(argType string) variableType {
+	if typ, ok := variableTypes[argType]; ok {
+		return typ
+	}
+	dbgPrintf("variable type cache miss: %q", argType)
+	return parseVariableType(argType)
+}
@@ -0,0 +1,61 @@
+package handlerx
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingPrinter struct {
+	lines []string
+}
+
+func (p *recordingPrinter) Println(v ...interface{}) {}
+func (p *recordingPrinter) Printf(format string, v ...interface{}) {
+	p.lines = append(p.lines, fmt.Sprintf(format, v...))
+}
+
+func TestVariableTypes(t *testing.T) {
+	setupTestMapping()
+	printer := &recordingPrinter{}
+	RegisterPrinter(printer)
+	defer RegisterPrinter(nil)
+
+	tests := []struct {
+		Type   string
+		IsList bool
+		Named  string
+		Miss   bool
+	}{
+		{Type: "[ID!]", IsList: true, Named: "ID"},
+		{Type: "NewTodoInput!", Named: "NewTodoInput"},
+		{Type: "[TagInput!]", IsList: true, Named: "TagInput"},
+		{Type: "", Named: ""},
+		{Type: "[[Float!]]!", IsList: true, Named: "Float", Miss: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Type, func(t *testing.T) {
+			printer.lines = nil
+			isList, named := getUnderlayingArgType(tt.Type)
+			assert.Equal(t, tt.IsList, isList)
+			assert.Equal(t, tt.Named, named)
+			if tt.Miss {
+				assert.Equal(t, []string{fmt.Sprintf("variable type cache miss: %q", tt.Type)}, printer.lines)
+			} else {
+				assert.Empty(t, printer.lines)
+			}
+		})
+	}
+
+	// a RESTful request only looks up cached types
+	printer.lines = nil
+	r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos?ids=1&ids=2&limit=3", nil), "/todos")
+	_, err := convertHTTPRequestParamsToGraphQLQuery(r, &graphql.RawParams{}, map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Empty(t, printer.lines)
+}
@@ -1,127 +1,891 @@
 package handlerx
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
 func jsonDecode(r io.Reader, val interface{}) error {
-	dec := json.NewDecoder(r)
+	dec := jsonCodec.NewDecoder(r)
 	dec.UseNumber()
 	return dec.Decode(val)
 }
 
-func statusFor(errs gqlerror.List) int {
-	switch errcode.GetErrorKind(errs) {
-	case errcode.KindProtocol:
+// jsonDecodeStrict is like jsonDecode, but rejects unknown fields
+func jsonDecodeStrict(r io.Reader, val interface{}) error {
+	dec := jsonCodec.NewDecoder(r)
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+	return dec.Decode(val)
+}
+
+var strictDecoding bool
+
+// SetStrictDecoding makes request bodies with unknown fields rejected with 400 instead of
+// being silently ignored. For RESTful requests, body fields are checked against the operation
+// arguments and input types.
+func SetStrictDecoding(strict bool) {
+	strictDecoding = strict
+}
+
+var maxRequestBytes int64
+
+// SetMaxRequestBytes limits the size of request bodies, larger bodies are rejected with 413.
+// Multipart bodies are limited by the smaller of it and the SetMultipartLimits one.
+// Default is 0, which means unlimited.
+func SetMaxRequestBytes(n int64) {
+	maxRequestBytes = n
+}
+
+// limitRequestBody applies the SetMaxRequestBytes limit to the request body
+func limitRequestBody(w http.ResponseWriter, r *http.Request) {
+	if maxRequestBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+	}
+}
+
+// readRequestBody reads the whole request body, and writes an error response if it fails
+func readRequestBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, ok := readRawRequestBody(w, r)
+	if !ok {
+		return nil, false
+	}
+	body, err := transformRequestBody(r, body)
+	if err != nil {
+		isRESTful := isRESTRoute(r)
+		writeHeader(w, r, http.StatusBadRequest, isRESTful)
+		writeJSONErrorf(w, r, http.StatusBadRequest, isRESTful, "request body could not be transformed: "+err.Error())
+		return nil, false
+	}
+	return body, true
+}
+
+// readRawRequestBody reads the whole request body as sent, and writes an error response if it fails
+func readRawRequestBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	limitRequestBody(w, r)
+	body, err := ioutil.ReadAll(r.Body)
+	if err == nil {
+		return body, true
+	}
+
+	isRESTful := isRESTRoute(r)
+	if isBodyTooLarge(err) {
+		writeHeader(w, r, http.StatusRequestEntityTooLarge, isRESTful)
+		writeJSONErrorWithExtensions(w, r, http.StatusRequestEntityTooLarge, isRESTful, fmt.Sprintf("request body exceeds %d bytes", maxRequestBytes),
+			map[string]interface{}{"reason": "BODY_TOO_LARGE", "retryable": false})
+		return nil, false
+	}
+	writeHeader(w, r, http.StatusBadRequest, isRESTful)
+	writeJSONErrorf(w, r, http.StatusBadRequest, isRESTful, "request body could not be read: "+err.Error())
+	return nil, false
+}
+
+var errorStatusMapper func(errs gqlerror.List) int
+
+// SetErrorStatusMapper sets fn to override the HTTP status of the requests whose operation can't be
+// created, eg. failing to parse or validate, or rejected by an operation parameter or context mutator
+// (persisted queries, complexity limit). fn returns the HTTP status, or 0 to keep the default one:
+// 422 for GraphQL parse and validation errors, otherwise 200 as usual for GraphQL, unless the request
+// is RESTful with its status derived from the response code (SetHTTPStatusEnabled, problem details or
+// raw output), which is then used. The `code` of the response body is not changed. Pass nil to remove it.
+func SetErrorStatusMapper(fn func(errs gqlerror.List) int) {
+	errorStatusMapper = fn
+}
+
+// writeOperationError writes the errors of an operation which can't be created, see SetErrorStatusMapper
+func writeOperationError(w http.ResponseWriter, req *http.Request, exec graphql.GraphExecutor, rc *graphql.OperationContext, errs gqlerror.List, isRESTful bool) {
+	resp := exec.DispatchError(graphql.WithOperationContext(req.Context(), rc), errs)
+	writeJSONWithStatus(w, req, resp, isRESTful, errorStatus(req, errs, isRESTful))
+}
+
+// errorStatus returns the HTTP status of the errors of an operation which can't be created, or 0 to leave
+// it to the response: 200, or the status derived from the response code, see SetErrorStatusMapper
+func errorStatus(req *http.Request, errs gqlerror.List, isRESTful bool) int {
+	if errorStatusMapper != nil {
+		if status := errorStatusMapper(errs); status >= 100 && status <= 599 {
+			return status
+		}
+	}
+	if isRESTful && isStatusFromCode(req) {
+		return 0
+	}
+	if errcode.GetErrorKind(errs) == errcode.KindProtocol {
 		return http.StatusUnprocessableEntity
-	default:
-		return http.StatusOK
 	}
+	return 0
 }
 
 // RESTResponse is response struct for RESTful API call
 // @see graphql.Response
 type RESTResponse struct {
-	Code    int             `json:"code"`
-	Message string          `json:"message,omitempty"`
-	Data    json.RawMessage `json:"data"`
+	Code    int                    `json:"code"`
+	Message string                 `json:"message,omitempty"`
+	Data    json.RawMessage        `json:"data"`
+	Meta    map[string]interface{} `json:"meta,omitempty"`
+	Errors  []RESTError            `json:"errors,omitempty"`
+	Fields  map[string]string      `json:"fields,omitempty"`
+	DryRun  bool                   `json:"dry_run,omitempty"`
+
+	RequestID string `json:"request_id,omitempty"`
+	ErrorID   string `json:"error_id,omitempty"`
+
+	Debug *RESTDebug `json:"_debug,omitempty"`
+
+	omitData bool // see NullDataPolicy
+	status   int  // the HTTP status mapped from the result or the errors, see SetResultStatusMapper and SetErrorStatusMapper
+}
+
+// RESTError is a single GraphQL error reported in verbose mode
+type RESTError struct {
+	Message    string                 `json:"message"`
+	Path       ast.Path               `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+var verboseErrors bool
+
+// SetVerboseErrors enables reporting every GraphQL error in the `errors` field of
+// RESTful responses, in addition to the joined message. The `code` of the response is
+// then the code of the first error, instead of the last error with a code.
+func SetVerboseErrors(verbose bool) {
+	verboseErrors = verbose
+}
+
+var (
+	partialSuccessMode bool
+	partialSuccessCode = http.StatusMultiStatus
+)
+
+// SetPartialSuccessMode makes RESTful responses with both non-null `data` and errors keep the data,
+// with the partial success code (207 by default) and every error in the `errors` field. By default
+// the response code is the code of the first error.
+func SetPartialSuccessMode(enabled bool) {
+	partialSuccessMode = enabled
+}
+
+// SetPartialSuccessCode sets the `code` of partial success responses, see SetPartialSuccessMode
+func SetPartialSuccessCode(code int) {
+	partialSuccessCode = code
 }
 
 var numRegexp = regexp.MustCompile(`^\d+$`)
 
-func writeJSON(w io.Writer, r *graphql.Response, isRESTful bool) {
-	// 1. For GraphQL API
-	if !isRESTful {
-		b, err := json.Marshal(r)
-		if err != nil {
-			panic(err)
+var fieldErrorMode bool
+
+// SetFieldErrorMode reports errors with a path in the `fields` field of RESTful responses,
+// keyed by the dotted path, eg. {"fields": {"user.email": "invalid format"}}.
+// Errors without a path are still reported in `message`.
+func SetFieldErrorMode(enabled bool) {
+	fieldErrorMode = enabled
+}
+
+var maxMessageLength int
+
+// SetMaxMessageLength truncates the joined error message of RESTful responses to n runes, the messages
+// left out are counted, eg. "not found; invalid id... (+5 more)". The verbose `errors` are not truncated.
+// Pass 0 for no limit (default).
+func SetMaxMessageLength(n int) {
+	maxMessageLength = n
+}
+
+// joinMessages joins error messages with "; ", truncated to maxMessageLength runes
+func joinMessages(msgs []string) string {
+	joined := strings.Join(msgs, "; ")
+	if maxMessageLength <= 0 || utf8.RuneCountInString(joined) <= maxMessageLength {
+		return joined
+	}
+
+	for shown := len(msgs) - 1; shown >= 1; shown-- {
+		head := strings.Join(msgs[:shown], "; ")
+		suffix := fmt.Sprintf("... (+%d more)", len(msgs)-shown)
+		if utf8.RuneCountInString(head)+utf8.RuneCountInString(suffix) <= maxMessageLength {
+			return head + suffix
 		}
-		_, err = w.Write(b)
-		if err != nil {
-			panic(err)
+	}
+
+	// even the first message is too long, cut it
+	suffix := "..."
+	if len(msgs) > 1 {
+		suffix = fmt.Sprintf("... (+%d more)", len(msgs)-1)
+	}
+	first := []rune(msgs[0])
+	keep := maxMessageLength - utf8.RuneCountInString(suffix)
+	if keep < 0 {
+		keep = 0
+	}
+	if keep > len(first) {
+		keep = len(first)
+	}
+	return string(first[:keep]) + suffix
+}
+
+var errorCodeMapper func(*gqlerror.Error) int
+
+// SetErrorCodeMapper overrides how the response code is derived from a GraphQL error.
+// Pass nil to restore the default mapping from the `code` extension.
+func SetErrorCodeMapper(mapper func(*gqlerror.Error) int) {
+	errorCodeMapper = mapper
+}
+
+var errorCodeTable = make(map[string]int)
+
+// SetErrorCodeTable maps named `code` extensions of GraphQL errors to response codes,
+// eg. {"NOT_FOUND": 404, "FORBIDDEN": 403}. Numeric codes are used as-is, and unmapped codes are
+// reported as 500 (or 422 for GraphQL validation and parse errors).
+func SetErrorCodeTable(table map[string]int) {
+	errorCodeTable = make(map[string]int, len(table))
+	for k, v := range table {
+		errorCodeTable[k] = v
+	}
+}
+
+// errorCode derives the response code from a GraphQL error
+func errorCode(e *gqlerror.Error) int {
+	if code, ok := mappedErrorCode(e); ok {
+		return code
+	}
+	if code, _ := e.Extensions["code"].(string); code == errcode.ValidationFailed || code == errcode.ParseFailed {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+// responseErrorCode derives the response code from the GraphQL errors of a response: the code of the
+// first error in verbose mode (the errors are all reported), otherwise the last error with a `code`
+// extension, as always reported before verbose mode was added
+func responseErrorCode(errs gqlerror.List) int {
+	if !verboseErrors {
+		for i := len(errs) - 1; i > 0; i-- {
+			if _, ok := errs[i].Extensions["code"]; ok {
+				return errorCode(errs[i])
+			}
+		}
+	}
+	return errorCode(errs[0])
+}
+
+// mappedErrorCode derives the response code from a GraphQL error, false if its code is neither numeric nor mapped
+func mappedErrorCode(e *gqlerror.Error) (int, bool) {
+	if errorCodeMapper != nil {
+		return errorCodeMapper(e), true
+	}
+
+	code := strconv.Itoa(http.StatusUnprocessableEntity)
+	if n, ok := e.Extensions["code"]; ok {
+		switch v := n.(type) {
+		case int: // set by writeJSONError
+			code = strconv.Itoa(v)
+		case int32:
+			code = strconv.FormatInt(int64(v), 10)
+		case int64:
+			code = strconv.FormatInt(v, 10)
+		case float64: // decoded from JSON, eg. by a remote resolver
+			code = strconv.FormatFloat(v, 'f', -1, 64)
+		case json.Number:
+			code = v.String()
+		default:
+			code, _ = n.(string)
+		}
+	}
+
+	if numRegexp.MatchString(code) {
+		// if code is number string
+		n, _ := strconv.Atoi(code)
+		return n, true
+	}
+	n, ok := errorCodeTable[code]
+	return n, ok
+}
+
+var successCode = 0
+
+// SetSuccessCode sets the `code` of successful RESTful responses, default is 0
+func SetSuccessCode(code int) {
+	successCode = code
+}
+
+var successMessage string
+
+// SetSuccessMessage sets the `message` of successful RESTful responses, eg. "OK", default is empty,
+// which omits it. Error responses keep their error message.
+func SetSuccessMessage(msg string) {
+	successMessage = msg
+}
+
+var httpStatusEnabled bool
+
+// SetHTTPStatusEnabled makes RESTful responses carry the response code as the real
+// HTTP status too. By default the HTTP status is always 200 and the code is only
+// reported in the body.
+func SetHTTPStatusEnabled(enabled bool) {
+	httpStatusEnabled = enabled
+}
+
+var statusRewriter func(code int) int
+
+// SetStatusRewriter sets fn to rewrite the real HTTP status derived from the `code` of RESTful responses,
+// eg. to report business codes misclassified as 500 by the fallback as 400. fn is called with the response
+// code, it returns the HTTP status to write, or 0 to keep the default one. The `code` of the response body
+// is not changed. Pass nil to remove it.
+func SetStatusRewriter(fn func(code int) int) {
+	statusRewriter = fn
+}
+
+// httpStatusForCode converts a response code to a valid HTTP status, see SetStatusRewriter
+func httpStatusForCode(code int) int {
+	if statusRewriter != nil {
+		if status := statusRewriter(code); status >= 100 && status <= 599 {
+			return status
 		}
+	}
+	if code == successCode {
+		return http.StatusOK
+	}
+	if code < 100 || code > 599 {
+		return http.StatusInternalServerError
+	}
+	return code
+}
+
+var successStatuses = make(map[string]int)
+
+// SetSuccessStatus sets the real HTTP status of successful RESTful responses of operation opName,
+// eg. 201 Created or 202 Accepted, regardless of SetHTTPStatusEnabled. The `code` of the response
+// body is not changed. Pass a status <= 0 to restore the default.
+func SetSuccessStatus(opName string, status int) {
+	if status <= 0 {
+		delete(successStatuses, opName)
+	} else {
+		successStatuses[opName] = status
+	}
+}
+
+var locationBuilders = make(map[string]func(data json.RawMessage) (string, bool))
+
+// SetLocationBuilder registers fn to build the Location header of successful RESTful responses of
+// operation opName from the response `data`, eg. "/todos/1" for a created todo. No header is set
+// when fn returns false. Use it with SetSuccessStatus(opName, http.StatusCreated). Pass a nil fn
+// to remove the builder.
+func SetLocationBuilder(opName string, fn func(data json.RawMessage) (string, bool)) {
+	if fn == nil {
+		delete(locationBuilders, opName)
+	} else {
+		locationBuilders[opName] = fn
+	}
+}
+
+// setLocation sets the Location header built from the response data of operation opName
+func setLocation(w http.ResponseWriter, opName string, data json.RawMessage) {
+	fn, ok := locationBuilders[opName]
+	if !ok {
+		return
+	}
+	if location, ok := fn(data); ok {
+		w.Header().Set("Location", location)
+	}
+}
+
+// successStatus returns the HTTP status mapped from the result of response, or registered for
+// successful responses to req
+func successStatus(req *http.Request, response *RESTResponse) (int, bool) {
+	if response.status > 0 {
+		return response.status, true
+	}
+	if len(successStatuses) == 0 {
+		return 0, false
+	}
+	opName, ok := restOperationName(req)
+	if !ok {
+		return 0, false
+	}
+	status, ok := successStatuses[opName]
+	return status, ok
+}
+
+// writeStatus writes the HTTP status of a RESTful response, if it's not left to the default 200
+func writeStatus(w http.ResponseWriter, req *http.Request, response *RESTResponse, success bool) {
+	if status, ok := successStatus(req, response); ok && success {
+		w.WriteHeader(status)
+		return
+	}
+	if response.status > 0 || httpStatusEnabled {
+		w.WriteHeader(errorResponseStatus(response))
+	}
+}
+
+// errorResponseStatus returns the HTTP status of a RESTful error response: the status mapped from
+// the errors, otherwise derived from the response code
+func errorResponseStatus(response *RESTResponse) int {
+	if response.status > 0 {
+		return response.status
+	}
+	return httpStatusForCode(response.Code)
+}
+
+// writeHeader writes the HTTP status, unless it will be derived from the response code
+func writeHeader(w http.ResponseWriter, req *http.Request, status int, isRESTful bool) {
+	if isRESTful && isStatusFromCode(req) {
 		return
 	}
+	w.WriteHeader(status)
+}
+
+// isStatusFromCode reports whether the HTTP status of RESTful error responses to req is derived from the response code
+func isStatusFromCode(req *http.Request) bool {
+	return httpStatusEnabled || errorFormat == ProblemJSON || isRawOutput(req)
+}
+
+var unwrapSingleField = true
+
+// SetUnwrapSingleField controls whether the top level data member of a GraphQL response
+// is unwrapped as the `data` of RESTful responses. It is enabled by default, disable it
+// to keep the full data map when a REST endpoint selects several root fields.
+func SetUnwrapSingleField(unwrap bool) {
+	unwrapSingleField = unwrap
+}
+
+// unwrapData returns the first top level member of GraphQL response data
+func unwrapData(data json.RawMessage) json.RawMessage {
+	var m map[string]json.RawMessage
+	err := jsonCodec.Unmarshal(data, &m)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, v := range m {
+		return v // it's ok to return here, because graphql response data will have only one top struct member
+	}
+	return data
+}
+
+var scalarWrapKey string
+
+// SetScalarWrapKey makes the scalar `data` of RESTful responses wrapped as an object with the member
+// key, eg. {"data":{"value":42}} instead of {"data":42} with SetScalarWrapKey("value"). Objects, lists
+// and null are not wrapped. Pass an empty key to disable it (default).
+func SetScalarWrapKey(key string) {
+	scalarWrapKey = key
+}
 
-	// 1.1 recover from panic
+// wrapScalar wraps the scalar data as an object with the scalar wrap key
+func wrapScalar(data json.RawMessage) (json.RawMessage, error) {
+	if scalarWrapKey == "" {
+		return data, nil
+	}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] == '{' || trimmed[0] == '[' || string(trimmed) == "null" {
+		return data, nil
+	}
+	key, err := json.Marshal(scalarWrapKey)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(`{` + string(key) + `:` + string(trimmed) + `}`), nil
+}
+
+var unwrapFields = make(StringMap)
+
+// SetUnwrapField names the top level member of the GraphQL response data unwrapped as the `data` of
+// RESTful responses of operation opName, instead of the first member. Responses missing the member
+// are reported as 500. Pass an empty fieldName to restore the default.
+func SetUnwrapField(opName, fieldName string) {
+	if fieldName == "" {
+		delete(unwrapFields, opName)
+	} else {
+		unwrapFields[opName] = fieldName
+	}
+}
+
+// unwrapNamedField returns the top level member named field of GraphQL response data
+func unwrapNamedField(data json.RawMessage, field string) (json.RawMessage, bool) {
+	var m map[string]json.RawMessage
+	err := jsonCodec.Unmarshal(data, &m)
+	if err != nil {
+		panic(err)
+	}
+
+	v, ok := m[field]
+	return v, ok
+}
+
+var unwrapPaths = make(map[string][]string)
+
+// SetUnwrapPath walks path into the unwrapped `data` of RESTful responses of operation opName, for payloads
+// wrapped under several levels, eg. []string{"result", "payload"} responds the `result.payload` of the
+// operation field. Responses missing a segment of the path keep the one-level unwrapped data.
+// Pass an empty path to remove it.
+func SetUnwrapPath(opName string, path []string) {
+	if len(path) == 0 {
+		delete(unwrapPaths, opName)
+	} else {
+		unwrapPaths[opName] = append([]string(nil), path...)
+	}
+}
+
+// unwrapDataPath returns the member of data at path
+func unwrapDataPath(data json.RawMessage, path []string) (json.RawMessage, bool) {
+	for _, segment := range path {
+		var m map[string]json.RawMessage
+		if err := jsonCodec.Unmarshal(data, &m); err != nil || m == nil {
+			return nil, false
+		}
+		v, ok := m[segment]
+		if !ok {
+			return nil, false
+		}
+		data = v
+	}
+	return data, true
+}
+
+var noContentOnEmpty bool
+
+// SetNoContentOnEmpty makes successful RESTful mutations with null `data` respond 204 No Content
+// without a body, instead of {"code":0,"data":null}.
+func SetNoContentOnEmpty(enabled bool) {
+	noContentOnEmpty = enabled
+}
+
+// isEmptyData reports whether the unwrapped data is null or missing
+func isEmptyData(data json.RawMessage) bool {
+	trimmed := strings.TrimSpace(string(data))
+	return trimmed == "" || trimmed == "null"
+}
+
+var internalErrorMessage = "unexpected error: unmarshal or write response error"
+
+// SetInternalErrorMessage sets the message of RESTful responses when writing the response failed,
+// the details are only reported to the registered Printer.
+func SetInternalErrorMessage(msg string) {
+	internalErrorMessage = msg
+}
+
+var internalErrorID bool
+
+// SetInternalErrorID enables a random `error_id` in RESTful responses when writing the response failed,
+// it's also reported to the registered Printer along with the stack, for support correlation.
+func SetInternalErrorID(enabled bool) {
+	internalErrorID = enabled
+}
+
+var panicHandler func(recovered interface{}, stack []byte, w http.ResponseWriter, isRESTful bool)
+
+// SetPanicHandler sets fn to write the response when writing a response panics, instead of the 500 envelope
+// with the internal error message, eg. to report the panic to an error tracker and respond an incident ID.
+// fn is called with the recovered value and the stack of the panic, it's also called for GraphQL responses,
+// which otherwise panic again. Pass nil to restore the default.
+func SetPanicHandler(fn func(recovered interface{}, stack []byte, w http.ResponseWriter, isRESTful bool)) {
+	panicHandler = fn
+}
+
+func writeJSON(w http.ResponseWriter, req *http.Request, r *graphql.Response, isRESTful bool) {
+	writeJSONWithStatus(w, req, r, isRESTful, 0)
+}
+
+// writeJSONWithStatus is like writeJSON, with the HTTP status of the errors of r, 0 to leave it to the response
+func writeJSONWithStatus(w http.ResponseWriter, req *http.Request, r *graphql.Response, isRESTful bool, errorStatus int) {
+	// 1. recover from panic
 	defer func() {
+		if !isRESTful && panicHandler == nil {
+			return // GraphQL responses panic as usual
+		}
 		if err := recover(); err != nil {
+			markResponseErrors(req)
 			var buf [4096]byte
 			n := runtime.Stack(buf[:], false)
-			dbgPrintf("restful response recover from panic:%v", string(buf[:n]))
+			if panicHandler != nil {
+				panicHandler(err, buf[:n], w, isRESTful)
+				return
+			}
+
+			var errorID string
+			if internalErrorID {
+				errorID = newRequestID()
+			}
+			ctxPrintf(req, "restful response recover from panic (error_id=%s): %v\n%v", errorID, err, string(buf[:n]))
 
 			r := &RESTResponse{
-				Code:    http.StatusInternalServerError,
-				Message: "unexpected error: unmarshal or write response error",
+				Code:      http.StatusInternalServerError,
+				Message:   internalErrorMessage,
+				RequestID: bodyRequestID(req),
+				ErrorID:   errorID,
+			}
+			applyNullDataPolicy(r)
+			if isRawOutput(req) {
+				writeRawOutput(w, req, r, false)
+				return
+			}
+			if errorFormat == ProblemJSON {
+				writeProblem(w, req, r)
+				return
+			}
+
+			content, _ := marshalJSON(r)
+			recordCode(req, r.Code)
+			setCacheControl(w, req, false)
+			w.Header().Set("Content-Type", jsonContentType)
+			if httpStatusEnabled {
+				w.WriteHeader(r.Code)
 			}
-			content, _ := json.Marshal(r)
 			if _, err := w.Write(content); err != nil {
 				panic(err)
 			}
 		}
 	}()
 
+	if len(r.Errors) > 0 {
+		markResponseErrors(req)
+	}
+
+	// 1.1 For GraphQL API
+	if !isRESTful {
+		w.Header().Set("Content-Type", jsonContentType)
+		b, err := marshalJSON(r)
+		if err != nil {
+			panic(err)
+		}
+		if errorStatus > 0 {
+			w.WriteHeader(errorStatus)
+		}
+		_, err = w.Write(b)
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	// 2. For RESTful API
 	response := &RESTResponse{
-		Code: 0,
-		Data: r.Data,
+		Code:      successCode,
+		Message:   successMessage,
+		Data:      r.Data,
+		RequestID: bodyRequestID(req),
 	}
 
-	if len(r.Data) > 0 {
-		var m map[string]json.RawMessage
-		err := json.Unmarshal(r.Data, &m)
+	if req != nil {
+		if state := responseStateFromContext(req.Context()); state != nil {
+			response.Debug = state.debug
+		}
+		response.DryRun = DryRunFromContext(req.Context())
+	}
+
+	opName, _ := restOperationName(req)
+	if len(r.Data) > 0 && len(enumMappings[opName]) > 0 {
+		data, err := mapResponseEnums(opName, r.Data)
 		if err != nil {
+			dbgPrintf("restful response enum mapping of %s failed: %v", opName, err)
 			panic(err)
 		}
-
-		for _, v := range m {
-			response.Data = v
-			break // it's ok to break here, because graphql response data will have only one top struct member
+		mapped := *r
+		mapped.Data = data
+		r = &mapped
+	}
+	if field, ok := unwrapFields[opName]; ok && len(r.Data) > 0 {
+		data, found := unwrapNamedField(r.Data, field)
+		if !found && len(r.Errors) == 0 {
+			r = &graphql.Response{Errors: gqlerror.List{{
+				Message:    fmt.Sprintf("unwrap field %q of %s is missing in response data", field, opName),
+				Extensions: map[string]interface{}{"code": http.StatusInternalServerError},
+			}}}
+		}
+		response.Data = data
+	} else if len(r.Data) > 0 && unwrapSingleField {
+		response.Data = unwrapData(r.Data)
+	}
+	if path, ok := unwrapPaths[opName]; ok && len(response.Data) > 0 && !isEmptyData(response.Data) {
+		if data, found := unwrapDataPath(response.Data, path); found {
+			response.Data = data
+		} else {
+			dbgPrintf("unwrap path %s of %s is missing in response data", strings.Join(path, "."), opName)
+		}
+	}
+	unwrappedData := response.Data // before transforms, which may flatten the connection or drop fields
+	// the transforms of operation opName and the registered data transforms, then the null members are
+	// omitted, the large ints stringified and the scalars wrapped if they're enabled
+	streamArray := false
+	if len(response.Data) > 0 {
+		data, err := reshapeData(opName, response.Data)
+		if err == nil && isStreamableArray(req, opName, r, data) {
+			streamArray = true // the elements are transformed while streaming
+		} else if err == nil {
+			if data, err = transformValues(data); err == nil {
+				data, err = wrapScalar(data)
+			}
+		}
+		if err != nil {
+			dbgPrintf("restful response transform of %s failed: %v", opName, err)
+			panic(err)
+		}
+		response.Data = data
+	}
+	locationData := response.Data // before fields filtering, which may drop the id
+	if tree := requestedFields(req, opName); tree != nil && len(r.Errors) == 0 && len(response.Data) > 0 && !streamArray {
+		data, unknown, err := filterFields(response.Data, tree)
+		if err != nil {
+			panic(err)
+		}
+		response.Data = data
+		if len(unknown) > 0 && strictFieldFiltering {
+			response.Data = nil
+			r = &graphql.Response{Errors: gqlerror.List{{
+				Message:    "unknown fields: " + strings.Join(unknown, ", "),
+				Extensions: map[string]interface{}{"code": http.StatusUnprocessableEntity},
+			}}}
 		}
 	}
 
+	partial := len(r.Errors) > 0 && partialSuccessMode && !isEmptyData(response.Data)
+	if len(r.Errors) == 0 {
+		applyResultStatus(response, opName, unwrappedData)
+		applyListMeta(response, opName, unwrappedData)
+	}
 	if len(r.Errors) > 0 {
-		code, msgs := strconv.Itoa(http.StatusUnprocessableEntity), []string{}
+		markResponseErrors(req) // eg. unknown fields
+		recordErrorMessage(req, r.Errors)
+		msgs := []string{}
 		for _, e := range r.Errors {
-			if n, ok := e.Extensions["code"]; ok {
-				code, _ = n.(string)
-			}
-			if len(e.Path) > 0 {
-				msgs = append(msgs, e.Message+" "+e.Path.String())
+			message := sanitizeMessage(req, e)
+			if len(e.Path) > 0 && fieldErrorMode {
+				if response.Fields == nil {
+					response.Fields = make(map[string]string)
+				}
+				path := e.Path.String()
+				if msg, ok := response.Fields[path]; ok {
+					response.Fields[path] = msg + "; " + message
+				} else {
+					response.Fields[path] = message
+				}
+			} else if len(e.Path) > 0 {
+				msgs = append(msgs, message+" "+e.Path.String())
 			} else {
-				msgs = append(msgs, e.Message)
+				msgs = append(msgs, message)
+			}
+			if verboseErrors || partial {
+				response.Errors = append(response.Errors, RESTError{
+					Message:    message,
+					Path:       e.Path,
+					Extensions: e.Extensions,
+				})
 			}
 		}
 
-		if numRegexp.MatchString(code) {
-			// if code is number string
-			response.Code, _ = strconv.Atoi(code)
-		} else {
-			if code == errcode.ValidationFailed || code == errcode.ParseFailed {
-				response.Code = http.StatusUnprocessableEntity
-			} else {
-				response.Code = http.StatusInternalServerError
+		response.Code = concurrencyCode(opName, responseErrorCode(r.Errors))
+		if partial {
+			response.Code = partialSuccessCode
+		}
+		response.Message = localizeMessage(req, response.Code, joinMessages(msgs))
+		if !partial {
+			response.status = errorStatus
+			applyNullDataPolicy(response)
+		}
+	}
+
+	if len(r.Errors) == 0 && writeBinaryField(w, req, opName, response) {
+		return
+	}
+	if isRawOutput(req) {
+		if len(r.Errors) == 0 {
+			setLocation(w, opName, locationData)
+			setLinks(w, req, opName, unwrappedData)
+		}
+		writeRawOutput(w, req, response, len(r.Errors) == 0)
+		return
+	}
+
+	if len(r.Errors) > 0 && !partial && errorFormat == ProblemJSON {
+		writeProblem(w, req, response)
+		return
+	}
+
+	contentType := responseContentType(req)
+	success := len(r.Errors) == 0
+	var csvBody []byte
+	if contentType == "text/csv" {
+		if success {
+			var err error
+			if csvBody, err = encodeCSV(response.Data); err != nil && !csvFallbackJSON {
+				success = false
+				markResponseErrors(req)
+				response.Code = http.StatusNotAcceptable
+				response.Message = "text/csv is not acceptable: " + err.Error()
+				response.Data = nil
 			}
 		}
+		if csvBody == nil {
+			contentType = "application/json"
+		}
+	}
 
-		response.Message = strings.Join(msgs, "; ")
+	setCacheControl(w, req, success)
+	if success {
+		setLocation(w, opName, locationData)
+		setLinks(w, req, opName, unwrappedData)
+	}
+	if noContentOnEmpty && success && isEmptyData(response.Data) && req != nil && req.Method != http.MethodGet && req.Method != http.MethodHead {
+		recordCode(req, response.Code)
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if success && isETagEnabled(req) {
+		etag := computeETag(contentType, response.Data)
+		if state := responseStateFromContext(req.Context()); state != nil && state.etag != "" {
+			etag = state.etag
+		}
+		w.Header().Set("ETag", etag)
+		if etagMatches(req.Header.Get("If-None-Match"), etag) {
+			w.Header().Del("Content-Type")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
 	}
 
-	b, err := json.Marshal(response)
+	recordCode(req, response.Code)
+
+	var b []byte
+	var err error
+	switch contentType {
+	case "text/csv":
+		b = csvBody
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+csvFilename(req)+`"`)
+	case "application/json":
+		w.Header().Set("Content-Type", jsonContentType)
+		if streamArray {
+			writeStreamingArray(w, req, opName, response)
+			return
+		}
+		if streamingJSON {
+			writeStreamingJSON(w, req, response, success)
+			return
+		}
+		b, err = marshalJSON(response)
+	default:
+		b, err = xml.Marshal(response)
+		w.Header().Set("Content-Type", contentType)
+	}
 	if err != nil {
 		panic(err)
 	}
+	writeStatus(w, req, response, success)
 	_, err = w.Write(b)
 	if err != nil {
 		//logx.Errorf("an io write error occurred: %v", err)
@@ -129,18 +893,100 @@ func writeJSON(w io.Writer, r *graphql.Response, isRESTful bool) {
 	}
 }
 
-func writeJSONError(w io.Writer, code int, isRESTful bool, msg string) {
-	err := gqlerror.Error{
-		Message:    msg,
-		Extensions: map[string]interface{}{"code": code}}
-	writeJSON(w, &graphql.Response{Errors: gqlerror.List{&err}}, isRESTful)
+// jsonContentType is the Content-Type header of JSON responses
+var jsonContentType = "application/json; charset=utf-8"
+
+// SetContentType overrides the Content-Type header of JSON responses, "application/json; charset=utf-8"
+// by default, eg. "application/vnd.api+json". Negotiated XML and CSV responses keep their own type.
+// Pass an empty string to restore the default.
+func SetContentType(contentType string) {
+	if contentType == "" {
+		contentType = "application/json; charset=utf-8"
+	}
+	jsonContentType = contentType
+}
+
+var streamingJSON bool
+
+// SetStreamingJSON makes RESTful JSON responses stream to the writer instead of being
+// marshaled into a buffer first, the `data` member is copied as-is without re-marshaling.
+func SetStreamingJSON(streaming bool) {
+	streamingJSON = streaming
+}
+
+func writeStreamingJSON(w http.ResponseWriter, req *http.Request, response *RESTResponse, success bool) {
+	writeStatus(w, req, response, success)
+	var out io.Writer = w
+	if prettyJSON {
+		out = &indentWriter{w: w}
+	}
+	n, err := response.WriteTo(out)
+	if err == nil {
+		return
+	}
+	if n == 0 {
+		// nothing has been written yet, let the recover block write an error envelope
+		panic(err)
+	}
+	// the envelope is partially written, there's nothing better to do than stop here
+	dbgPrintf("restful response aborted after %d bytes: %v", n, err)
+}
+
+func writeJSONError(w http.ResponseWriter, req *http.Request, code int, isRESTful bool, msg string) {
+	writeJSONErrorWithExtensions(w, req, code, isRESTful, msg, nil)
+}
+
+func writeJSONErrorf(w http.ResponseWriter, req *http.Request, code int, isRESTful bool, format string, args ...interface{}) {
+	writeJSONErrorWithExtensions(w, req, code, isRESTful, fmt.Sprintf(format, args...), nil)
 }
 
-func writeJSONErrorf(w io.Writer, code int, isRESTful bool, format string, args ...interface{}) {
+// writeConvertError writes the error of converting a RESTful request, the fields of a
+// ValidationError are reported as errors with a path, like GraphQL field errors.
+func writeConvertError(w http.ResponseWriter, req *http.Request, err error, isRESTful bool, msg string) {
+	var nfe *persistedQueryNotFoundError
+	var pre *preconditionRequiredError
+	var pfe *preconditionFailedError
+	if errors.As(err, &nfe) || errors.As(err, &pre) || errors.As(err, &pfe) {
+		writeJSONError(w, req, convertErrorCode(err), isRESTful, err.Error())
+		return
+	}
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		writeJSONErrorf(w, req, convertErrorCode(err), isRESTful, msg+": "+err.Error())
+		return
+	}
+
+	keys := make([]string, 0, len(ve.Fields))
+	for k := range ve.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	errs := make(gqlerror.List, 0, len(keys))
+	for _, k := range keys {
+		errs = append(errs, &gqlerror.Error{
+			Message:    ve.Fields[k],
+			Path:       ve.paths[k],
+			Extensions: map[string]interface{}{"code": http.StatusUnprocessableEntity},
+		})
+	}
+	writeJSON(w, req, &graphql.Response{Errors: errs}, isRESTful)
+}
+
+// writeJSONErrorWithExtensions is like writeJSONError, with extra extensions (eg. `reason`, `retryable`)
+// merged into the error. RESTful responses report them in the `errors` field in verbose errors mode only,
+// the collapsed message has none; GraphQL responses always report them.
+func writeJSONErrorWithExtensions(w http.ResponseWriter, req *http.Request, code int, isRESTful bool, msg string, ext map[string]interface{}) {
+	extensions := map[string]interface{}{"code": code}
+	for k, v := range ext {
+		if k != "code" {
+			extensions[k] = v
+		}
+	}
 	err := gqlerror.Error{
-		Message:    fmt.Sprintf(format, args...),
-		Extensions: map[string]interface{}{"code": code}}
-	writeJSON(w, &graphql.Response{Errors: gqlerror.List{&err}}, isRESTful)
+		Message:    msg,
+		Extensions: extensions}
+	writeJSON(w, req, &graphql.Response{Errors: gqlerror.List{&err}}, isRESTful)
 }
 
 type Printer interface {
@@ -154,6 +1000,22 @@ func RegisterPrinter(printer Printer) {
 	_printer = printer
 }
 
+// ContextPrinter is a Printer logging with the request context, eg. to report the tenant stored by
+// the context enricher. It's used for the panic recovery logging when the registered printer implements it.
+type ContextPrinter interface {
+	Printer
+	PrintfContext(ctx context.Context, format string, v ...interface{})
+}
+
+// ctxPrintf is like dbgPrintf, with the context of req passed to a ContextPrinter
+func ctxPrintf(req *http.Request, format string, v ...interface{}) {
+	if p, ok := _printer.(ContextPrinter); ok && req != nil {
+		p.PrintfContext(req.Context(), format, v...)
+		return
+	}
+	dbgPrintf(format, v...)
+}
+
 func dbgPrintf(format string, v ...interface{}) {
 	if _, ok := _printer.(Printer); ok {
 		_printer.Printf(format, v...)
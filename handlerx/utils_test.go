@@ -0,0 +1,913 @@
+package handlerx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestSetEnvelopeFieldNames(t *testing.T) {
+	defer func() { _ = SetEnvelopeFieldNames("code", "message", "data") }()
+
+	tests := []struct {
+		Name        string
+		Code        string
+		Message     string
+		Data        string
+		Expected    string
+		ShouldError bool
+	}{
+		{
+			Name:     "default names",
+			Code:     "code",
+			Message:  "message",
+			Data:     "data",
+			Expected: `{"code":0,"data":{"id":"1"}}`,
+		},
+		{
+			Name:     "custom names",
+			Code:     "status",
+			Message:  "error",
+			Data:     "result",
+			Expected: `{"status":0,"result":{"id":"1"}}`,
+		},
+		{
+			Name:        "empty name",
+			Code:        "status",
+			Message:     "",
+			Data:        "result",
+			ShouldError: true,
+		},
+		{
+			Name:        "duplicated name",
+			Code:        "status",
+			Message:     "status",
+			Data:        "result",
+			ShouldError: true,
+		},
+		{
+			Name:        "reserved name",
+			Code:        "status",
+			Message:     "errors",
+			Data:        "result",
+			ShouldError: true,
+		},
+		{
+			Name:        "reserved debug name",
+			Code:        "status",
+			Message:     "error",
+			Data:        "_debug",
+			ShouldError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := SetEnvelopeFieldNames(tt.Code, tt.Message, tt.Data)
+			if tt.ShouldError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			buf := httptest.NewRecorder()
+			writeJSON(buf, nil, &graphql.Response{Data: json.RawMessage(`{"todo":{"id":"1"}}`)}, true)
+			assert.Equal(t, tt.Expected, buf.Body.String())
+		})
+	}
+}
+
+func TestSetVerboseErrors(t *testing.T) {
+	defer SetVerboseErrors(false)
+
+	resp := &graphql.Response{
+		Errors: gqlerror.List{
+			{Message: "invalid email", Path: ast.Path{ast.PathName("input"), ast.PathName("email")}, Extensions: map[string]interface{}{"code": "400"}},
+			{Message: "too short", Path: ast.Path{ast.PathName("input"), ast.PathName("name")}},
+		},
+	}
+
+	buf := httptest.NewRecorder()
+	writeJSON(buf, nil, resp, true)
+	assert.Equal(t, `{"code":400,"message":"invalid email input.email; too short input.name","data":null}`, buf.Body.String())
+
+	SetVerboseErrors(true)
+	buf = httptest.NewRecorder()
+	writeJSON(buf, nil, resp, true)
+	assert.Equal(t, `{"code":400,"message":"invalid email input.email; too short input.name","data":null,`+
+		`"errors":[{"message":"invalid email","path":["input","email"],"extensions":{"code":"400"}},{"message":"too short","path":["input","name"]}]}`, buf.Body.String())
+}
+
+func TestWriteJSONErrorWithExtensions(t *testing.T) {
+	defer SetVerboseErrors(false)
+	ext := map[string]interface{}{"reason": "RATE_LIMITED", "retryable": true, "code": 500}
+
+	tests := []struct {
+		Name      string
+		Verbose   bool
+		IsRESTful bool
+		Expected  string
+	}{
+		{Name: "collapsed", IsRESTful: true, Expected: `{"code":429,"message":"slow down","data":null}`},
+		{Name: "verbose", Verbose: true, IsRESTful: true, Expected: `{"code":429,"message":"slow down","data":null,` +
+			`"errors":[{"message":"slow down","extensions":{"code":429,"reason":"RATE_LIMITED","retryable":true}}]}`},
+		{Name: "graphql", Expected: `{"errors":[{"message":"slow down","extensions":{"code":429,"reason":"RATE_LIMITED","retryable":true}}],"data":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetVerboseErrors(tt.Verbose)
+			w := httptest.NewRecorder()
+			writeJSONErrorWithExtensions(w, nil, http.StatusTooManyRequests, tt.IsRESTful, "slow down", ext)
+			assert.Equal(t, tt.Expected, w.Body.String())
+		})
+	}
+}
+
+func TestResponseErrorCode(t *testing.T) {
+	defer SetVerboseErrors(false)
+
+	errs := gqlerror.List{
+		{Message: "not found", Extensions: map[string]interface{}{"code": "404"}},
+		{Message: "forbidden", Extensions: map[string]interface{}{"code": "403"}},
+		{Message: "uncoded"},
+	}
+	tests := []struct {
+		Name     string
+		Verbose  bool
+		Errors   gqlerror.List
+		Expected int
+	}{
+		{Name: "last coded error", Errors: errs, Expected: http.StatusForbidden},
+		{Name: "first error in verbose mode", Verbose: true, Errors: errs, Expected: http.StatusNotFound},
+		{Name: "no coded error", Errors: gqlerror.List{{Message: "a"}, {Message: "b"}}, Expected: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetVerboseErrors(tt.Verbose)
+			assert.Equal(t, tt.Expected, responseErrorCode(tt.Errors))
+		})
+	}
+}
+
+func TestSetHTTPStatusEnabled(t *testing.T) {
+	defer SetHTTPStatusEnabled(false)
+
+	tests := []struct {
+		Name     string
+		Enabled  bool
+		Response *graphql.Response
+		Expected int
+	}{
+		{
+			Name:     "disabled",
+			Enabled:  false,
+			Response: &graphql.Response{Errors: gqlerror.List{{Message: "not found", Extensions: map[string]interface{}{"code": "404"}}}},
+			Expected: http.StatusOK,
+		},
+		{
+			Name:     "enabled with error",
+			Enabled:  true,
+			Response: &graphql.Response{Errors: gqlerror.List{{Message: "not found", Extensions: map[string]interface{}{"code": "404"}}}},
+			Expected: http.StatusNotFound,
+		},
+		{
+			Name:     "enabled with business code",
+			Enabled:  true,
+			Response: &graphql.Response{Errors: gqlerror.List{{Message: "quota exceeded", Extensions: map[string]interface{}{"code": "10001"}}}},
+			Expected: http.StatusInternalServerError,
+		},
+		{
+			Name:     "enabled with success",
+			Enabled:  true,
+			Response: &graphql.Response{Data: json.RawMessage(`{"todo":null}`)},
+			Expected: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetHTTPStatusEnabled(tt.Enabled)
+			w := httptest.NewRecorder()
+			writeJSON(w, nil, tt.Response, true)
+			assert.Equal(t, tt.Expected, w.Code)
+		})
+	}
+}
+
+func TestSetStatusRewriter(t *testing.T) {
+	SetHTTPStatusEnabled(true)
+	SetStatusRewriter(func(code int) int {
+		if code >= 10000 && code < 20000 {
+			return http.StatusBadRequest
+		}
+		return 0
+	})
+	defer SetHTTPStatusEnabled(false)
+	defer SetStatusRewriter(nil)
+
+	tests := []struct {
+		Name     string
+		Code     string
+		Status   int
+		Expected string
+	}{
+		{Name: "rewritten", Code: "10001", Status: http.StatusBadRequest, Expected: `{"code":10001,"message":"quota exceeded","data":null}`},
+		{Name: "default", Code: "404", Status: http.StatusNotFound, Expected: `{"code":404,"message":"quota exceeded","data":null}`},
+		{Name: "fallback", Code: "20001", Status: http.StatusInternalServerError, Expected: `{"code":20001,"message":"quota exceeded","data":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeJSON(w, nil, &graphql.Response{Errors: gqlerror.List{{Message: "quota exceeded", Extensions: map[string]interface{}{"code": tt.Code}}}}, true)
+			assert.Equal(t, tt.Status, w.Code)
+			assert.Equal(t, tt.Expected, w.Body.String())
+		})
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Mapper     func(gqlerror.List) int
+		RESTStatus bool
+		IsRESTful  bool
+		Code       interface{}
+		Expected   int
+	}{
+		{Name: "protocol", Code: errcode.ValidationFailed, Expected: http.StatusUnprocessableEntity},
+		{Name: "protocol restful", IsRESTful: true, Code: errcode.ValidationFailed, Expected: http.StatusUnprocessableEntity},
+		{Name: "protocol from code", RESTStatus: true, IsRESTful: true, Code: errcode.ValidationFailed, Expected: 0},
+		{Name: "user graphql", Code: 404, Expected: 0},
+		{Name: "user from code", RESTStatus: true, IsRESTful: true, Code: 404, Expected: 0},
+		{Name: "user graphql with http status", RESTStatus: true, Code: 404, Expected: 0},
+		{Name: "override", Mapper: func(gqlerror.List) int { return http.StatusTeapot }, Code: 404, Expected: http.StatusTeapot},
+		{Name: "override from code", Mapper: func(gqlerror.List) int { return http.StatusTeapot }, RESTStatus: true, IsRESTful: true, Code: 404, Expected: http.StatusTeapot},
+		{Name: "override kept default", Mapper: func(gqlerror.List) int { return 0 }, Code: errcode.ParseFailed, Expected: http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetErrorStatusMapper(tt.Mapper)
+			defer SetErrorStatusMapper(nil)
+			SetHTTPStatusEnabled(tt.RESTStatus)
+			defer SetHTTPStatusEnabled(false)
+			r := httptest.NewRequest(http.MethodGet, "/todos", nil)
+			errs := gqlerror.List{{Message: "failed", Extensions: map[string]interface{}{"code": tt.Code}}}
+			assert.Equal(t, tt.Expected, errorStatus(r, errs, tt.IsRESTful))
+		})
+	}
+}
+
+// rejectExecutor fails to create the operation of every request
+type rejectExecutor struct {
+	errs gqlerror.List
+}
+
+func (e *rejectExecutor) CreateOperationContext(ctx context.Context, params *graphql.RawParams) (*graphql.OperationContext, gqlerror.List) {
+	return nil, e.errs
+}
+
+func (e *rejectExecutor) DispatchOperation(ctx context.Context, rc *graphql.OperationContext) (graphql.ResponseHandler, context.Context) {
+	return nil, ctx
+}
+
+func (e *rejectExecutor) DispatchError(ctx context.Context, list gqlerror.List) *graphql.Response {
+	return &graphql.Response{Errors: list}
+}
+
+func TestOperationErrorStatus(t *testing.T) {
+	setupTestMapping()
+	SetErrorCodeTable(map[string]int{"COMPLEXITY_LIMIT_EXCEEDED": http.StatusBadRequest})
+	defer SetErrorCodeTable(nil)
+
+	notFound := &gqlerror.Error{Message: "not found", Extensions: map[string]interface{}{"code": 404}}
+	tests := []struct {
+		Name           string
+		Mapper         func(gqlerror.List) int
+		RESTStatus     bool
+		Format         ErrorFormat
+		Errs           gqlerror.List
+		ExpectedStatus int
+		ExpectedCode   string
+	}{
+		{Name: "default", Errs: gqlerror.List{notFound}, ExpectedStatus: http.StatusOK, ExpectedCode: `"code":404`},
+		{Name: "protocol", Errs: gqlerror.List{{Message: "invalid", Extensions: map[string]interface{}{"code": errcode.ValidationFailed}}},
+			ExpectedStatus: http.StatusUnprocessableEntity, ExpectedCode: `"code":422`},
+		{Name: "numeric code", RESTStatus: true, Errs: gqlerror.List{notFound}, ExpectedStatus: http.StatusNotFound, ExpectedCode: `"code":404`},
+		{Name: "table code", RESTStatus: true, Errs: gqlerror.List{{Message: "too complex", Extensions: map[string]interface{}{"code": "COMPLEXITY_LIMIT_EXCEEDED"}}},
+			ExpectedStatus: http.StatusBadRequest, ExpectedCode: `"code":400`},
+		{Name: "last coded error", RESTStatus: true, Errs: gqlerror.List{
+			{Message: "too complex", Extensions: map[string]interface{}{"code": "COMPLEXITY_LIMIT_EXCEEDED"}}, notFound,
+		}, ExpectedStatus: http.StatusNotFound, ExpectedCode: `"code":404`},
+		{Name: "mapper", Mapper: func(gqlerror.List) int { return http.StatusServiceUnavailable }, Errs: gqlerror.List{notFound},
+			ExpectedStatus: http.StatusServiceUnavailable, ExpectedCode: `"code":404`},
+		{Name: "mapper with http status", Mapper: func(gqlerror.List) int { return http.StatusServiceUnavailable }, RESTStatus: true, Errs: gqlerror.List{notFound},
+			ExpectedStatus: http.StatusServiceUnavailable, ExpectedCode: `"code":404`},
+		{Name: "mapper with problem details", Mapper: func(gqlerror.List) int { return http.StatusServiceUnavailable }, Format: ProblemJSON, Errs: gqlerror.List{notFound},
+			ExpectedStatus: http.StatusServiceUnavailable, ExpectedCode: `"code":404`},
+		{Name: "mapper kept default", Mapper: func(gqlerror.List) int { return 0 }, RESTStatus: true, Errs: gqlerror.List{notFound},
+			ExpectedStatus: http.StatusNotFound, ExpectedCode: `"code":404`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetErrorStatusMapper(tt.Mapper)
+			defer SetErrorStatusMapper(nil)
+			SetHTTPStatusEnabled(tt.RESTStatus)
+			defer SetHTTPStatusEnabled(false)
+			SetErrorFormat(tt.Format)
+			defer SetErrorFormat(EnvelopeErrors)
+
+			r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos/1", nil), "/todos/{id}", "1")
+			w := httptest.NewRecorder()
+			GET{}.Do(w, r, &rejectExecutor{errs: tt.Errs})
+			assert.Equal(t, tt.ExpectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.ExpectedCode)
+		})
+	}
+
+	// the GraphQL transport keeps 200 for user errors
+	SetHTTPStatusEnabled(true)
+	defer SetHTTPStatusEnabled(false)
+	w := httptest.NewRecorder()
+	POST{}.Do(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{todos{id}}"}`)), &rejectExecutor{errs: gqlerror.List{notFound}})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestSetErrorCodeMapper(t *testing.T) {
+	defer SetErrorCodeMapper(nil)
+
+	resp := &graphql.Response{Errors: gqlerror.List{{Message: "denied", Extensions: map[string]interface{}{"errno": 403}}}}
+
+	w := httptest.NewRecorder()
+	writeJSON(w, nil, resp, true)
+	assert.Equal(t, `{"code":422,"message":"denied","data":null}`, w.Body.String())
+
+	SetErrorCodeMapper(func(e *gqlerror.Error) int {
+		n, _ := e.Extensions["errno"].(int)
+		return n
+	})
+	w = httptest.NewRecorder()
+	writeJSON(w, nil, resp, true)
+	assert.Equal(t, `{"code":403,"message":"denied","data":null}`, w.Body.String())
+}
+
+func TestWriteJSONNegotiateXML(t *testing.T) {
+	SetContentNegotiation(true)
+	defer SetContentNegotiation(false)
+	req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+	req.Header.Set("Accept", "application/json;q=0.5, application/xml")
+
+	w := httptest.NewRecorder()
+	writeJSON(w, req, &graphql.Response{Data: json.RawMessage(`{"todos":[{"id":"1","done":true,"user":null}]}`)}, true)
+
+	assert.Equal(t, "application/xml", w.Header().Get("Content-Type"))
+	assert.Equal(t, `<response><code>0</code><data><item><done>true</done><id>1</id><user></user></item></data></response>`, w.Body.String())
+
+	// browsers prefer XML to */*, it's only negotiated if enabled
+	SetContentNegotiation(false)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	w = httptest.NewRecorder()
+	writeJSON(w, req, &graphql.Response{Data: json.RawMessage(`{"todos":[]}`)}, true)
+	assert.Equal(t, jsonContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"code":0,"data":[]}`, w.Body.String())
+}
+
+func TestSetStreamingJSON(t *testing.T) {
+	defer SetStreamingJSON(false)
+
+	resp := &graphql.Response{Data: json.RawMessage(`{"todos":[{"id":"1"},{"id":"2"}]}`)}
+
+	buffered := httptest.NewRecorder()
+	writeJSON(buffered, nil, resp, true)
+
+	SetStreamingJSON(true)
+	streamed := httptest.NewRecorder()
+	writeJSON(streamed, nil, resp, true)
+
+	assert.Equal(t, buffered.Body.String(), streamed.Body.String())
+}
+
+func TestSetFieldErrorMode(t *testing.T) {
+	defer SetFieldErrorMode(false)
+	SetFieldErrorMode(true)
+
+	resp := &graphql.Response{
+		Errors: gqlerror.List{
+			{Message: "invalid format", Path: ast.Path{ast.PathName("user"), ast.PathName("email")}, Extensions: map[string]interface{}{"code": "422"}},
+			{Message: "permission denied"},
+		},
+	}
+
+	w := httptest.NewRecorder()
+	writeJSON(w, nil, resp, true)
+	assert.Equal(t, `{"code":422,"message":"permission denied","data":null,"fields":{"user.email":"invalid format"}}`, w.Body.String())
+}
+
+func TestSetSuccessCode(t *testing.T) {
+	defer SetSuccessCode(0)
+	SetSuccessCode(200)
+
+	w := httptest.NewRecorder()
+	writeJSON(w, nil, &graphql.Response{Data: json.RawMessage(`{"todo":{"id":"1"}}`)}, true)
+	assert.Equal(t, `{"code":200,"data":{"id":"1"}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	writeJSON(w, nil, &graphql.Response{Errors: gqlerror.List{{Message: "not found", Extensions: map[string]interface{}{"code": "404"}}}}, true)
+	assert.Equal(t, `{"code":404,"message":"not found","data":null}`, w.Body.String())
+}
+
+func TestSetSuccessMessage(t *testing.T) {
+	defer SetSuccessCode(0)
+	defer SetSuccessMessage("")
+	SetSuccessCode(200)
+	SetSuccessMessage("OK")
+
+	w := httptest.NewRecorder()
+	writeJSON(w, nil, &graphql.Response{Data: json.RawMessage(`{"todo":{"id":"1"}}`)}, true)
+	assert.Equal(t, `{"code":200,"message":"OK","data":{"id":"1"}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	writeJSON(w, nil, &graphql.Response{Errors: gqlerror.List{{Message: "not found", Extensions: map[string]interface{}{"code": "404"}}}}, true)
+	assert.Equal(t, `{"code":404,"message":"not found","data":null}`, w.Body.String())
+}
+
+func TestSetInternalErrorMessage(t *testing.T) {
+	defer SetInternalErrorMessage("unexpected error: unmarshal or write response error")
+	defer SetInternalErrorID(false)
+	SetInternalErrorMessage("服务器内部错误")
+
+	w := httptest.NewRecorder()
+	writeJSON(w, nil, &graphql.Response{Data: json.RawMessage(`{"todo":`)}, true)
+	assert.Equal(t, `{"code":500,"message":"服务器内部错误","data":null}`, w.Body.String())
+
+	SetInternalErrorID(true)
+	w = httptest.NewRecorder()
+	writeJSON(w, nil, &graphql.Response{Data: json.RawMessage(`{"todo":`)}, true)
+	var resp map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(t, resp["error_id"], 36)
+}
+
+func TestSetPanicHandler(t *testing.T) {
+	defer SetPanicHandler(nil)
+
+	assert.Panics(t, func() {
+		writeJSON(httptest.NewRecorder(), nil, &graphql.Response{Data: json.RawMessage(`{"todo":`)}, false)
+	}, "GraphQL responses panic without a handler")
+
+	var stack []byte
+	SetPanicHandler(func(recovered interface{}, s []byte, w http.ResponseWriter, isRESTful bool) {
+		stack = s
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = fmt.Fprintf(w, `{"incident":"i1","restful":%v}`, isRESTful)
+	})
+
+	for _, isRESTful := range []bool{true, false} {
+		w := httptest.NewRecorder()
+		writeJSON(w, nil, &graphql.Response{Data: json.RawMessage(`{"todo":`)}, isRESTful)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, fmt.Sprintf(`{"incident":"i1","restful":%v}`, isRESTful), w.Body.String())
+		assert.Contains(t, string(stack), "writeJSON")
+	}
+}
+
+func TestSetNoContentOnEmpty(t *testing.T) {
+	defer SetNoContentOnEmpty(false)
+	SetNoContentOnEmpty(true)
+
+	tests := []struct {
+		Name     string
+		Method   string
+		Response *graphql.Response
+		Expected int
+		Body     string
+	}{
+		{
+			Name:     "null mutation",
+			Method:   http.MethodDelete,
+			Response: &graphql.Response{Data: json.RawMessage(`{"deleteTodo":null}`)},
+			Expected: http.StatusNoContent,
+		},
+		{
+			Name:     "non-empty mutation",
+			Method:   http.MethodDelete,
+			Response: &graphql.Response{Data: json.RawMessage(`{"deleteTodo":true}`)},
+			Expected: http.StatusOK,
+			Body:     `{"code":0,"data":true}`,
+		},
+		{
+			Name:     "null query",
+			Method:   http.MethodGet,
+			Response: &graphql.Response{Data: json.RawMessage(`{"todo":null}`)},
+			Expected: http.StatusOK,
+			Body:     `{"code":0,"data":null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeJSON(w, httptest.NewRequest(tt.Method, "/todos/1", nil), tt.Response, true)
+			assert.Equal(t, tt.Expected, w.Code)
+			assert.Equal(t, tt.Body, w.Body.String())
+		})
+	}
+}
+
+func TestSetMaxRequestBytes(t *testing.T) {
+	setupTestMapping()
+	defer SetMaxRequestBytes(0)
+	SetMaxRequestBytes(16)
+	defer SetVerboseErrors(false)
+	SetVerboseErrors(true)
+
+	tests := []struct {
+		Name        string
+		ContentType string
+		Body        string
+	}{
+		{Name: "json", ContentType: "application/json", Body: `{"input":{"text":"too long to be accepted"}}`},
+		{Name: "form", ContentType: "application/x-www-form-urlencoded", Body: "text=too+long+to+be+accepted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(tt.Body)), "/todos")
+			r.Header.Set("Content-Type", tt.ContentType)
+			w := httptest.NewRecorder()
+			POST{}.Do(w, r, nil)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+			assert.Contains(t, w.Body.String(), `"code":413`)
+			assert.Contains(t, w.Body.String(), `"extensions":{"code":413,"reason":"BODY_TOO_LARGE","retryable":false}`)
+		})
+	}
+}
+
+func TestSetErrorCodeTable(t *testing.T) {
+	defer SetErrorCodeTable(nil)
+	SetErrorCodeTable(map[string]int{"NOT_FOUND": 404, errcode.ValidationFailed: 400})
+
+	tests := []struct {
+		Name     string
+		Code     interface{}
+		Expected int
+	}{
+		{Name: "mapped", Code: "NOT_FOUND", Expected: 404},
+		{Name: "remapped builtin", Code: errcode.ValidationFailed, Expected: 400},
+		{Name: "numeric", Code: "409", Expected: 409},
+		{Name: "unmapped", Code: "FORBIDDEN", Expected: 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Expected, errorCode(&gqlerror.Error{Message: "x", Extensions: map[string]interface{}{"code": tt.Code}}))
+		})
+	}
+}
+
+func TestErrorCodeNumbers(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Code     interface{}
+		Expected int
+	}{
+		{Name: "string", Code: "404", Expected: 404},
+		{Name: "int", Code: 404, Expected: 404},
+		{Name: "int64", Code: int64(404), Expected: 404},
+		{Name: "float64", Code: float64(404), Expected: 404},
+		{Name: "json.Number", Code: json.Number("404"), Expected: 404},
+		{Name: "fractional float64", Code: 404.5, Expected: 500},
+		{Name: "missing", Expected: 422},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			ext := map[string]interface{}{}
+			if tt.Code != nil {
+				ext["code"] = tt.Code
+			}
+			assert.Equal(t, tt.Expected, errorCode(&gqlerror.Error{Message: "x", Extensions: ext}))
+		})
+	}
+
+	// decoded from the JSON of a remote GraphQL response
+	var resp graphql.Response
+	assert.NoError(t, json.Unmarshal([]byte(`{"errors":[{"message":"not found","extensions":{"code":404}}],"data":null}`), &resp))
+	w := httptest.NewRecorder()
+	writeJSON(w, nil, &resp, true)
+	assert.Equal(t, `{"code":404,"message":"not found","data":null}`, w.Body.String())
+}
+
+func TestSetUnwrapSingleField(t *testing.T) {
+	defer SetUnwrapSingleField(true)
+
+	tests := []struct {
+		Name     string
+		Unwrap   bool
+		Data     string
+		Expected string
+	}{
+		{Name: "default", Unwrap: true, Data: `{"todo":{"id":"1"}}`, Expected: `{"code":0,"data":{"id":"1"}}`},
+		{Name: "disabled", Data: `{"todo":{"id":"1"}}`, Expected: `{"code":0,"data":{"todo":{"id":"1"}}}`},
+		{Name: "disabled with several root fields", Data: `{"todo":{"id":"1"},"viewer":{"id":"u"}}`, Expected: `{"code":0,"data":{"todo":{"id":"1"},"viewer":{"id":"u"}}}`},
+	}
+
+	assert.True(t, unwrapSingleField, "enabled by default")
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetUnwrapSingleField(tt.Unwrap)
+			w := httptest.NewRecorder()
+			writeJSON(w, nil, &graphql.Response{Data: json.RawMessage(tt.Data)}, true)
+			assert.Equal(t, tt.Expected, w.Body.String())
+		})
+	}
+}
+
+func TestSetUnwrapField(t *testing.T) {
+	setupTestMapping()
+	SetUnwrapField("todo", "todo")
+	defer SetUnwrapField("todo", "")
+
+	tests := []struct {
+		Name     string
+		Data     string
+		Expected string
+	}{
+		{Name: "named field", Data: `{"viewer":{"id":"u"},"todo":{"id":"1"}}`, Expected: `{"code":0,"data":{"id":"1"}}`},
+		{Name: "null field", Data: `{"todo":null}`, Expected: `{"code":0,"data":null}`},
+		{Name: "missing field", Data: `{"viewer":{"id":"u"}}`, Expected: `{"code":500,"message":"unwrap field \"todo\" of todo is missing in response data","data":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos/1", nil), "/todos/{id}", "1")
+			w := httptest.NewRecorder()
+			writeJSON(w, r, &graphql.Response{Data: json.RawMessage(tt.Data)}, true)
+			assert.Equal(t, tt.Expected, w.Body.String())
+		})
+	}
+}
+
+func TestSetUnwrapPath(t *testing.T) {
+	setupTestMapping()
+	SetUnwrapPath("todo", []string{"result", "payload"})
+	defer SetUnwrapPath("todo", nil)
+
+	tests := []struct {
+		Name     string
+		Data     string
+		Expected string
+	}{
+		{Name: "nested payload", Data: `{"todo":{"result":{"payload":{"id":"1"},"meta":{}}}}`, Expected: `{"code":0,"data":{"id":"1"}}`},
+		{Name: "null payload", Data: `{"todo":{"result":{"payload":null}}}`, Expected: `{"code":0,"data":null}`},
+		{Name: "missing segment", Data: `{"todo":{"result":{"id":"1"}}}`, Expected: `{"code":0,"data":{"result":{"id":"1"}}}`},
+		{Name: "null segment", Data: `{"todo":{"result":null}}`, Expected: `{"code":0,"data":{"result":null}}`},
+		{Name: "null data", Data: `{"todo":null}`, Expected: `{"code":0,"data":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(http.MethodGet, "/todos/1", nil), "/todos/{id}", "1")
+			w := httptest.NewRecorder()
+			writeJSON(w, r, &graphql.Response{Data: json.RawMessage(tt.Data)}, true)
+			assert.Equal(t, tt.Expected, w.Body.String())
+		})
+	}
+}
+
+func TestSetSuccessStatus(t *testing.T) {
+	setupTestMapping()
+	SetSuccessStatus("createTodo", http.StatusCreated)
+	defer SetSuccessStatus("createTodo", 0)
+
+	tests := []struct {
+		Name       string
+		Method     string
+		Pattern    string
+		HTTPStatus bool
+		Response   *graphql.Response
+		Expected   int
+	}{
+		{Name: "created", Method: http.MethodPost, Pattern: "/todos", Response: &graphql.Response{Data: json.RawMessage(`{"createTodo":{"id":"1"}}`)}, Expected: http.StatusCreated},
+		{Name: "created with http status", Method: http.MethodPost, Pattern: "/todos", HTTPStatus: true, Response: &graphql.Response{Data: json.RawMessage(`{"createTodo":{"id":"1"}}`)}, Expected: http.StatusCreated},
+		{Name: "error keeps default", Method: http.MethodPost, Pattern: "/todos", Response: &graphql.Response{Errors: gqlerror.List{{Message: "not found", Extensions: map[string]interface{}{"code": 404}}}}, Expected: http.StatusOK},
+		{Name: "error with http status", Method: http.MethodPost, Pattern: "/todos", HTTPStatus: true, Response: &graphql.Response{Errors: gqlerror.List{{Message: "not found", Extensions: map[string]interface{}{"code": 404}}}}, Expected: http.StatusNotFound},
+		{Name: "other operation", Method: http.MethodGet, Pattern: "/todos", Response: &graphql.Response{Data: json.RawMessage(`{"todos":[]}`)}, Expected: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetHTTPStatusEnabled(tt.HTTPStatus)
+			defer SetHTTPStatusEnabled(false)
+			r := WithRoute(httptest.NewRequest(tt.Method, "/todos", nil), tt.Pattern)
+			w := httptest.NewRecorder()
+			writeJSON(w, r, tt.Response, true)
+			assert.Equal(t, tt.Expected, w.Code)
+		})
+	}
+}
+
+func TestSetLocationBuilder(t *testing.T) {
+	setupTestMapping()
+	SetLocationBuilder("createTodo", func(data json.RawMessage) (string, bool) {
+		var todo struct{ ID string }
+		if err := json.Unmarshal(data, &todo); err != nil || todo.ID == "" {
+			return "", false
+		}
+		return "/todos/" + todo.ID, true
+	})
+	defer SetLocationBuilder("createTodo", nil)
+
+	tests := []struct {
+		Name     string
+		Target   string
+		Response *graphql.Response
+		Expected string
+	}{
+		{Name: "created", Target: "/todos", Response: &graphql.Response{Data: json.RawMessage(`{"createTodo":{"id":"1"}}`)}, Expected: "/todos/1"},
+		{Name: "id filtered out", Target: "/todos?fields=text", Response: &graphql.Response{Data: json.RawMessage(`{"createTodo":{"id":"1","text":"x"}}`)}, Expected: "/todos/1"},
+		{Name: "builder declined", Target: "/todos", Response: &graphql.Response{Data: json.RawMessage(`{"createTodo":null}`)}},
+		{Name: "error", Target: "/todos", Response: &graphql.Response{Data: json.RawMessage(`{"createTodo":{"id":"1"}}`), Errors: gqlerror.List{{Message: "failed"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(http.MethodPost, tt.Target, nil), "/todos")
+			w := httptest.NewRecorder()
+			writeJSON(w, r, tt.Response, true)
+			assert.Equal(t, tt.Expected, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestSetPartialSuccessMode(t *testing.T) {
+	defer SetPartialSuccessMode(false)
+	defer SetPartialSuccessCode(http.StatusMultiStatus)
+
+	partial := &graphql.Response{
+		Data:   json.RawMessage(`{"todos":[{"id":"1","user":null}]}`),
+		Errors: gqlerror.List{{Message: "user not found", Path: ast.Path{ast.PathName("todos"), ast.PathIndex(0), ast.PathName("user")}, Extensions: map[string]interface{}{"code": 404}}},
+	}
+	failed := &graphql.Response{
+		Data:   json.RawMessage(`{"todos":null}`),
+		Errors: gqlerror.List{{Message: "not found", Extensions: map[string]interface{}{"code": 404}}},
+	}
+
+	tests := []struct {
+		Name     string
+		Enabled  bool
+		Code     int
+		Response *graphql.Response
+		Expected string
+	}{
+		{Name: "disabled", Response: partial, Expected: `{"code":404,"message":"user not found todos[0].user","data":[{"id":"1","user":null}]}`},
+		{Name: "partial", Enabled: true, Response: partial, Expected: `{"code":207,"message":"user not found todos[0].user","data":[{"id":"1","user":null}],"errors":[{"message":"user not found","path":["todos",0,"user"],"extensions":{"code":404}}]}`},
+		{Name: "custom code", Enabled: true, Code: 10207, Response: partial, Expected: `{"code":10207,"message":"user not found todos[0].user","data":[{"id":"1","user":null}],"errors":[{"message":"user not found","path":["todos",0,"user"],"extensions":{"code":404}}]}`},
+		{Name: "null data", Enabled: true, Response: failed, Expected: `{"code":404,"message":"not found","data":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetPartialSuccessMode(tt.Enabled)
+			if tt.Code != 0 {
+				SetPartialSuccessCode(tt.Code)
+			}
+			w := httptest.NewRecorder()
+			writeJSON(w, nil, tt.Response, true)
+			assert.Equal(t, tt.Expected, w.Body.String())
+		})
+	}
+}
+
+func TestSetScalarWrapKey(t *testing.T) {
+	SetScalarWrapKey("value")
+	defer SetScalarWrapKey("")
+
+	tests := []struct {
+		Name     string
+		Data     string
+		Expected string
+	}{
+		{Name: "number", Data: `{"count":42}`, Expected: `{"code":0,"data":{"value":42}}`},
+		{Name: "string", Data: `{"version":"v1"}`, Expected: `{"code":0,"data":{"value":"v1"}}`},
+		{Name: "boolean", Data: `{"deleteTodo":true}`, Expected: `{"code":0,"data":{"value":true}}`},
+		{Name: "object", Data: `{"todo":{"id":"1"}}`, Expected: `{"code":0,"data":{"id":"1"}}`},
+		{Name: "list", Data: `{"todos":[1,2]}`, Expected: `{"code":0,"data":[1,2]}`},
+		{Name: "null", Data: `{"todo":null}`, Expected: `{"code":0,"data":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeJSON(w, nil, &graphql.Response{Data: json.RawMessage(tt.Data)}, true)
+			assert.Equal(t, tt.Expected, w.Body.String())
+		})
+	}
+}
+
+func TestSetMaxMessageLength(t *testing.T) {
+	defer SetMaxMessageLength(0)
+
+	tests := []struct {
+		Name     string
+		Max      int
+		Msgs     []string
+		Expected string
+	}{
+		{Name: "unlimited", Msgs: []string{"not found", "invalid id"}, Expected: "not found; invalid id"},
+		{Name: "short enough", Max: 21, Msgs: []string{"not found", "invalid id"}, Expected: "not found; invalid id"},
+		{Name: "counted", Max: 30, Msgs: []string{"not found", "invalid id", "bad", "worse"}, Expected: "not found... (+3 more)"},
+		{Name: "first message cut", Max: 12, Msgs: []string{"not found at all"}, Expected: "not found..."},
+		{Name: "counted by runes", Max: 14, Msgs: []string{"找不到该待办事项", "参数错误"}, Expected: "找不到该待办事项; 参数错误"},
+		{Name: "multibyte cut", Max: 5, Msgs: []string{"找不到该待办事项"}, Expected: "找不..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetMaxMessageLength(tt.Max)
+			assert.Equal(t, tt.Expected, joinMessages(tt.Msgs))
+		})
+	}
+
+	// verbose errors are kept as-is
+	SetMaxMessageLength(12)
+	SetVerboseErrors(true)
+	defer SetVerboseErrors(false)
+	w := httptest.NewRecorder()
+	writeJSON(w, nil, &graphql.Response{Errors: gqlerror.List{{Message: "not found at all"}, {Message: "invalid id"}}}, true)
+	var resp RESTResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "... (+1 more)", resp.Message)
+	assert.Equal(t, "not found at all", resp.Errors[0].Message)
+}
+
+func TestSetContentType(t *testing.T) {
+	SetContentNegotiation(true)
+	defer SetContentNegotiation(false)
+	defer SetContentType("")
+
+	tests := []struct {
+		Name        string
+		ContentType string
+		Accept      string
+		Expected    string
+	}{
+		{Name: "default", Expected: "application/json; charset=utf-8"},
+		{Name: "override", ContentType: "application/vnd.api+json", Expected: "application/vnd.api+json"},
+		{Name: "negotiated", ContentType: "application/vnd.api+json", Accept: "application/xml", Expected: "application/xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetContentType(tt.ContentType)
+			r := httptest.NewRequest(http.MethodGet, "/todos", nil)
+			if tt.Accept != "" {
+				r.Header.Set("Accept", tt.Accept)
+			}
+			w := httptest.NewRecorder()
+			writeJSON(w, r, &graphql.Response{Data: json.RawMessage(`{"todos":[]}`)}, true)
+			assert.Equal(t, tt.Expected, w.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestSetNullDataPolicy(t *testing.T) {
+	defer SetNullDataPolicy(NullDataKeep)
+
+	errResp := &graphql.Response{Data: json.RawMessage(`null`), Errors: gqlerror.List{{Message: "denied", Extensions: map[string]interface{}{"code": 403}}}}
+	tests := []struct {
+		Name     string
+		Policy   NullDataPolicy
+		Response *graphql.Response
+		Expected string
+	}{
+		{Name: "keep", Policy: NullDataKeep, Response: errResp, Expected: `{"code":403,"message":"denied","data":null}`},
+		{Name: "omit", Policy: NullDataOmit, Response: errResp, Expected: `{"code":403,"message":"denied"}`},
+		{Name: "empty object", Policy: NullDataEmptyObject, Response: errResp, Expected: `{"code":403,"message":"denied","data":{}}`},
+		{Name: "empty list", Policy: NullDataEmptyList, Response: errResp, Expected: `{"code":403,"message":"denied","data":[]}`},
+		{Name: "success not affected", Policy: NullDataOmit, Response: &graphql.Response{Data: json.RawMessage(`{"todo":null}`)}, Expected: `{"code":0,"data":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetNullDataPolicy(tt.Policy)
+			w := httptest.NewRecorder()
+			writeJSON(w, nil, tt.Response, true)
+			assert.Equal(t, tt.Expected, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Constraint is the validation rule of an argument or input field, generated from
+// the @constraintString, @constraintNumber, @constraintSlice and @constraintStringSlice directives.
+// The length, range and pattern rules apply to every item of a list value.
+type Constraint struct {
+	MinLength *int
+	MaxLength *int
+	Min       *float64
+	Max       *float64
+	Pattern   *regexp.Regexp
+	MinItems  *int
+	MaxItems  *int
+}
+
+// ConstraintMap maps a GraphQL operation (or input type) to the constraints of its arguments (or fields)
+type ConstraintMap map[string]map[string]*Constraint
+
+var (
+	// GraphQL Operation => Argument Constraints
+	operationConstraints = make(ConstraintMap)
+	// Input Type => Field Constraints
+	inputTypeConstraints = make(ConstraintMap)
+)
+
+// SetupRESTConstraints sets up the constraints checked for RESTful requests before the GraphQL execution,
+// it's called by the generated SetupRESTMapping.
+func SetupRESTConstraints(operations ConstraintMap, inputTypes ConstraintMap) {
+	operationConstraints = operations
+	inputTypeConstraints = inputTypes
+}
+
+// Int returns a pointer to v, for generated constraints
+func Int(v int) *int {
+	return &v
+}
+
+// Float returns a pointer to v, for generated constraints
+func Float(v float64) *float64 {
+	return &v
+}
+
+// ValidationError is returned when RESTful request parameters violate their constraints,
+// Fields maps the dotted path of each invalid parameter to the reason.
+type ValidationError struct {
+	Fields map[string]string
+	paths  map[string]ast.Path
+}
+
+func (e *ValidationError) Error() string {
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	msgs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		msgs = append(msgs, k+": "+e.Fields[k])
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) add(path ast.Path, msg string) {
+	if e.Fields == nil {
+		e.Fields = make(map[string]string)
+		e.paths = make(map[string]ast.Path)
+	}
+	key := path.String()
+	e.Fields[key] = msg
+	e.paths[key] = append(ast.Path(nil), path...)
+}
+
+// validateArguments checks the request parameters of an operation against the constraints
+func validateArguments(operationName string, argTypes StringMap, queryParams map[string]interface{}) error {
+	verr := &ValidationError{}
+	validateFields(verr, nil, argTypes, operationConstraints[operationName], queryParams)
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
+
+func validateFields(verr *ValidationError, path ast.Path, fieldTypes StringMap, constraints map[string]*Constraint, params map[string]interface{}) {
+	for k, v := range params {
+		fieldType, ok := fieldTypes[k]
+		if !ok || v == nil {
+			continue
+		}
+		validateValue(verr, append(path, ast.PathName(k)), fieldType, constraints[k], v)
+	}
+}
+
+func validateValue(verr *ValidationError, path ast.Path, fieldType string, constraint *Constraint, v interface{}) {
+	isArray, underlayingType := getUnderlayingArgType(fieldType)
+	if !isArray {
+		validateScalar(verr, path, underlayingType, constraint, v)
+		return
+	}
+
+	vals, err := getSliceInterface(v)
+	if err != nil {
+		return // reported by the conversion
+	}
+	if constraint != nil {
+		if constraint.MinItems != nil && len(vals) < *constraint.MinItems {
+			verr.add(path, fmt.Sprintf("must have at least %d items", *constraint.MinItems))
+			return
+		}
+		if constraint.MaxItems != nil && len(vals) > *constraint.MaxItems {
+			verr.add(path, fmt.Sprintf("must have at most %d items", *constraint.MaxItems))
+			return
+		}
+	}
+	for i, vv := range vals {
+		validateScalar(verr, append(path, ast.PathIndex(i)), underlayingType, constraint, vv)
+	}
+}
+
+func validateScalar(verr *ValidationError, path ast.Path, underlayingType string, constraint *Constraint, v interface{}) {
+	if input, ok := v.(map[string]interface{}); ok {
+		if fieldTypes, ok := inputType2FieldDefinitions[underlayingType]; ok {
+			validateFields(verr, path, fieldTypes, inputTypeConstraints[underlayingType], input)
+		}
+		return
+	}
+	if constraint == nil {
+		return
+	}
+
+	switch underlayingType {
+	case "Int", "Float":
+		n, err := strconv.ParseFloat(fmt.Sprint(v), 64)
+		if err != nil {
+			return // reported by the conversion
+		}
+		if constraint.Min != nil && n < *constraint.Min {
+			verr.add(path, "must be greater than or equal to "+strconv.FormatFloat(*constraint.Min, 'f', -1, 64))
+		} else if constraint.Max != nil && n > *constraint.Max {
+			verr.add(path, "must be less than or equal to "+strconv.FormatFloat(*constraint.Max, 'f', -1, 64))
+		}
+	default:
+		s, ok := v.(string)
+		if !ok {
+			if n, isNumber := v.(json.Number); isNumber {
+				s = n.String()
+			} else {
+				return
+			}
+		}
+		length := utf8.RuneCountInString(s)
+		if constraint.MinLength != nil && length < *constraint.MinLength {
+			verr.add(path, fmt.Sprintf("length must be at least %d", *constraint.MinLength))
+		} else if constraint.MaxLength != nil && length > *constraint.MaxLength {
+			verr.add(path, fmt.Sprintf("length must be at most %d", *constraint.MaxLength))
+		} else if constraint.Pattern != nil && !constraint.Pattern.MatchString(s) {
+			verr.add(path, "must match pattern "+constraint.Pattern.String())
+		}
+	}
+}
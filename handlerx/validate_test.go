@@ -0,0 +1,74 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetupRESTConstraints(t *testing.T) {
+	setupTestMapping()
+	SetupRESTConstraints(
+		ConstraintMap{"todos": {"ids": {MaxItems: Int(2), Pattern: regexp.MustCompile(`^\d+$`)}, "limit": {Min: Float(1), Max: Float(100)}}},
+		ConstraintMap{
+			"NewTodoInput": {"text": {MinLength: Int(3), MaxLength: Int(5)}},
+			"TagInput":     {"name": {MinLength: Int(1)}},
+		},
+	)
+	defer SetupRESTConstraints(make(ConstraintMap), make(ConstraintMap))
+	defer SetFieldErrorMode(false)
+
+	tests := []struct {
+		Name     string
+		Method   string
+		URL      string
+		Body     string
+		Expected map[string]interface{}
+	}{
+		{Name: "valid query", Method: http.MethodGet, URL: "/todos?ids=1&ids=2&limit=10"},
+		{Name: "valid body", Method: http.MethodPost, URL: "/todos", Body: `{"text":"abcd","userId":"1"}`},
+		{
+			Name: "out of range", Method: http.MethodGet, URL: "/todos?limit=0",
+			Expected: map[string]interface{}{"limit": "must be greater than or equal to 1"},
+		},
+		{
+			Name: "too many items", Method: http.MethodGet, URL: "/todos?ids=1,2,3",
+			Expected: map[string]interface{}{"ids": "must have at most 2 items"},
+		},
+		{
+			Name: "pattern mismatch", Method: http.MethodGet, URL: "/todos?ids=1&ids=x",
+			Expected: map[string]interface{}{"ids[1]": "must match pattern ^\\d+$"},
+		},
+		{
+			Name: "nested input fields", Method: http.MethodPost, URL: "/todos", Body: `{"text":"abcdef","userId":"1","tags":[{"name":""}]}`,
+			Expected: map[string]interface{}{"input.text": "length must be at most 5", "input.tags[0].name": "length must be at least 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := WithRoute(httptest.NewRequest(tt.Method, tt.URL, strings.NewReader(tt.Body)), "/todos")
+			_, err := convertHTTPRequestToGraphQLQuery(r, &graphql.RawParams{}, []byte(tt.Body))
+			if tt.Expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, http.StatusUnprocessableEntity, convertErrorCode(err))
+
+			SetFieldErrorMode(true)
+			w := httptest.NewRecorder()
+			writeConvertError(w, r, err, true, "query body could not be parsed")
+
+			var resp map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, float64(http.StatusUnprocessableEntity), resp["code"])
+			assert.Equal(t, tt.Expected, resp["fields"])
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package handlerx
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// jsonWalk rewrites a JSON value in a single depth-checked pass, keeping the order of the object
+// members. Its callbacks are the part of the response transforms (eg. omitting nulls, fields filtering)
+// which differs, nil ones keep the values as-is.
+type jsonWalk struct {
+	// leaf rewrites a scalar value, null included
+	leaf func(value json.RawMessage) json.RawMessage
+	// member returns the walk of the value of the object member name, a nil one copies the value as-is,
+	// and false to drop the member; by default the value is walked by the same walk
+	member func(name string, value json.RawMessage) (*jsonWalk, bool)
+	// end is called once the members of an object are walked
+	end func()
+}
+
+// walk rewrites data, see checkTransformDepth
+func (w *jsonWalk) walk(data json.RawMessage) (json.RawMessage, error) {
+	return w.walkValue(data, 0)
+}
+
+func (w *jsonWalk) walkValue(data json.RawMessage, depth int) (json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return data, nil
+	}
+	if err := checkTransformDepth(trimmed, depth); err != nil {
+		return nil, err
+	}
+
+	switch trimmed[0] {
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		buf.WriteByte('[')
+		for i, item := range items {
+			value, err := w.walkValue(item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(value)
+		}
+		buf.WriteByte(']')
+		return buf.Bytes(), nil
+	case '{':
+		return w.walkObject(trimmed, depth)
+	default:
+		if w.leaf != nil {
+			return w.leaf(trimmed), nil
+		}
+		return data, nil
+	}
+}
+
+func (w *jsonWalk) walkObject(data json.RawMessage, depth int) (json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		name, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		sub, keep := w, true
+		if w.member != nil {
+			sub, keep = w.member(name, bytes.TrimSpace(value))
+		}
+		if !keep {
+			continue
+		}
+		if sub != nil {
+			if value, err = sub.walkValue(value, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	if w.end != nil {
+		w.end()
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,59 @@
+package handlerx
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransformValues(t *testing.T) {
+	defer SetOmitNulls(false)
+	defer SetStringifyLargeInts(false)
+
+	tests := []struct {
+		Name         string
+		OmitNulls    bool
+		StringifyInt bool
+		Data         string
+		Expected     string
+	}{
+		{Name: "disabled", Data: `{"id":9007199254740993,"text":null}`, Expected: `{"id":9007199254740993,"text":null}`},
+		{Name: "omit nulls", OmitNulls: true, Data: `{"id":9007199254740993,"text":null}`, Expected: `{"id":9007199254740993}`},
+		{Name: "large ints", StringifyInt: true, Data: `{"id":9007199254740993,"text":null}`, Expected: `{"id":"9007199254740993","text":null}`},
+		{Name: "both", OmitNulls: true, StringifyInt: true, Data: `[{"id":9007199254740993,"text":null,"user":{"id":1,"ip":null}},null]`,
+			Expected: `[{"id":"9007199254740993","user":{"id":1}},null]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			SetOmitNulls(tt.OmitNulls)
+			SetStringifyLargeInts(tt.StringifyInt)
+			data, err := transformValues(json.RawMessage(tt.Data))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.Expected, string(data))
+		})
+	}
+}
+
+func TestJSONWalk(t *testing.T) {
+	SetMaxTransformDepth(2)
+	defer SetMaxTransformDepth(0)
+
+	var ends int
+	w := &jsonWalk{end: func() { ends++ }}
+	w.member = func(name string, value json.RawMessage) (*jsonWalk, bool) {
+		if name == "raw" {
+			return nil, true
+		}
+		return w, name != "dropped"
+	}
+
+	data, err := w.walk(json.RawMessage(`[{"id":1,"dropped":2},{"raw":[[{}]]}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"id":1},{"raw":[[{}]]}]`, string(data))
+	assert.Equal(t, 2, ends)
+
+	_, err = w.walk(json.RawMessage(`[{"id":[1]}]`))
+	assert.Equal(t, ErrTransformDepthExceeded, err)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/99designs/gqlgen/codegen/config"
 	validator "github.com/speedoops/go-gqlrest/config"
 	"github.com/speedoops/go-gqlrest/restgen"
+	"github.com/speedoops/go-gqlrest/restgen/utils"
 )
 
 var (
@@ -16,6 +17,15 @@ var (
 	flagDoc               = flag.Bool("doc", false, "generate openapi doc")
 	flagValidatorFilePath = flag.String("f", "./validator.yaml", "validator config file path")
 	flagPublish           = flag.Bool("publish", false, "publish api to external user")
+	flagDocOutput         = flag.String("doc-output", "graph/generated/rest.yaml", "openapi doc output path")
+	flagTSClient          = flag.String("ts-client", "", "generate typescript client into the given directory")
+	flagGoClient          = flag.String("go-client", "", "generate a go client package into the given directory")
+	flagPersisted         = flag.Bool("persisted-queries", false, "generate persisted queries of the rest endpoints")
+	flagSwaggerUI         = flag.Bool("swagger-ui", false, "generate a swagger ui handler of the openapi doc, the ui assets are only built into binaries using it")
+	flagRouter            = flag.String("router", "", "generate route registration for the router framework: chi, gin, echo or net/http")
+	flagPostman           = flag.String("postman", "", "generate a postman v2.1 collection of the rest endpoints into the given file")
+	flagHTTPFile          = flag.String("http-file", "", "generate a .http file (VS Code REST Client / JetBrains HTTP Client) of the rest endpoints into the given file")
+	flagMock              = flag.Bool("mock", false, "generate a mock server of the rest endpoints responding example data")
 )
 
 func main() {
@@ -29,9 +39,35 @@ func main() {
 			os.Exit(2)
 		}
 
-		err = api.Generate(cfg,
+		options := []api.Option{
 			api.AddPlugin(restgen.New("graph/generated/rest.go", "Query")), // This is the magic line
-		)
+		}
+		if *flagRouter != "" {
+			filename := "graph/generated/rest_" + utils.SanitizePackageName(*flagRouter) + ".go"
+			options = append(options, api.AddPlugin(restgen.NewRouterPlugin(filename, *flagRouter)))
+		}
+		if *flagPersisted {
+			options = append(options, api.AddPlugin(restgen.NewPersistedQueryPlugin("graph/generated/rest_queries.go")))
+		}
+		if *flagSwaggerUI {
+			options = append(options, api.AddPlugin(restgen.NewSwaggerUIPlugin("graph/generated/rest_swagger.go", *flagDocOutput)))
+		}
+		if *flagTSClient != "" {
+			options = append(options, api.AddPlugin(restgen.NewTSClientPlugin(*flagTSClient)))
+		}
+		if *flagGoClient != "" {
+			options = append(options, api.AddPlugin(restgen.NewGoClientPlugin(*flagGoClient)))
+		}
+		if *flagMock {
+			options = append(options, api.AddPlugin(restgen.NewMockServerPlugin("graph/generated/rest_mock.go")))
+		}
+		if *flagPostman != "" {
+			options = append(options, api.AddPlugin(restgen.NewPostmanPlugin(*flagPostman)))
+		}
+		if *flagHTTPFile != "" {
+			options = append(options, api.AddPlugin(restgen.NewHTTPFilePlugin(*flagHTTPFile)))
+		}
+		err = api.Generate(cfg, options...)
 
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err.Error())
@@ -49,7 +85,7 @@ func main() {
 			os.Exit(2)
 		}
 		err = api.Generate(cfg,
-			api.AddPlugin(restgen.NewDocPlugin("graph/generated/rest.yaml", "YAML", *flagPublish)), //this is the magic line
+			api.AddPlugin(restgen.NewDocPlugin(*flagDocOutput, "YAML", *flagPublish)), //this is the magic line
 		)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err.Error())
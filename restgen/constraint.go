@@ -0,0 +1,90 @@
+package restgen
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen/codegen"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// FieldConstraint 描述参数或输入字段的校验规则，Literal 为生成的 handlerx.Constraint 字面量
+type FieldConstraint struct {
+	Name    string
+	Literal string
+}
+
+// GetArgumentConstraints 返回操作参数的校验规则
+func GetArgumentConstraints(field *codegen.Field) []*FieldConstraint {
+	constraints := make([]*FieldConstraint, 0)
+	for _, arg := range field.Arguments {
+		if literal := GetConstraint(arg.Name, arg.Directives); literal != "" {
+			constraints = append(constraints, &FieldConstraint{Name: arg.Name, Literal: literal})
+		}
+	}
+	return constraints
+}
+
+// GetInputConstraints 返回输入类型字段的校验规则
+func GetInputConstraints(object *codegen.Object) []*FieldConstraint {
+	constraints := make([]*FieldConstraint, 0)
+	for _, field := range object.Definition.Fields {
+		if literal := GetConstraint(field.Name, field.Directives); literal != "" {
+			constraints = append(constraints, &FieldConstraint{Name: field.Name, Literal: literal})
+		}
+	}
+	return constraints
+}
+
+// GetConstraint 将 @constraintString、@constraintNumber、@constraintSlice、@constraintStringSlice 指令
+// 转换为 handlerx.Constraint 字面量，没有约束时返回空字符串
+func GetConstraint(name string, directives ast.DirectiveList) string {
+	var directive *ast.Directive
+	for _, directiveName := range []string{"constraintNumber", "constraintString", "constraintSlice", "constraintStringSlice"} {
+		if directive = directives.ForName(directiveName); directive != nil {
+			break
+		}
+	}
+	if directive == nil {
+		return ""
+	}
+
+	// 与 OpenAPI 文档使用相同的解析规则，包括 format 的外部配置
+	v := (&DocPlugin{}).parseConstraintDirectiver(name, directive)
+	if pattern := directive.Arguments.ForName("pattern"); pattern != nil {
+		v.Pattern = &pattern.Value.Raw
+	}
+
+	fields := make([]string, 0)
+	if v.MinLength != nil {
+		fields = append(fields, "MinLength: handlerx.Int("+strconv.FormatInt(*v.MinLength, 10)+")")
+	}
+	if v.MaxLength != nil {
+		fields = append(fields, "MaxLength: handlerx.Int("+strconv.FormatInt(*v.MaxLength, 10)+")")
+	}
+	if v.Minimum != nil {
+		fields = append(fields, "Min: handlerx.Float("+strconv.FormatFloat(*v.Minimum, 'g', -1, 64)+")")
+	}
+	if v.Maximum != nil {
+		fields = append(fields, "Max: handlerx.Float("+strconv.FormatFloat(*v.Maximum, 'g', -1, 64)+")")
+	}
+	if v.Pattern != nil && *v.Pattern != "" {
+		if _, err := regexp.Compile(*v.Pattern); err != nil {
+			// 正则语法与 Go 不兼容时，仅保留在文档中
+			dbgPrintf("ignore pattern of %s: %v", name, err)
+		} else {
+			fields = append(fields, "Pattern: regexp.MustCompile("+strconv.Quote(*v.Pattern)+")")
+		}
+	}
+	if v.MinItems != nil {
+		fields = append(fields, "MinItems: handlerx.Int("+strconv.FormatInt(*v.MinItems, 10)+")")
+	}
+	if v.MaxItems != nil {
+		fields = append(fields, "MaxItems: handlerx.Int("+strconv.FormatInt(*v.MaxItems, 10)+")")
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return "&handlerx.Constraint{" + strings.Join(fields, ", ") + "}"
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -368,7 +369,16 @@ func (m *DocPlugin) GenerateOpenAPIDoc(yamlDir string, schema *ast.Schema, query
 			return err
 		}
 	}
-	return nil
+
+	// 生成包含全部接口的OpenAPI文档
+	allAPIs := make([]*API, 0, len(apis))
+	for _, api := range apis {
+		allAPIs = append(allAPIs, api)
+	}
+	sort.Slice(allAPIs, func(i, j int) bool {
+		return allAPIs[i].uri < allAPIs[j].uri
+	})
+	return m.saveOpenAPIDoc(m.filename, allAPIs, objects)
 }
 
 func (m *DocPlugin) saveOpenAPIDoc(yamlFile string, apis []*API, objects map[string]*Object) error {
@@ -472,23 +482,13 @@ func (m *DocPlugin) parseAPI(data *codegen.Object, apis map[string]*API, compone
 			dbgPrintf("not suppor http method:%v", method)
 		}
 
-		uris := strings.Split(uri, "/")
-		// 解析uri，获取第四个域作为tag
-		if len(uris) >= 4 {
-			obj.Tags = []string{uris[3]}
-		} else {
-			obj.Tags = []string{"default"}
-		}
+		// 解析uri，获取第四个域作为tag，可通过tag指令category域改写
+		obj.Tags = []string{GetTag(field, uri)}
 
-		// 通过tag指令category域，改写接口的tags
 		directive := field.FieldDefinition.Directives.ForName("tag")
 		if directive != nil {
 			for _, arg := range directive.Arguments {
-				if arg.Name == "category" {
-					category := arg.Value.String()
-					category = strings.ReplaceAll(category, "\"", "")
-					obj.Tags = []string{category}
-				} else if arg.Name == "versions" {
+				if arg.Name == "versions" {
 					if !m.isPublished {
 						// 非发布API，才到处HCI版本
 						value := strings.ReplaceAll(arg.Value.String(), "[", "")
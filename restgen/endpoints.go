@@ -0,0 +1,95 @@
+package restgen
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/99designs/gqlgen/codegen"
+)
+
+// Endpoint 描述一个由 @http 指令导出的 REST 接口
+type Endpoint struct {
+	Method     string // HTTP 方法，如 GET
+	URL        string // 接口地址，如 /api/v1/todos/{id}
+	Operation  string // 对应的 GraphQL 操作名
+	IsMutation bool
+	// IsSubscription 订阅接口，以 SSE（text/event-stream）推送
+	IsSubscription bool
+	Field          *codegen.Field
+}
+
+var pathParamRegexp = regexp.MustCompile(`\{(\w+)\}`)
+
+// PathParams 返回 URL 中的路径参数
+func (e *Endpoint) PathParams() []string {
+	params := make([]string, 0)
+	for _, match := range pathParamRegexp.FindAllStringSubmatch(e.URL, -1) {
+		params = append(params, match[1])
+	}
+	return params
+}
+
+// ColonURL 返回冒号风格的接口地址，如 /api/v1/todos/:id，用于 gin、echo 等路由框架
+func (e *Endpoint) ColonURL() string {
+	return pathParamRegexp.ReplaceAllString(e.URL, ":$1")
+}
+
+// Methods 返回接口注册的 HTTP 方法，GET 接口同时注册 HEAD，订阅接口除外
+func (e *Endpoint) Methods() []string {
+	if e.Method == "GET" && !e.IsSubscription {
+		return []string{"GET", "HEAD"}
+	}
+	return []string{e.Method}
+}
+
+// HasBody 判断请求参数是否通过 body 传递
+func (e *Endpoint) HasBody() bool {
+	return e.Method == "POST" || e.Method == "PUT" || e.Method == "PATCH"
+}
+
+// CollectEndpoints 收集全部导出的 REST 接口，按 URL 和方法排序
+func CollectEndpoints(data *codegen.Data) []*Endpoint {
+	endpoints := make([]*Endpoint, 0)
+	endpoints = appendEndpoints(endpoints, data.QueryRoot, "GET", false)
+	endpoints = appendEndpoints(endpoints, data.MutationRoot, "POST", true)
+	for _, endpoint := range appendEndpoints(nil, data.SubscriptionRoot, "GET", false) {
+		endpoint.Method = "GET" // 订阅只支持 GET
+		endpoint.IsSubscription = true
+		endpoints = append(endpoints, endpoint)
+	}
+
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		if endpoints[i].URL != endpoints[j].URL {
+			return endpoints[i].URL < endpoints[j].URL
+		}
+		return endpoints[i].Method < endpoints[j].Method
+	})
+	return endpoints
+}
+
+func appendEndpoints(endpoints []*Endpoint, object *codegen.Object, defaultMethod string, isMutation bool) []*Endpoint {
+	if object == nil {
+		return endpoints
+	}
+
+	for _, field := range object.Fields {
+		if IsIgnoreField(field) {
+			continue
+		}
+		url := GetURL(field)
+		if url == "" {
+			// url为空，接口未导出，则跳过
+			continue
+		}
+
+		endpoints = append(endpoints, &Endpoint{
+			Method:     strings.ToUpper(strings.ReplaceAll(GetMethod(field, defaultMethod), "\"", "")),
+			URL:        strings.ReplaceAll(url, "\"", ""),
+			Operation:  field.Name,
+			IsMutation: isMutation,
+			Field:      field,
+		})
+	}
+	return endpoints
+}
@@ -0,0 +1,87 @@
+package restgen
+
+import (
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// exampleMaxDepth 示例值中对象的最大嵌套层数，避免递归类型无限展开
+const exampleMaxDepth = 3
+
+// ExampleValue 根据 GraphQL 类型生成示例值，优先使用默认值，
+// 字符串的示例值为其名称，用于 Postman 接口集合的请求参数、Mock 服务的返回值等
+func ExampleValue(schema *ast.Schema, name string, typ *ast.Type, defaultValue *ast.Value) interface{} {
+	return exampleValue(schema, name, typ, defaultValue, 0)
+}
+
+func exampleValue(schema *ast.Schema, name string, typ *ast.Type, defaultValue *ast.Value, depth int) interface{} {
+	if defaultValue != nil {
+		if v, err := defaultValue.Value(nil); err == nil && v != nil {
+			return v
+		}
+	}
+	if typ.Elem != nil {
+		return []interface{}{exampleValue(schema, name, typ.Elem, nil, depth)}
+	}
+
+	switch typ.NamedType {
+	case "ID":
+		return "1"
+	case "String":
+		return name
+	case "Int":
+		return 1
+	case "Float":
+		return 1.5
+	case "Boolean":
+		return true
+	}
+
+	def := schema.Types[typ.NamedType]
+	if def == nil {
+		return nil
+	}
+	switch def.Kind {
+	case ast.Enum:
+		if len(def.EnumValues) > 0 {
+			return def.EnumValues[0].Name
+		}
+	case ast.Object, ast.InputObject:
+		obj := make(map[string]interface{})
+		if depth >= exampleMaxDepth {
+			return obj
+		}
+		for _, field := range def.Fields {
+			if strings.HasPrefix(field.Name, "__") || ShouldHide(field.Directives.ForName("hide")) {
+				continue
+			}
+			obj[field.Name] = exampleValue(schema, field.Name, field.Type, field.DefaultValue, depth+1)
+		}
+		return obj
+	case ast.Interface, ast.Union:
+		// 取第一个实现类型
+		if possible := schema.GetPossibleTypes(def); len(possible) > 0 {
+			return exampleValue(schema, name, &ast.Type{NamedType: possible[0].Name}, nil, depth)
+		}
+	case ast.Scalar:
+		return exampleScalarValue(def.Name)
+	}
+	return nil
+}
+
+// exampleScalarValue 返回自定义标量的示例值
+func exampleScalarValue(name string) interface{} {
+	switch name {
+	case "Time":
+		return "2006-01-02T15:04:05Z"
+	case "Map":
+		return map[string]interface{}{}
+	case "IP":
+		return "192.168.0.1"
+	case "MAC":
+		return "00:00:5e:00:53:01"
+	default:
+		return ""
+	}
+}
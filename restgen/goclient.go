@@ -0,0 +1,306 @@
+package restgen
+
+import (
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/template"
+
+	"github.com/99designs/gqlgen/codegen"
+	"github.com/99designs/gqlgen/codegen/config"
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/99designs/gqlgen/plugin"
+	"github.com/speedoops/go-gqlrest/restgen/utils"
+)
+
+const goClientFilename = "client.go"
+
+// NewGoClientPlugin 生成 Go 客户端包，输出到 dir 目录，包名为目录名，
+// 每个 REST 接口一个方法，参数和返回值使用 gqlgen 生成的模型类型
+func NewGoClientPlugin(dir string) plugin.Plugin {
+	return &GoClientPlugin{dir: dir}
+}
+
+type GoClientPlugin struct {
+	dir string
+}
+
+var _ plugin.CodeGenerator = &GoClientPlugin{}
+var _ plugin.ConfigMutator = &GoClientPlugin{}
+
+func (m *GoClientPlugin) Name() string {
+	return "go_client"
+}
+
+func (m *GoClientPlugin) MutateConfig(cfg *config.Config) error {
+	_ = syscall.Unlink(filepath.Join(m.dir, goClientFilename))
+	return nil
+}
+
+func (m *GoClientPlugin) GenerateCode(data *codegen.Data) error {
+	endpoints := make([]*Endpoint, 0)
+	for _, endpoint := range CollectEndpoints(data) {
+		if endpoint.IsSubscription {
+			continue // SSE 接口不生成方法
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	abs, err := filepath.Abs(m.dir)
+	if err != nil {
+		return err
+	}
+
+	return templates.Render(templates.Options{
+		PackageName:     utils.SanitizePackageName(filepath.Base(abs)),
+		Filename:        filepath.Join(m.dir, goClientFilename),
+		Template:        goClientTemplate,
+		Data:            endpoints,
+		GeneratedHeader: true,
+		Packages:        data.Config.Packages,
+		Funcs: template.FuncMap{
+			"comment": goComment,
+		},
+	})
+}
+
+// goComment 将描述转换为 Go 注释，每行以 indent 缩进
+func goComment(indent, description string) string {
+	lines := strings.Split(strings.TrimSpace(description), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(indent+"// "+strings.TrimSpace(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+const goClientTemplate = `// Code generated by github.com/speedoops/gqlrest, DO NOT EDIT.
+
+{{ reserveImport "bytes" }}
+{{ reserveImport "context" }}
+{{ reserveImport "encoding/json" }}
+{{ reserveImport "fmt" }}
+{{ reserveImport "io" }}
+{{ reserveImport "net/http" }}
+{{ reserveImport "net/url" }}
+{{ reserveImport "strings" }}
+
+` + goClientRuntime + `
+{{- range $e := . }}
+{{- $name := $e.Operation | go }}
+{{- if $e.Field.Args }}
+
+// {{ $name }}Params are the parameters of {{ $name }}
+type {{ $name }}Params struct {
+	{{- range $arg := $e.Field.Args }}
+	{{- if $arg.Description }}
+{{ comment "	" $arg.Description }}
+	{{- end }}
+	{{ $arg.Name | go }} {{ $arg.TypeReference.GO | ref }} ` + "`" + `json:"{{ $arg.Name }}{{ if not $arg.Type.NonNull }},omitempty{{ end }}"` + "`" + `
+	{{- end }}
+}
+{{- end }}
+
+{{ if $e.Field.Description -}}
+{{ comment "" $e.Field.Description }}
+//
+{{ end -}}
+// {{ $name }} calls {{ $e.Method }} {{ $e.URL }}
+func (c *Client) {{ $name }}(ctx context.Context{{ if $e.Field.Args }}, params *{{ $name }}Params{{ end }}) ({{ $e.Field.TypeReference.GO | ref }}, error) {
+	var result {{ $e.Field.TypeReference.GO | ref }}
+	err := c.do(ctx, "{{ $e.Method }}", "{{ $e.URL }}", {{ if $e.Field.Args }}params{{ else }}nil{{ end }}, {{ $e.HasBody }}, &result)
+	return result, err
+}
+{{- end }}
+`
+
+// goClientRuntime is the code of the generated client shared by the endpoint methods, without their imports
+const goClientRuntime = `// Error is the error of a RESTful response whose envelope code isn't the success code
+type Error struct {
+	StatusCode int    // the HTTP status
+	Code       int    // the envelope code
+	Message    string // the envelope message
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("code %d: %s", e.Code, e.Message)
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client sending the requests, defaults to http.DefaultClient
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithHeader sets a header of every request, eg. Authorization
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Set(key, value)
+	}
+}
+
+// WithSuccessCode sets the envelope code of successful responses, defaults to 0
+func WithSuccessCode(code int) Option {
+	return func(c *Client) {
+		c.successCode = code
+	}
+}
+
+// Client is a client of the RESTful endpoints
+type Client struct {
+	baseURL     string
+	httpClient  *http.Client
+	header      http.Header
+	successCode int
+}
+
+// NewClient returns a client of the RESTful endpoints served at baseURL, eg. "http://localhost:8080/api/v1"
+func NewClient(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		header:     make(http.Header),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// do sends a RESTful request, path parameters are taken from the parameters of the same name or the
+// fields of ` + "`input`" + `, the other parameters are sent in the JSON body or the query string, see addQueryValue
+func (c *Client) do(ctx context.Context, method, path string, params interface{}, hasBody bool, result interface{}) error {
+	var body []byte
+	values := make(map[string]interface{})
+	if params != nil {
+		var err error
+		if body, err = json.Marshal(params); err != nil {
+			return err
+		}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&values); err != nil {
+			return err
+		}
+	}
+
+	input, _ := values["input"].(map[string]interface{})
+	used := make(map[string]bool)
+	for _, m := range []map[string]interface{}{values, input} {
+		for k, v := range m {
+			placeholder := "{" + k + "}"
+			if strings.Contains(path, placeholder) {
+				path = strings.ReplaceAll(path, placeholder, url.PathEscape(queryValue(v)))
+				used[k] = true
+			}
+		}
+	}
+
+	var reader io.Reader
+	query := make(url.Values)
+	if hasBody {
+		if params != nil {
+			reader = bytes.NewReader(body)
+		}
+	} else {
+		for k, v := range values {
+			if used[k] {
+				continue
+			}
+			if k == "input" && input != nil {
+				// the fields of input in the bracket notation, eg. "input[text]=x"
+				for ik, iv := range input {
+					if !used[ik] {
+						addQueryValue(query, "input["+ik+"]", iv, true)
+					}
+				}
+				continue
+			}
+			addQueryValue(query, k, v, false)
+		}
+	}
+
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	if hasBody {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	var envelope struct {
+		Code    int             ` + "`" + `json:"code"` + "`" + `
+		Message string          ` + "`" + `json:"message"` + "`" + `
+		Data    json.RawMessage ` + "`" + `json:"data"` + "`" + `
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return &Error{StatusCode: resp.StatusCode, Code: resp.StatusCode, Message: resp.Status}
+	}
+	if envelope.Code != c.successCode {
+		return &Error{StatusCode: resp.StatusCode, Code: envelope.Code, Message: envelope.Message}
+	}
+	if len(envelope.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(envelope.Data, result)
+}
+
+// addQueryValue adds the query parameter key of value v, objects are sent in the bracket notation, eg.
+// "filter[state]=DONE", and lists as repeated parameters, eg. "ids=1&ids=2" or "filter[tags][]=a" when nested.
+// The objects of lists can't be sent in the bracket notation, they're JSON encoded.
+func addQueryValue(query url.Values, key string, v interface{}, nested bool) {
+	switch vv := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		for k, fv := range vv {
+			addQueryValue(query, key+"["+k+"]", fv, true)
+		}
+	case []interface{}:
+		if nested {
+			key += "[]"
+		}
+		for _, item := range vv {
+			if item != nil {
+				query.Add(key, queryValue(item))
+			}
+		}
+	default:
+		query.Add(key, queryValue(vv))
+	}
+}
+
+// queryValue formats a parameter as a query or path parameter, objects and lists are JSON encoded
+func queryValue(v interface{}) string {
+	switch vv := v.(type) {
+	case string:
+		return vv
+	case json.Number:
+		return vv.String()
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(vv)
+		return string(b)
+	default:
+		return fmt.Sprint(vv)
+	}
+}
+`
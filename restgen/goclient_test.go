@@ -0,0 +1,212 @@
+package restgen
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/go-chi/chi/v5"
+	"github.com/speedoops/go-gqlrest/handlerx"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// clientRequest is a request sent by a generated client, and the GraphQL query it's mapped to
+type clientRequest struct {
+	Method   string
+	Path     string
+	Params   json.RawMessage
+	Query    url.Values
+	Contains []string
+}
+
+// clientRequests are the requests of the generated client tests, over the routes of setupClientMapping
+var clientRequests = []clientRequest{
+	{
+		Method: http.MethodGet,
+		Path:   "/todos/search",
+		Params: json.RawMessage(`{"input":{"text":"x","done":true,"tags":["a","b"],"owner":{"id":"7"}}}`),
+		Query: url.Values{
+			"input[text]": {"x"}, "input[done]": {"true"}, "input[tags][]": {"a", "b"}, "input[owner][id]": {"7"},
+		},
+		Contains: []string{"searchTodos(", "input:{", `text:"x"`, "done:true", `tags:["a","b"]`, `owner:{id:"7"}`},
+	},
+	{
+		Method:   http.MethodDelete,
+		Path:     "/todos/{id}",
+		Params:   json.RawMessage(`{"input":{"id":"1","text":"x"}}`),
+		Query:    url.Values{"input[text]": {"x"}},
+		Contains: []string{"deleteTodo(", `id:"1"`, `text:"x"`},
+	},
+	{
+		Method:   http.MethodGet,
+		Path:     "/todos",
+		Params:   json.RawMessage(`{"ids":["1","2"],"limit":2}`),
+		Query:    url.Values{"ids": {"1", "2"}, "limit": {"2"}},
+		Contains: []string{"todos(", `ids:["1","2"]`, "limit:2"},
+	},
+}
+
+// setupClientMapping maps the operations of clientRequests
+func setupClientMapping() {
+	handlerx.SetupHTTP2GraphQLMapping(
+		handlerx.StringMap{"GET:/todos/search": "searchTodos", "DELETE:/todos/{id}": "deleteTodo", "GET:/todos": "todos"},
+		handlerx.StringMap{"searchTodos": "{id}", "deleteTodo": "{id}", "todos": "{id}"},
+		handlerx.ArgTypeMap{
+			"searchTodos": {"input": "TodoSearchInput!"},
+			"deleteTodo":  {"input": "DeleteTodoInput!"},
+			"todos":       {"ids": "[ID!]", "limit": "Int"},
+		},
+		handlerx.ArgTypeMap{
+			"TodoSearchInput": {"text": "String", "done": "Boolean", "tags": "[String!]", "owner": "OwnerFilter"},
+			"OwnerFilter":     {"id": "ID"},
+			"DeleteTodoInput": {"id": "ID!", "text": "String"},
+		},
+		handlerx.StringMap{"TodoSearchInput": "INPUT_OBJECT", "OwnerFilter": "INPUT_OBJECT", "DeleteTodoInput": "INPUT_OBJECT"},
+	)
+}
+
+// queryExecutor records the queries of the executed operations
+type queryExecutor struct {
+	queries []string
+}
+
+func (e *queryExecutor) CreateOperationContext(ctx context.Context, params *graphql.RawParams) (*graphql.OperationContext, gqlerror.List) {
+	e.queries = append(e.queries, params.Query)
+	op := &ast.OperationDefinition{Operation: ast.Mutation}
+	if strings.HasPrefix(params.Query, "query") {
+		op.Operation = ast.Query
+	}
+	return &graphql.OperationContext{RawQuery: params.Query, Variables: params.Variables, Operation: op, Doc: &ast.QueryDocument{Operations: ast.OperationList{op}}}, nil
+}
+
+func (e *queryExecutor) DispatchOperation(ctx context.Context, rc *graphql.OperationContext) (graphql.ResponseHandler, context.Context) {
+	return func(ctx context.Context) *graphql.Response {
+		return &graphql.Response{Data: json.RawMessage(`{"ok":null}`)}
+	}, ctx
+}
+
+func (e *queryExecutor) DispatchError(ctx context.Context, list gqlerror.List) *graphql.Response {
+	return &graphql.Response{Errors: list}
+}
+
+// assertClientRequests checks the requests sent by a generated client for clientRequests, eg. "GET /todos?limit=2",
+// and the GraphQL queries handlerx maps them to
+func assertClientRequests(t *testing.T, sent []string) {
+	setupClientMapping()
+	exec := &queryExecutor{}
+	router := chi.NewRouter()
+	router.Get("/todos/search", func(w http.ResponseWriter, r *http.Request) { handlerx.GET{}.Do(w, r, exec) })
+	router.Get("/todos", func(w http.ResponseWriter, r *http.Request) { handlerx.GET{}.Do(w, r, exec) })
+	router.Delete("/todos/{id}", func(w http.ResponseWriter, r *http.Request) { handlerx.DELETE{}.Do(w, r, exec) })
+
+	if !assert.Len(t, sent, len(clientRequests)) {
+		return
+	}
+	for i, tt := range clientRequests {
+		t.Run(tt.Method+" "+tt.Path, func(t *testing.T) {
+			method, target := sent[i][:strings.IndexByte(sent[i], ' ')], sent[i][strings.IndexByte(sent[i], ' ')+1:]
+			u, err := url.Parse(target)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.Method, method)
+			assert.Equal(t, strings.ReplaceAll(tt.Path, "{id}", "1"), u.Path)
+			assert.Equal(t, tt.Query, u.Query())
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+			assert.Equal(t, `{"code":0,"data":null}`, w.Body.String())
+			if assert.Len(t, exec.queries, i+1) {
+				for _, c := range tt.Contains {
+					assert.Contains(t, exec.queries[i], c)
+				}
+			}
+		})
+	}
+}
+
+const goClientTestHarness = `package clienttest
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRequests(t *testing.T) {
+	var sent []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.Method+" "+r.URL.String())
+		_, _ = w.Write([]byte(` + "`" + `{"code":0,"data":null}` + "`" + `))
+	}))
+	defer ts.Close()
+
+	var requests []struct {
+		Method string
+		Path   string
+		Params json.RawMessage
+	}
+	if err := json.Unmarshal([]byte(os.Getenv("CLIENT_REQUESTS")), &requests); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(ts.URL)
+	for _, r := range requests {
+		var result interface{}
+		if err := c.do(context.Background(), r.Method, r.Path, r.Params, false, &result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(os.Getenv("CLIENT_SENT"), []byte(strings.Join(sent, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+`
+
+func TestGoClientQuery(t *testing.T) {
+	goCmd := filepath.Join(runtime.GOROOT(), "bin", "go")
+	if _, err := os.Stat(goCmd); err != nil {
+		t.Skip("go command not found")
+	}
+
+	// the runtime of the generated client, in a package of its own
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module clienttest\n\ngo 1.16\n",
+		"client.go": "package clienttest\n\nimport (\n" +
+			"\t\"bytes\"\n\t\"context\"\n\t\"encoding/json\"\n\t\"fmt\"\n\t\"io\"\n\t\"net/http\"\n\t\"net/url\"\n\t\"strings\"\n)\n\n" +
+			goClientRuntime,
+		"client_test.go": goClientTestHarness,
+	}
+	for name, content := range files {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	requests, err := json.Marshal(clientRequests)
+	assert.NoError(t, err)
+	sentFile := filepath.Join(dir, "sent.txt")
+	cmd := exec.Command(goCmd, "test", "-count=1", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "CLIENT_REQUESTS="+string(requests), "CLIENT_SENT="+sentFile, "GOFLAGS=", "GOTOOLCHAIN=local")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("generated client: %v\n%s", err, out)
+	}
+
+	sent, err := ioutil.ReadFile(sentFile)
+	assert.NoError(t, err)
+	assertClientRequests(t, strings.Split(string(sent), "\n"))
+}
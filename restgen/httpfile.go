@@ -0,0 +1,94 @@
+package restgen
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/99designs/gqlgen/codegen"
+	"github.com/99designs/gqlgen/codegen/config"
+	"github.com/99designs/gqlgen/plugin"
+	"github.com/speedoops/go-gqlrest/restgen/utils"
+)
+
+// NewHTTPFilePlugin 生成 VS Code REST Client / JetBrains HTTP Client 格式的 .http 文件，输出到 filename，
+// 每个 REST 接口一个请求，baseUrl 和 token 为文件变量，请求参数为与 Postman 接口集合相同的示例值
+func NewHTTPFilePlugin(filename string) plugin.Plugin {
+	return &HTTPFilePlugin{filename: filename}
+}
+
+type HTTPFilePlugin struct {
+	filename string
+}
+
+var _ plugin.CodeGenerator = &HTTPFilePlugin{}
+var _ plugin.ConfigMutator = &HTTPFilePlugin{}
+
+func (m *HTTPFilePlugin) Name() string {
+	return "httpfile"
+}
+
+func (m *HTTPFilePlugin) MutateConfig(cfg *config.Config) error {
+	_ = syscall.Unlink(m.filename)
+	return nil
+}
+
+func (m *HTTPFilePlugin) GenerateCode(data *codegen.Data) error {
+	name := "REST API"
+	if wd, err := os.Getwd(); err == nil {
+		name = utils.SanitizePackageName(wd)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s\n", name)
+	buf.WriteString("# Code generated by github.com/speedoops/go-gqlrest, DO NOT EDIT.\n\n")
+	buf.WriteString("@baseUrl = http://localhost:8080\n")
+	buf.WriteString("@token = \n")
+
+	for _, endpoint := range CollectEndpoints(data) {
+		params := exampleParamsFor(data.Schema, endpoint)
+
+		buf.WriteString("\n### " + endpoint.Operation + "\n")
+		for _, line := range strings.Split(strings.TrimSpace(endpoint.Field.Description), "\n") {
+			if line != "" {
+				buf.WriteString("# " + line + "\n")
+			}
+		}
+
+		// 路径参数替换为示例值
+		target := endpoint.URL
+		for _, p := range params.Path {
+			target = strings.Replace(target, "{"+p.Key+"}", url.PathEscape(p.Value), 1)
+		}
+		for i, q := range params.Query {
+			sep := "&"
+			if i == 0 {
+				sep = "?"
+			}
+			target += sep + url.QueryEscape(q.Key) + "=" + url.QueryEscape(q.Value)
+		}
+		fmt.Fprintf(&buf, "%s {{baseUrl}}%s\n", endpoint.Method, target)
+
+		accept := "application/json"
+		if endpoint.IsSubscription {
+			accept = "text/event-stream"
+		}
+		buf.WriteString("Accept: " + accept + "\n")
+		buf.WriteString("Authorization: Bearer {{token}}\n")
+		if params.Body != nil {
+			buf.WriteString("Content-Type: application/json\n\n")
+			buf.Write(params.Body)
+			buf.WriteString("\n")
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.filename), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(m.filename, buf.Bytes(), 0644)
+}
@@ -0,0 +1,111 @@
+package restgen
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"syscall"
+
+	"github.com/99designs/gqlgen/codegen"
+	"github.com/99designs/gqlgen/codegen/config"
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/99designs/gqlgen/plugin"
+	"github.com/speedoops/go-gqlrest/restgen/utils"
+)
+
+// NewMockServerPlugin 生成 REST 接口的 Mock 服务，输出到 filename，
+// 每个接口返回根据 GraphQL 返回类型生成的示例数据，以标准信封格式包装，不执行 GraphQL
+func NewMockServerPlugin(filename string) plugin.Plugin {
+	return &MockServerPlugin{filename: filename}
+}
+
+type MockServerPlugin struct {
+	filename string
+}
+
+var _ plugin.CodeGenerator = &MockServerPlugin{}
+var _ plugin.ConfigMutator = &MockServerPlugin{}
+
+func (m *MockServerPlugin) Name() string {
+	return "mock_server"
+}
+
+func (m *MockServerPlugin) MutateConfig(cfg *config.Config) error {
+	_ = syscall.Unlink(m.filename)
+	return nil
+}
+
+// mockEndpoint Mock 接口及其示例数据
+type mockEndpoint struct {
+	*Endpoint
+
+	Example string
+}
+
+func (m *MockServerPlugin) GenerateCode(data *codegen.Data) error {
+	endpoints := make([]*mockEndpoint, 0)
+	examples := make(map[string]string)
+	for _, endpoint := range CollectEndpoints(data) {
+		if endpoint.IsSubscription {
+			continue // SSE 接口不生成 Mock
+		}
+		if _, ok := examples[endpoint.Operation]; !ok {
+			b, err := json.Marshal(ExampleValue(data.Schema, endpoint.Operation, endpoint.Field.Type, nil))
+			if err != nil {
+				return err
+			}
+			examples[endpoint.Operation] = string(b)
+		}
+		endpoints = append(endpoints, &mockEndpoint{Endpoint: endpoint, Example: examples[endpoint.Operation]})
+	}
+
+	abs, err := filepath.Abs(m.filename)
+	if err != nil {
+		return err
+	}
+
+	return templates.Render(templates.Options{
+		PackageName:     utils.NameForDir(filepath.Dir(abs)),
+		Filename:        m.filename,
+		Template:        mockServerTemplate,
+		Data:            endpoints,
+		GeneratedHeader: true,
+		Packages:        data.Config.Packages,
+	})
+}
+
+const mockServerTemplate = `// Code generated by github.com/speedoops/gqlrest, DO NOT EDIT.
+
+{{ reserveImport "encoding/json" }}
+{{ reserveImport "net/http" }}
+{{ reserveImport "github.com/go-chi/chi/v5" }}
+{{ reserveImport "github.com/speedoops/go-gqlrest/handlerx" }}
+
+// RESTMockResponses are the canned ` + "`data`" + ` of the mock RESTful endpoints, keyed by operation name
+var RESTMockResponses = map[string]json.RawMessage{
+	{{- range $e := . }}
+	"{{ $e.Operation }}": json.RawMessage({{ rawQuote $e.Example }}),
+	{{- end }}
+}
+
+// NewRESTMockServer returns a mock server of the RESTful endpoints, each responding its canned example
+// data of RESTMockResponses in the RESTful envelope without a GraphQL backend. The data of the operations
+// in overrides replace their canned data.
+func NewRESTMockServer(prefix string, overrides map[string]json.RawMessage) http.Handler {
+	SetupRESTMapping(prefix)
+
+	data := func(opName string) json.RawMessage {
+		if v, ok := overrides[opName]; ok {
+			return v
+		}
+		return RESTMockResponses[opName]
+	}
+
+	r := chi.NewRouter()
+	{{- range $e := . }}
+	{{- range $method := $e.Methods }}
+	r.Method("{{ $method }}", prefix+"{{ $e.URL }}", handlerx.MockHandler("{{ $e.Operation }}", data("{{ $e.Operation }}")))
+	{{- end }}
+	{{- end }}
+	return r
+}
+`
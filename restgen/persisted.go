@@ -0,0 +1,124 @@
+package restgen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/99designs/gqlgen/codegen"
+	"github.com/99designs/gqlgen/codegen/config"
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/99designs/gqlgen/plugin"
+	"github.com/speedoops/go-gqlrest/restgen/utils"
+)
+
+// NewPersistedQueryPlugin 生成 REST 接口的持久化查询，输出到 filename，
+// 运行时 REST 请求通过哈希查找查询语句，请求参数作为变量传递
+func NewPersistedQueryPlugin(filename string) plugin.Plugin {
+	return &PersistedQueryPlugin{filename: filename}
+}
+
+type PersistedQueryPlugin struct {
+	filename string
+}
+
+var _ plugin.CodeGenerator = &PersistedQueryPlugin{}
+var _ plugin.ConfigMutator = &PersistedQueryPlugin{}
+
+func (m *PersistedQueryPlugin) Name() string {
+	return "persisted_query"
+}
+
+func (m *PersistedQueryPlugin) MutateConfig(cfg *config.Config) error {
+	_ = syscall.Unlink(m.filename)
+	return nil
+}
+
+// PersistedQuery 持久化查询
+type PersistedQuery struct {
+	Operation string
+	Hash      string
+	Query     string
+}
+
+func (m *PersistedQueryPlugin) GenerateCode(data *codegen.Data) error {
+	queries := make([]*PersistedQuery, 0)
+	seen := make(map[string]bool)
+	for _, endpoint := range CollectEndpoints(data) {
+		if endpoint.IsSubscription || seen[endpoint.Operation] {
+			continue
+		}
+		seen[endpoint.Operation] = true
+
+		query := GetPersistedQuery(data.Objects, endpoint.Field, endpoint.IsMutation)
+		queries = append(queries, &PersistedQuery{
+			Operation: endpoint.Operation,
+			Hash:      PersistedQueryHash(query),
+			Query:     query,
+		})
+	}
+
+	abs, err := filepath.Abs(m.filename)
+	if err != nil {
+		return err
+	}
+
+	return templates.Render(templates.Options{
+		PackageName:     utils.NameForDir(filepath.Dir(abs)),
+		Filename:        m.filename,
+		Template:        persistedQueryTemplate,
+		Data:            queries,
+		GeneratedHeader: true,
+		Packages:        data.Config.Packages,
+	})
+}
+
+// GetPersistedQuery 返回操作的查询语句，参数以变量传递，
+// 如 query todos($ids:[ID!],$limit:Int){todos(ids:$ids,limit:$limit){id,text}}
+func GetPersistedQuery(objects codegen.Objects, field *codegen.Field, isMutation bool) string {
+	operationType := "query"
+	if isMutation {
+		operationType = "mutation"
+	}
+
+	defs := make([]string, 0, len(field.Args))
+	args := make([]string, 0, len(field.Args))
+	for _, arg := range field.Args {
+		defs = append(defs, "$"+arg.Name+":"+arg.Type.String())
+		args = append(args, arg.Name+":$"+arg.Name)
+	}
+
+	query := operationType + " " + field.Name
+	if len(defs) > 0 {
+		query += "(" + strings.Join(defs, ",") + ")"
+	}
+	query += "{" + field.Name
+	if len(args) > 0 {
+		query += "(" + strings.Join(args, ",") + ")"
+	}
+	return query + GetSelection(&objects, field, false) + "}"
+}
+
+// PersistedQueryHash 返回查询语句的 SHA-256 哈希
+func PersistedQueryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+const persistedQueryTemplate = `// Code generated by github.com/speedoops/gqlrest, DO NOT EDIT.
+
+{{ reserveImport "github.com/speedoops/go-gqlrest/handlerx" }}
+
+func init() {
+	{{ range $query := . -}}
+		handlerx.RegisterPersistedQuery("{{ $query.Hash }}", {{ printf "%q" $query.Query }})
+	{{ end }}
+	handlerx.SetupPersistedQueries(handlerx.StringMap{
+		{{ range $query := . -}}
+			"{{ $query.Operation }}": "{{ $query.Hash }}",
+		{{ end -}}
+	})
+}
+`
@@ -0,0 +1,224 @@
+package restgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/99designs/gqlgen/codegen"
+	"github.com/99designs/gqlgen/codegen/config"
+	"github.com/99designs/gqlgen/plugin"
+	"github.com/speedoops/go-gqlrest/restgen/utils"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const postmanSchemaURL = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// NewPostmanPlugin 生成 Postman v2.1 接口集合，输出到 filename，
+// 每个 REST 接口一个请求，按资源分组，请求参数为根据 GraphQL 类型生成的示例值
+func NewPostmanPlugin(filename string) plugin.Plugin {
+	return &PostmanPlugin{filename: filename}
+}
+
+type PostmanPlugin struct {
+	filename string
+}
+
+var _ plugin.CodeGenerator = &PostmanPlugin{}
+var _ plugin.ConfigMutator = &PostmanPlugin{}
+
+func (m *PostmanPlugin) Name() string {
+	return "postman"
+}
+
+func (m *PostmanPlugin) MutateConfig(cfg *config.Config) error {
+	_ = syscall.Unlink(m.filename)
+	return nil
+}
+
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Item     []*postmanFolder  `json:"item"`
+	Variable []postmanKeyValue `json:"variable"`
+}
+
+type postmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+type postmanFolder struct {
+	Name string         `json:"name"`
+	Item []*postmanItem `json:"item"`
+}
+
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Request *postmanRequest `json:"request"`
+}
+
+type postmanRequest struct {
+	Method      string            `json:"method"`
+	Description string            `json:"description,omitempty"`
+	Header      []postmanKeyValue `json:"header"`
+	Body        *postmanBody      `json:"body,omitempty"`
+	URL         *postmanURL       `json:"url"`
+}
+
+type postmanBody struct {
+	Mode    string                 `json:"mode"`
+	Raw     string                 `json:"raw"`
+	Options map[string]interface{} `json:"options"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path"`
+	Query    []postmanKeyValue `json:"query,omitempty"`
+	Variable []postmanKeyValue `json:"variable,omitempty"`
+}
+
+type postmanKeyValue struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+func (m *PostmanPlugin) GenerateCode(data *codegen.Data) error {
+	name := "REST API"
+	if wd, err := os.Getwd(); err == nil {
+		name = utils.SanitizePackageName(wd)
+	}
+	collection := &postmanCollection{
+		Info:     postmanInfo{Name: name, Schema: postmanSchemaURL},
+		Item:     make([]*postmanFolder, 0),
+		Variable: []postmanKeyValue{{Key: "baseUrl", Value: "http://localhost:8080"}},
+	}
+
+	folders := make(map[string]*postmanFolder)
+	for _, endpoint := range CollectEndpoints(data) {
+		tag := GetTag(endpoint.Field, endpoint.URL)
+		folder, ok := folders[tag]
+		if !ok {
+			folder = &postmanFolder{Name: tag}
+			folders[tag] = folder
+			collection.Item = append(collection.Item, folder)
+		}
+		folder.Item = append(folder.Item, &postmanItem{
+			Name:    endpoint.Operation,
+			Request: postmanRequestFor(data.Schema, endpoint),
+		})
+	}
+
+	b, err := json.MarshalIndent(collection, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.filename), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(m.filename, append(b, '\n'), 0644)
+}
+
+// postmanRequestFor 生成接口请求，有 body 的接口以 JSON body 传参，其余以 query 传参
+func postmanRequestFor(schema *ast.Schema, endpoint *Endpoint) *postmanRequest {
+	req := &postmanRequest{
+		Method:      endpoint.Method,
+		Description: endpoint.Field.Description,
+		Header:      []postmanKeyValue{{Key: "Accept", Value: "application/json"}},
+		URL:         &postmanURL{Host: []string{"{{baseUrl}}"}},
+	}
+	if endpoint.IsSubscription {
+		req.Header[0].Value = "text/event-stream"
+	}
+
+	params := exampleParamsFor(schema, endpoint)
+	req.URL.Variable = params.Path
+	req.URL.Query = params.Query
+	for _, segment := range strings.Split(strings.Trim(endpoint.ColonURL(), "/"), "/") {
+		req.URL.Path = append(req.URL.Path, segment)
+	}
+	if params.Body != nil {
+		req.Header = append(req.Header, postmanKeyValue{Key: "Content-Type", Value: "application/json"})
+		req.Body = &postmanBody{
+			Mode:    "raw",
+			Raw:     string(params.Body),
+			Options: map[string]interface{}{"raw": map[string]interface{}{"language": "json"}},
+		}
+	}
+
+	req.URL.Raw = "{{baseUrl}}" + endpoint.ColonURL()
+	for i, q := range req.URL.Query {
+		sep := "&"
+		if i == 0 {
+			sep = "?"
+		}
+		req.URL.Raw += sep + q.Key + "=" + q.Value
+	}
+	return req
+}
+
+// exampleParams 是接口的示例参数
+type exampleParams struct {
+	Path  []postmanKeyValue // 路径参数
+	Query []postmanKeyValue // query 参数，仅无 body 的接口
+	Body  []byte            // JSON body，仅有 body 的接口
+}
+
+// exampleParamsFor 根据 GraphQL 类型生成接口的示例参数，路径参数取自同名参数或 input 的同名域，
+// 有 body 的接口以 JSON body 传参，其余以 query 传参
+func exampleParamsFor(schema *ast.Schema, endpoint *Endpoint) *exampleParams {
+	ret := &exampleParams{}
+	params := make(map[string]interface{})
+	for _, arg := range endpoint.Field.Args {
+		params[arg.Name] = ExampleValue(schema, arg.Name, arg.Type, arg.DefaultValue)
+	}
+
+	pathParams := make(map[string]bool)
+	for _, name := range endpoint.PathParams() {
+		pathParams[name] = true
+		value, ok := params[name]
+		if input, isInput := params["input"].(map[string]interface{}); !ok && isInput {
+			value = input[name]
+		}
+		ret.Path = append(ret.Path, postmanKeyValue{Key: name, Value: postmanQueryValue(value)})
+	}
+
+	if endpoint.HasBody() {
+		ret.Body, _ = json.MarshalIndent(params, "", "  ")
+		return ret
+	}
+	for _, arg := range endpoint.Field.Args {
+		if pathParams[arg.Name] {
+			continue
+		}
+		values, ok := params[arg.Name].([]interface{})
+		if !ok {
+			values = []interface{}{params[arg.Name]}
+		}
+		for _, v := range values {
+			ret.Query = append(ret.Query, postmanKeyValue{Key: arg.Name, Value: postmanQueryValue(v), Description: arg.Description})
+		}
+	}
+	return ret
+}
+
+// postmanQueryValue 将示例值格式化为 query 或路径参数
+func postmanQueryValue(v interface{}) string {
+	switch vv := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return vv
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(vv)
+		return string(b)
+	default:
+		return fmt.Sprint(vv)
+	}
+}
@@ -165,6 +165,21 @@ func GetMethod(field *codegen.Field, defaultMethod string) string {
 	return methodValue
 }
 
+// GetTag 返回接口的分组，默认为 URL 的第四个域（如 /api/v1/todos/{id} 为 todos），可由 @tag(category) 指令改写
+func GetTag(field *codegen.Field, uri string) string {
+	tag := "default"
+	if uris := strings.Split(uri, "/"); len(uris) >= 4 {
+		tag = uris[3]
+	}
+
+	if directive := field.FieldDefinition.Directives.ForName("tag"); directive != nil {
+		if category := directive.Arguments.ForName("category"); category != nil {
+			tag = strings.ReplaceAll(category.Value.String(), "\"", "")
+		}
+	}
+	return tag
+}
+
 func StaticCheck(data *codegen.Data) {
 	for _, object := range data.Inputs {
 		if !strings.HasSuffix(object.Name, "Input") && !strings.HasSuffix(object.Name, "Spec") {
@@ -195,8 +210,9 @@ func (m *Plugin) GenerateCode(data *codegen.Data) error {
 		PackageName: pkgName,
 		Filename:    m.filename,
 		Data: &ResolverBuild{
-			Data:     data,
-			TypeName: m.typeName,
+			Data:      data,
+			TypeName:  m.typeName,
+			Endpoints: CollectEndpoints(data),
 		},
 		Funcs: template.FuncMap{
 			"getSelection": func(objects *codegen.Objects, field *codegen.Field, refer bool) string {
@@ -208,6 +224,8 @@ func (m *Plugin) GenerateCode(data *codegen.Data) error {
 			"getMethod": func(field *codegen.Field, defaultMethod string) string {
 				return GetMethod(field, defaultMethod)
 			},
+			"getArgumentConstraints": GetArgumentConstraints,
+			"getInputConstraints":    GetInputConstraints,
 		},
 		GeneratedHeader: true,
 		Packages:        data.Config.Packages,
@@ -217,5 +235,6 @@ func (m *Plugin) GenerateCode(data *codegen.Data) error {
 type ResolverBuild struct {
 	*codegen.Data
 
-	TypeName string
+	TypeName  string
+	Endpoints []*Endpoint
 }
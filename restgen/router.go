@@ -0,0 +1,213 @@
+package restgen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/99designs/gqlgen/codegen"
+	"github.com/99designs/gqlgen/codegen/config"
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/99designs/gqlgen/plugin"
+	"github.com/speedoops/go-gqlrest/restgen/utils"
+)
+
+// 支持的路由框架
+const (
+	RouterChi     = "chi"
+	RouterGin     = "gin"
+	RouterEcho    = "echo"
+	RouterNetHTTP = "net/http"
+)
+
+// routerTemplates 各路由框架的路由注册代码模板
+var routerTemplates = map[string]string{
+	RouterChi:  chiRouterTemplate,
+	RouterGin:  ginRouterTemplate,
+	RouterEcho: echoRouterTemplate,
+}
+
+// NewRouterPlugin 生成指定路由框架的路由注册代码，输出到 filename
+func NewRouterPlugin(filename string, framework string) plugin.Plugin {
+	return &RouterPlugin{filename: filename, framework: framework}
+}
+
+type RouterPlugin struct {
+	filename  string
+	framework string
+}
+
+var _ plugin.CodeGenerator = &RouterPlugin{}
+var _ plugin.ConfigMutator = &RouterPlugin{}
+
+func (m *RouterPlugin) Name() string {
+	return "restgen_router"
+}
+
+func (m *RouterPlugin) MutateConfig(cfg *config.Config) error {
+	_ = syscall.Unlink(m.filename)
+	return nil
+}
+
+func (m *RouterPlugin) GenerateCode(data *codegen.Data) error {
+	tpl, ok := routerTemplates[m.framework]
+	if !ok {
+		return fmt.Errorf("restgen: router framework %q is not supported", m.framework)
+	}
+
+	abs, err := filepath.Abs(m.filename)
+	if err != nil {
+		return err
+	}
+
+	return templates.Render(templates.Options{
+		PackageName:     utils.NameForDir(filepath.Dir(abs)),
+		Filename:        m.filename,
+		Template:        tpl,
+		Data:            newRouterBuild(data),
+		GeneratedHeader: true,
+		Packages:        data.Config.Packages,
+	})
+}
+
+type routerBuild struct {
+	*ResolverBuild
+
+	Groups []*routerGroup
+}
+
+// routerGroup 同一资源的接口，如 /api/v1/todos 和 /api/v1/todos/{id}
+type routerGroup struct {
+	Prefix string
+	Routes []*routerRoute
+}
+
+type routerRoute struct {
+	*Endpoint
+
+	Path string // 相对于分组前缀的地址
+	Slot string // 中间件插入点的字段名
+}
+
+func newRouterBuild(data *codegen.Data) *routerBuild {
+	build := &routerBuild{
+		ResolverBuild: &ResolverBuild{
+			Data:      data,
+			Endpoints: CollectEndpoints(data),
+		},
+	}
+
+	groups := make(map[string]*routerGroup)
+	for _, endpoint := range build.Endpoints {
+		prefix := groupPrefix(endpoint.URL)
+		group, ok := groups[prefix]
+		if !ok {
+			group = &routerGroup{Prefix: prefix}
+			groups[prefix] = group
+			build.Groups = append(build.Groups, group)
+		}
+
+		path := strings.TrimPrefix(endpoint.URL, prefix)
+		if path == "" {
+			path = "/"
+		}
+		group.Routes = append(group.Routes, &routerRoute{
+			Endpoint: endpoint,
+			Path:     path,
+			Slot:     utils.SanitizePackageName(strings.Title(endpoint.Operation)),
+		})
+	}
+	return build
+}
+
+// groupPrefix 返回接口所属分组的前缀，即第一个路径参数之前的部分，如 /api/v1/todos/{id} 的前缀为 /api/v1/todos
+func groupPrefix(url string) string {
+	if i := strings.Index(url, "/{"); i >= 0 {
+		return url[:i]
+	}
+	return strings.TrimSuffix(url, "/")
+}
+
+const chiRouterTemplate = `// Code generated by github.com/speedoops/gqlrest, DO NOT EDIT.
+
+{{ reserveImport "net/http" }}
+{{ reserveImport "github.com/go-chi/chi/v5" }}
+{{ reserveImport "github.com/speedoops/go-gqlrest/handlerx" }}
+
+// RESTMiddlewares are the middleware insertion points of MountRESTRoutes
+type RESTMiddlewares struct {
+	// Groups are applied to the routes of a group, keyed by the group prefix (eg. "/api/v1/todos")
+	Groups map[string][]func(http.Handler) http.Handler
+	{{ range $group := .Groups -}}
+	{{ range $route := $group.Routes }}
+	// {{ $route.Slot }} is applied to {{ $route.Method }} {{ $route.URL }}
+	{{ $route.Slot }} []func(http.Handler) http.Handler
+	{{- end }}
+	{{- end }}
+}
+
+// MountRESTRoutes registers the RESTful endpoints on a chi router, grouped by resource.
+// Methods a path isn't registered for are rejected with 405 and an Allow header.
+func MountRESTRoutes(r chi.Router, srv http.Handler, prefix string, mw RESTMiddlewares) {
+	SetupRESTMapping(prefix)
+
+	{{ range $group := .Groups -}}
+	r.Route(prefix+"{{ $group.Prefix }}", func(r chi.Router) {
+		r.Use(mw.Groups["{{ $group.Prefix }}"]...)
+		r.MethodNotAllowed(handlerx.MethodNotAllowed)
+		{{ range $route := $group.Routes -}}
+		{{ range $method := $route.Methods -}}
+		r.With(mw.{{ $route.Slot }}...).Method("{{ $method }}", "{{ $route.Path }}", srv)
+		{{ end -}}
+		{{ end -}}
+	})
+	{{ end -}}
+}
+`
+
+const ginRouterTemplate = `// Code generated by github.com/speedoops/gqlrest, DO NOT EDIT.
+
+{{ reserveImport "net/http" }}
+{{ reserveImport "github.com/gin-gonic/gin" }}
+{{ reserveImport "github.com/speedoops/go-gqlrest/handlerx" }}
+
+// RegisterGinHandlers registers the RESTful endpoints on a Gin router, eg. a *gin.Engine or *gin.RouterGroup
+func RegisterGinHandlers(r gin.IRouter, srv http.Handler, prefix string) {
+	SetupRESTMapping(prefix)
+
+	{{ range $endpoint := .Endpoints -}}
+		{{ range $method := $endpoint.Methods -}}
+		r.Handle("{{ $method }}", prefix+"{{ $endpoint.ColonURL }}", func(c *gin.Context) {
+			srv.ServeHTTP(c.Writer, handlerx.WithRoute(c.Request, prefix+"{{ $endpoint.URL }}"{{ range $param := $endpoint.PathParams }}, c.Param("{{ $param }}"){{ end }}))
+		})
+		{{ end -}}
+	{{ end -}}
+}
+`
+
+const echoRouterTemplate = `// Code generated by github.com/speedoops/gqlrest, DO NOT EDIT.
+
+{{ reserveImport "net/http" }}
+{{ reserveImport "github.com/labstack/echo/v4" }}
+{{ reserveImport "github.com/speedoops/go-gqlrest/handlerx" }}
+
+// EchoRouter is implemented by *echo.Echo and *echo.Group
+type EchoRouter interface {
+	Add(method, path string, handler echo.HandlerFunc, middleware ...echo.MiddlewareFunc) *echo.Route
+}
+
+// RegisterEchoHandlers registers the RESTful endpoints on an Echo router, eg. a *echo.Echo or *echo.Group
+func RegisterEchoHandlers(e EchoRouter, srv http.Handler, prefix string) {
+	SetupRESTMapping(prefix)
+
+	{{ range $endpoint := .Endpoints -}}
+		{{ range $method := $endpoint.Methods -}}
+		e.Add("{{ $method }}", prefix+"{{ $endpoint.ColonURL }}", func(c echo.Context) error {
+			srv.ServeHTTP(c.Response(), handlerx.WithRoute(c.Request(), prefix+"{{ $endpoint.URL }}"{{ range $param := $endpoint.PathParams }}, c.Param("{{ $param }}"){{ end }}))
+			return nil
+		})
+		{{ end -}}
+	{{ end -}}
+}
+`
@@ -0,0 +1,62 @@
+package restgen
+
+import (
+	"path/filepath"
+	"syscall"
+
+	"github.com/99designs/gqlgen/codegen"
+	"github.com/99designs/gqlgen/codegen/config"
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/99designs/gqlgen/plugin"
+	"github.com/speedoops/go-gqlrest/restgen/utils"
+)
+
+// NewSwaggerUIPlugin 生成 Swagger UI 接口文档的 handler，输出到 filename，specPath 为 OpenAPI 文档路径。
+// 未生成时，Swagger UI 的静态资源不会链接到可执行文件中
+func NewSwaggerUIPlugin(filename string, specPath string) plugin.Plugin {
+	return &SwaggerUIPlugin{filename: filename, specPath: specPath}
+}
+
+type SwaggerUIPlugin struct {
+	filename string
+	specPath string
+}
+
+var _ plugin.CodeGenerator = &SwaggerUIPlugin{}
+var _ plugin.ConfigMutator = &SwaggerUIPlugin{}
+
+func (m *SwaggerUIPlugin) Name() string {
+	return "swagger_ui"
+}
+
+func (m *SwaggerUIPlugin) MutateConfig(cfg *config.Config) error {
+	_ = syscall.Unlink(m.filename)
+	return nil
+}
+
+func (m *SwaggerUIPlugin) GenerateCode(data *codegen.Data) error {
+	abs, err := filepath.Abs(m.filename)
+	if err != nil {
+		return err
+	}
+
+	return templates.Render(templates.Options{
+		PackageName:     utils.NameForDir(filepath.Dir(abs)),
+		Filename:        m.filename,
+		Template:        swaggerUITemplate,
+		Data:            m.specPath,
+		GeneratedHeader: true,
+		Packages:        data.Config.Packages,
+	})
+}
+
+const swaggerUITemplate = `// Code generated by github.com/speedoops/gqlrest, DO NOT EDIT.
+
+{{ reserveImport "net/http" }}
+{{ reserveImport "github.com/speedoops/go-gqlrest/handlerx" }}
+
+// SwaggerUIHandler serves Swagger UI for the generated OpenAPI spec, mount it on a directory, eg. "/docs"
+func SwaggerUIHandler() http.Handler {
+	return handlerx.SwaggerUIHandler({{ printf "%q" . }})
+}
+`
@@ -0,0 +1,361 @@
+package restgen
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"text/template"
+
+	"github.com/99designs/gqlgen/codegen"
+	"github.com/99designs/gqlgen/codegen/config"
+	"github.com/99designs/gqlgen/plugin"
+	"github.com/speedoops/go-gqlrest/restgen/utils"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const tsClientFilename = "client.ts"
+
+// NewTSClientPlugin 生成 TypeScript 客户端，输出到 dir 目录
+func NewTSClientPlugin(dir string) plugin.Plugin {
+	return &TSClientPlugin{dir: dir}
+}
+
+type TSClientPlugin struct {
+	dir string
+}
+
+var _ plugin.CodeGenerator = &TSClientPlugin{}
+var _ plugin.ConfigMutator = &TSClientPlugin{}
+
+func (m *TSClientPlugin) Name() string {
+	return "ts_client"
+}
+
+func (m *TSClientPlugin) MutateConfig(cfg *config.Config) error {
+	_ = syscall.Unlink(filepath.Join(m.dir, tsClientFilename))
+	return nil
+}
+
+func (m *TSClientPlugin) GenerateCode(data *codegen.Data) error {
+	build := &tsClientBuild{}
+
+	// 1. 类型定义
+	names := make([]string, 0, len(data.Schema.Types))
+	for name := range data.Schema.Types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		typ := data.Schema.Types[name]
+		if typ.BuiltIn || strings.HasPrefix(typ.Name, "__") || isRootType(data.Schema, typ) {
+			continue
+		}
+		if decl := tsTypeDeclFor(data.Schema, typ); decl != nil {
+			build.Types = append(build.Types, decl)
+		}
+	}
+
+	// 2. 接口定义
+	for _, endpoint := range CollectEndpoints(data) {
+		if endpoint.IsSubscription {
+			continue // SSE 接口使用 EventSource，不生成 fetch 方法
+		}
+		e := &tsEndpoint{
+			Name:        endpoint.Operation,
+			Method:      endpoint.Method,
+			URL:         endpoint.URL,
+			Description: endpoint.Field.Description,
+			ResultType:  tsType(endpoint.Field.Type),
+			HasBody:     endpoint.HasBody(),
+		}
+		if len(endpoint.Field.Args) > 0 {
+			e.ParamsType = utils.SanitizePackageName(strings.Title(endpoint.Operation) + "Params")
+			e.ParamsOptional = true
+			params := &tsTypeDecl{Name: e.ParamsType, Description: "Parameters of " + endpoint.Operation}
+			for _, arg := range endpoint.Field.Args {
+				params.Fields = append(params.Fields, &tsField{
+					Name:        arg.Name,
+					Type:        tsType(arg.Type),
+					Optional:    !arg.Type.NonNull,
+					Description: arg.Description,
+				})
+				if arg.Type.NonNull {
+					e.ParamsOptional = false
+				}
+			}
+			build.Types = append(build.Types, params)
+		}
+		build.Endpoints = append(build.Endpoints, e)
+	}
+
+	var buf bytes.Buffer
+	if err := tsClientTemplate.Execute(&buf, build); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.dir, os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(m.dir, tsClientFilename), buf.Bytes(), 0644)
+}
+
+type tsClientBuild struct {
+	Types     []*tsTypeDecl
+	Endpoints []*tsEndpoint
+}
+
+// tsTypeDecl 为 interface 声明（Fields）或类型别名（Alias）
+type tsTypeDecl struct {
+	Name        string
+	Description string
+	Alias       string
+	Fields      []*tsField
+}
+
+type tsField struct {
+	Name        string
+	Type        string
+	Optional    bool
+	Description string
+}
+
+type tsEndpoint struct {
+	Name           string
+	Method         string
+	URL            string
+	Description    string
+	ParamsType     string
+	ParamsOptional bool
+	ResultType     string
+	HasBody        bool
+}
+
+func isRootType(schema *ast.Schema, typ *ast.Definition) bool {
+	return typ == schema.Query || typ == schema.Mutation || typ == schema.Subscription
+}
+
+func tsTypeDeclFor(schema *ast.Schema, typ *ast.Definition) *tsTypeDecl {
+	decl := &tsTypeDecl{
+		Name:        utils.SanitizePackageName(typ.Name),
+		Description: typ.Description,
+	}
+
+	switch typ.Kind {
+	case ast.Object, ast.InputObject:
+		for _, field := range typ.Fields {
+			if strings.HasPrefix(field.Name, "__") || ShouldHide(field.Directives.ForName("hide")) {
+				continue
+			}
+			decl.Fields = append(decl.Fields, &tsField{
+				Name:        field.Name,
+				Type:        tsType(field.Type),
+				Optional:    typ.Kind == ast.InputObject && !field.Type.NonNull,
+				Description: field.Description,
+			})
+		}
+		if len(decl.Fields) == 0 {
+			decl.Alias = "Record<string, never>"
+		}
+	case ast.Enum:
+		values := make([]string, 0, len(typ.EnumValues))
+		for _, v := range typ.EnumValues {
+			values = append(values, `"`+v.Name+`"`)
+		}
+		decl.Alias = strings.Join(values, " | ")
+	case ast.Union, ast.Interface:
+		members := make([]string, 0)
+		for _, possible := range schema.GetPossibleTypes(typ) {
+			members = append(members, utils.SanitizePackageName(possible.Name))
+		}
+		if len(members) == 0 {
+			members = append(members, "unknown")
+		}
+		decl.Alias = strings.Join(members, " | ")
+	case ast.Scalar:
+		decl.Alias = tsScalarType(typ.Name)
+	default:
+		return nil
+	}
+	return decl
+}
+
+// tsScalarType 将自定义标量映射为 TypeScript 类型，如 Time/IP/MAC 均为字符串
+func tsScalarType(name string) string {
+	switch name {
+	case "Map":
+		return "Record<string, unknown>"
+	case "Any":
+		return "unknown"
+	case "Upload":
+		return "Blob"
+	default:
+		return "string"
+	}
+}
+
+// tsType 将 GraphQL 类型转换为 TypeScript 类型
+func tsType(typ *ast.Type) string {
+	var ts string
+	if typ.Elem != nil {
+		ts = tsType(typ.Elem)
+		if strings.Contains(ts, "|") {
+			ts = "(" + ts + ")"
+		}
+		ts += "[]"
+	} else {
+		switch typ.NamedType {
+		case "ID", "String":
+			ts = "string"
+		case "Int", "Float":
+			ts = "number"
+		case "Boolean":
+			ts = "boolean"
+		default:
+			ts = utils.SanitizePackageName(typ.NamedType)
+		}
+	}
+	if !typ.NonNull {
+		ts += " | null"
+	}
+	return ts
+}
+
+var tsClientTemplate = template.Must(template.New(tsClientFilename).Parse(`// Code generated by github.com/speedoops/gqlrest, DO NOT EDIT.
+
+/* eslint-disable */
+
+export interface Envelope<T> {
+  code: number
+  message?: string
+  data: T
+}
+
+export class APIError extends Error {
+  constructor(public readonly code: number, message: string) {
+    super(message)
+    this.name = 'APIError'
+  }
+}
+
+export interface ClientOptions {
+  /** Custom fetch implementation, defaults to the global fetch */
+  fetch?: typeof fetch
+  /** Extra options of every request, eg. headers */
+  init?: RequestInit
+  /** Envelope code of successful responses, defaults to 0 */
+  successCode?: number
+}
+
+{{ range $type := .Types -}}
+{{ if $type.Description }}/** {{ $type.Description }} */
+{{ end -}}
+{{ if $type.Alias -}}
+export type {{ $type.Name }} = {{ $type.Alias }}
+{{ else -}}
+export interface {{ $type.Name }} {
+{{- range $field := $type.Fields }}
+{{- if $field.Description }}
+  /** {{ $field.Description }} */
+{{- end }}
+  {{ $field.Name }}{{ if $field.Optional }}?{{ end }}: {{ $field.Type }}
+{{- end }}
+}
+{{ end }}
+{{ end -}}
+
+function isObject(value: unknown): value is Record<string, unknown> {
+  return typeof value === 'object' && value !== null && !Array.isArray(value)
+}
+
+/**
+ * Appends the query parameter key of value, objects are sent in the bracket notation, eg. "filter[state]=DONE",
+ * and arrays as repeated parameters, eg. "ids=1&ids=2" or "filter[tags][]=a" when nested.
+ * The objects of arrays can't be sent in the bracket notation, they're JSON encoded.
+ */
+function appendQuery(query: URLSearchParams, key: string, value: unknown, nested: boolean): void {
+  if (value === undefined || value === null) {
+    return
+  }
+  if (Array.isArray(value)) {
+    for (const item of value) {
+      if (item !== undefined && item !== null) {
+        query.append(nested ? key + '[]' : key, isObject(item) ? JSON.stringify(item) : String(item))
+      }
+    }
+  } else if (isObject(value)) {
+    for (const [field, item] of Object.entries(value)) {
+      appendQuery(query, key + '[' + field + ']', item, true)
+    }
+  } else {
+    query.append(key, String(value))
+  }
+}
+
+/** Returns the query string of the parameters of url which aren't path parameters */
+function buildQuery(url: string, values: Record<string, unknown>): string {
+  const query = new URLSearchParams()
+  for (const [key, value] of Object.entries(values)) {
+    if (url.includes('{' + key + '}')) {
+      continue
+    }
+    if (key === 'input' && isObject(value)) {
+      // the fields of input in the bracket notation, eg. "input[text]=x"
+      for (const [field, item] of Object.entries(value)) {
+        if (!url.includes('{' + field + '}')) {
+          appendQuery(query, 'input[' + field + ']', item, true)
+        }
+      }
+      continue
+    }
+    appendQuery(query, key, value, false)
+  }
+  return query.toString()
+}
+
+export class Client {
+  private readonly fetchFn: typeof fetch
+  private readonly init: RequestInit
+  private readonly successCode: number
+
+  constructor(private readonly baseURL: string, options: ClientOptions = {}) {
+    this.fetchFn = options.fetch ?? fetch
+    this.init = options.init ?? {}
+    this.successCode = options.successCode ?? 0
+  }
+{{ range $e := .Endpoints }}
+  {{ if $e.Description }}/** {{ $e.Description }} */
+  {{ end -}}
+  async {{ $e.Name }}({{ if $e.ParamsType }}params: {{ $e.ParamsType }}{{ if $e.ParamsOptional }} = {}{{ end }}{{ end }}): Promise<{{ $e.ResultType }}> {
+    return this.request<{{ $e.ResultType }}>('{{ $e.Method }}', '{{ $e.URL }}', {{ if $e.ParamsType }}params{{ else }}{}{{ end }}, {{ $e.HasBody }})
+  }
+{{ end }}
+  private async request<T>(method: string, url: string, params: object, hasBody: boolean): Promise<T> {
+    const values = params as Record<string, unknown>
+    const input = (values.input ?? {}) as Record<string, unknown>
+    const path = url.replace(/\{(\w+)\}/g, (_, name: string) => encodeURIComponent(String(values[name] ?? input[name] ?? '')))
+
+    const qs = hasBody ? '' : buildQuery(url, values)
+
+    const headers = new Headers(this.init.headers)
+    headers.set('Accept', 'application/json')
+    if (hasBody) {
+      headers.set('Content-Type', 'application/json')
+    }
+
+    const resp = await this.fetchFn(this.baseURL + path + (qs ? '?' + qs : ''), {
+      ...this.init,
+      method,
+      headers,
+      body: hasBody ? JSON.stringify(params) : undefined,
+    })
+    const envelope = (await resp.json()) as Envelope<T>
+    if (envelope.code !== this.successCode) {
+      throw new APIError(envelope.code, envelope.message ?? resp.statusText)
+    }
+    return envelope.data
+  }
+}
+`))
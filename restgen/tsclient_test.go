@@ -0,0 +1,51 @@
+package restgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tsAnnotations are the type annotations of the query helpers of the TypeScript client
+var tsAnnotations = regexp.MustCompile(`: (value is Record<string, unknown>|Record<string, unknown>|URLSearchParams|unknown|string|boolean|void)`)
+
+func TestTSClientQuery(t *testing.T) {
+	build := &tsClientBuild{Endpoints: []*tsEndpoint{
+		{Name: "searchTodos", Method: "GET", URL: "/todos/search", ParamsType: "SearchTodosParams", ResultType: "Todo[]"},
+	}}
+	var buf bytes.Buffer
+	assert.NoError(t, tsClientTemplate.Execute(&buf, build))
+	client := buf.String()
+	assert.Contains(t, client, "return this.request<Todo[]>('GET', '/todos/search', params, false)")
+	assert.Contains(t, client, "const qs = hasBody ? '' : buildQuery(url, values)")
+
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node not found")
+	}
+	// the query helpers of the client without their type annotations, run for clientRequests
+	start, end := strings.Index(client, "function isObject("), strings.Index(client, "export class Client")
+	if !assert.True(t, start >= 0 && end > start, "query helpers") {
+		return
+	}
+	requests, err := json.Marshal(clientRequests)
+	assert.NoError(t, err)
+	script := tsAnnotations.ReplaceAllString(client[start:end], "") + `
+for (const r of ` + string(requests) + `) {
+  const qs = buildQuery(r.Path, r.Params)
+  console.log(r.Method + ' ' + r.Path.replace('{id}', '1') + (qs ? '?' + qs : ''))
+}
+`
+	cmd := exec.Command(node, "-")
+	cmd.Stdin = strings.NewReader(script)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("query helpers: %v\n%s", err, out)
+	}
+	assertClientRequests(t, strings.Split(strings.TrimSpace(string(out)), "\n"))
+}